@@ -0,0 +1,173 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// These are the states of a selective deployment.
+const (
+	// The workload is created and scheduled to all of the selected nodes.
+	SelectiveDeploymentStateSuccess = "Success"
+
+	// Some of the selectors cannot select the requested quantity of nodes.
+	SelectiveDeploymentStatePartial = "Partial"
+
+	// No nodes are selected or the workload cannot be created, the message contains the reason.
+	SelectiveDeploymentStateFailure = "Failure"
+)
+
+// WorkloadKind is the kind of the workload created by the selective deployment.
+// +kubebuilder:validation:Enum=Deployment;DaemonSet;StatefulSet
+type WorkloadKind string
+
+const (
+	WorkloadKindDeployment  WorkloadKind = "Deployment"
+	WorkloadKindDaemonSet   WorkloadKind = "DaemonSet"
+	WorkloadKindStatefulSet WorkloadKind = "StatefulSet"
+)
+
+// SelectorGroup is the location label used to group the nodes when a quantity is given.
+// +kubebuilder:validation:Enum=City;State;Country;Continent
+type SelectorGroup string
+
+const (
+	SelectorGroupCity      SelectorGroup = "City"
+	SelectorGroupState     SelectorGroup = "State"
+	SelectorGroupCountry   SelectorGroup = "Country"
+	SelectorGroupContinent SelectorGroup = "Continent"
+)
+
+// RadiusSelector selects the nodes within a distance of a point.
+type RadiusSelector struct {
+	// Latitude of the center in decimal degrees, for example "48.8566".
+	// +kubebuilder:validation:Pattern=`^-?[0-9]{1,2}(\.[0-9]+)?$`
+	// +kubebuilder:validation:Required
+	Latitude string `json:"latitude"`
+
+	// Longitude of the center in decimal degrees, for example "2.3522".
+	// +kubebuilder:validation:Pattern=`^-?[0-9]{1,3}(\.[0-9]+)?$`
+	// +kubebuilder:validation:Required
+	Longitude string `json:"longitude"`
+
+	// Distance from the center in kilometers.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Required
+	Kilometers int `json:"kilometers"`
+}
+
+// Selector selects the nodes by the location labels set by the node labeller. All of the given criteria should
+// match for a node to be selected.
+type Selector struct {
+	// ISO codes of the countries, for example "FR" or "JP".
+	// +kubebuilder:validation:Optional
+	Countries []string `json:"countries,omitempty"`
+
+	// ISO codes of the states, for example "IDF".
+	// +kubebuilder:validation:Optional
+	States []string `json:"states,omitempty"`
+
+	// Names of the cities, the spaces are written as underscores, for example "New_York".
+	// +kubebuilder:validation:Optional
+	Cities []string `json:"cities,omitempty"`
+
+	// Names of the continents, for example "Europe" or "North_America".
+	// +kubebuilder:validation:Optional
+	Continents []string `json:"continents,omitempty"`
+
+	// Selects the nodes within a distance of a point.
+	// +kubebuilder:validation:Optional
+	Radius *RadiusSelector `json:"radius,omitempty"`
+
+	// A GeoJSON Polygon, MultiPolygon or a Feature containing one of them. The nodes inside the polygon are
+	// selected.
+	// +kubebuilder:validation:Optional
+	Polygon string `json:"polygon,omitempty"`
+
+	// Maximum number of nodes to select. If GroupBy is set the quantity is for each group, for example 2 nodes
+	// per city. If zero, all of the matching nodes are selected.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Optional
+	Quantity int `json:"quantity,omitempty"`
+
+	// Groups the matching nodes by a location label before applying the quantity.
+	// +kubebuilder:validation:Optional
+	GroupBy SelectorGroup `json:"groupBy,omitempty"`
+}
+
+// SelectiveDeploymentSpec defines the desired state of SelectiveDeployment
+type SelectiveDeploymentSpec struct {
+	// Kind of the workload to create, can be Deployment, DaemonSet or StatefulSet.
+	// +kubebuilder:validation:Required
+	Kind WorkloadKind `json:"kind"`
+
+	// The spec of the workload, for example the DeploymentSpec for a Deployment. The node affinity of the pod
+	// template is set by the controller to the selected nodes. If the replicas of a Deployment or a StatefulSet
+	// are not set, they are set to the number of the selected nodes.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:validation:Type=object
+	// +kubebuilder:validation:Required
+	Template runtime.RawExtension `json:"template"`
+
+	// The nodes selected by any of the selectors are used.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:Required
+	Selectors []Selector `json:"selectors"`
+}
+
+// SelectiveDeploymentStatus defines the observed state of SelectiveDeployment
+type SelectiveDeploymentStatus struct {
+	// The state can be Success, Partial or Failure.
+	State string `json:"state,omitempty"`
+
+	// Additional description can be located here.
+	Message string `json:"message,omitempty"`
+
+	// Names of the selected nodes.
+	Nodes []string `json:"nodes,omitempty"`
+}
+
+// SelectiveDeployment is the Schema for the selectivedeployments API
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Namespaced,shortName=sd
+// +kubebuilder:printcolumn:name="Kind",type="string",JSONPath=".spec.kind"
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type SelectiveDeployment struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SelectiveDeploymentSpec   `json:"spec,omitempty"`
+	Status SelectiveDeploymentStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// SelectiveDeploymentList contains a list of SelectiveDeployment
+type SelectiveDeploymentList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SelectiveDeployment `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SelectiveDeployment{}, &SelectiveDeploymentList{})
+}
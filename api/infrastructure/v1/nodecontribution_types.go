@@ -0,0 +1,142 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// These are the states of a node contribution.
+const (
+	// A bootstrap token is issued and the node is expected to join the cluster.
+	NodeContributionStatePending = "Pending"
+
+	// The node joined the cluster and it is ready.
+	NodeContributionStateReady = "Ready"
+
+	// The node joined the cluster but it is not ready, for example it lost its connection.
+	NodeContributionStateNotReady = "NotReady"
+
+	// The contribution cannot be processed, the message contains the reason.
+	NodeContributionStateFailed = "Failed"
+)
+
+// These labels are set on the contributed nodes.
+const (
+	// Name of the tenant contributing the node.
+	OwnerTenantLabel = "edge-net.io/owner-tenant"
+
+	// Name of the node contribution the node joined with.
+	ContributionLabel = "edge-net.io/contribution"
+
+	// Name of the person or the institution contributing the node.
+	ContributorLabel = "edge-net.io/contributor"
+)
+
+// The nodes that didn't join with a node contribution, such as the nodes added by the cluster admins, can be
+// assigned to a tenant with these annotations. The labels are then set from the annotations.
+const (
+	OwnerTenantAnnotation = "edge-net.io/owner-tenant"
+	ContributorAnnotation = "edge-net.io/contributor"
+)
+
+// SecretReference points to a secret in a namespace.
+type SecretReference struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// NodeContributionSpec defines the desired state of NodeContribution
+type NodeContributionSpec struct {
+	// Name of the tenant contributing the node.
+	// +kubebuilder:validation:Required
+	Tenant string `json:"tenant"`
+
+	// Hostname of the contributed host, the node is expected to join with this name.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`
+	// +kubebuilder:validation:Required
+	Host string `json:"host"`
+
+	// Name of the person or the institution contributing the node. Defaults to the tenant.
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Optional
+	Contributor string `json:"contributor,omitempty"`
+
+	// The public IP address of the host. If set, a node with this address is matched as well as the hostname.
+	// +kubebuilder:validation:Optional
+	ExpectedIP string `json:"expectedIP,omitempty"`
+
+	// The secret holding the SSH credentials of the host, it is used by the installer and not read by the
+	// controller.
+	// +kubebuilder:validation:Optional
+	SecretRef *SecretReference `json:"secretRef,omitempty"`
+
+	// How long the bootstrap token stays valid. A new token is issued if the node doesn't join in time.
+	// +kubebuilder:default="24h"
+	// +kubebuilder:validation:Optional
+	TokenTTL metav1.Duration `json:"tokenTTL,omitempty"`
+}
+
+// NodeContributionStatus defines the observed state of NodeContribution
+type NodeContributionStatus struct {
+	// The state can be Pending, Ready, NotReady or Failed.
+	State string `json:"state,omitempty"`
+
+	// Additional description can be located here.
+	Message string `json:"message,omitempty"`
+
+	// Name of the node joined with the contribution.
+	Node string `json:"node,omitempty"`
+
+	// Name of the secret holding the join token in the core namespace of the tenant.
+	JoinSecret string `json:"joinSecret,omitempty"`
+
+	// The time the current bootstrap token expires.
+	TokenExpiry *metav1.Time `json:"tokenExpiry,omitempty"`
+
+	// The last heartbeat of the node.
+	LastHeartbeat *metav1.Time `json:"lastHeartbeat,omitempty"`
+}
+
+// NodeContribution is the Schema for the nodecontributions API
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,shortName=nc
+// +kubebuilder:printcolumn:name="Tenant",type="string",JSONPath=".spec.tenant"
+// +kubebuilder:printcolumn:name="Host",type="string",JSONPath=".spec.host"
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type NodeContribution struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   NodeContributionSpec   `json:"spec,omitempty"`
+	Status NodeContributionStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// NodeContributionList contains a list of NodeContribution
+type NodeContributionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NodeContribution `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&NodeContribution{}, &NodeContributionList{})
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The allocation policy is a singleton, only the policy with this name is enforced.
+const AllocationPolicyName = "cluster"
+
+// TenantAllocation limits the resources allocated to a tenant.
+type TenantAllocation struct {
+	// Weight of the tenant in the fair share. A tenant can be allocated at most its weight over the sum of the
+	// weights of all of the tenants of the allocatable resources.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Optional
+	Weight int32 `json:"weight,omitempty"`
+
+	// The maximum of each resource the tenant can be allocated, regardless of the capacity of the cluster.
+	// +kubebuilder:validation:Optional
+	Cap corev1.ResourceList `json:"cap,omitempty"`
+}
+
+// AllocationPolicySpec defines how the resources of the cluster are allocated to the tenants
+type AllocationPolicySpec struct {
+	// The maximum ratio of the resources allocated to all of the tenants over the capacity of the cluster, such
+	// as "1.5" for cpu. The resources without a ratio can be allocated without a limit.
+	// +kubebuilder:validation:Optional
+	OvercommitRatios map[corev1.ResourceName]resource.Quantity `json:"overcommitRatios,omitempty"`
+
+	// Limits each tenant to its weighted share of the allocatable resources.
+	// +kubebuilder:validation:Optional
+	FairShare bool `json:"fairShare,omitempty"`
+
+	// The weight and the caps of the tenants not listed in the tenants.
+	// +kubebuilder:default={weight: 1}
+	// +kubebuilder:validation:Optional
+	DefaultTenant TenantAllocation `json:"defaultTenant,omitempty"`
+
+	// The weight and the caps of the individual tenants by their names. The fields not given are taken from the
+	// default tenant.
+	// +kubebuilder:validation:Optional
+	Tenants map[string]TenantAllocation `json:"tenants,omitempty"`
+}
+
+// Returns the allocation policy of the tenant, the default tenant policy completes the tenant specific one.
+func (p *AllocationPolicy) TenantAllocation(tenant string) TenantAllocation {
+	allocation := TenantAllocation{Weight: p.Spec.DefaultTenant.Weight, Cap: p.Spec.DefaultTenant.Cap}
+	if specific, ok := p.Spec.Tenants[tenant]; ok {
+		if specific.Weight != 0 {
+			allocation.Weight = specific.Weight
+		}
+		if specific.Cap != nil {
+			allocation.Cap = specific.Cap
+		}
+	}
+	if allocation.Weight == 0 {
+		allocation.Weight = 1
+	}
+	return allocation
+}
+
+// These are the condition types of an allocation policy.
+const (
+	// The resources allocated to the tenants exceed the allocatable resources, for example after nodes leave.
+	AllocationPolicyConditionOvercommitted = "Overcommitted"
+)
+
+// AllocationPolicyStatus defines the observed allocation of the cluster
+type AllocationPolicyStatus struct {
+	// Sum of the allocatable resources of the schedulable nodes.
+	// +kubebuilder:validation:Optional
+	Capacity corev1.ResourceList `json:"capacity,omitempty"`
+
+	// The capacity multiplied by the overcommit ratios. Only the resources with a ratio are listed.
+	// +kubebuilder:validation:Optional
+	Allocatable corev1.ResourceList `json:"allocatable,omitempty"`
+
+	// Sum of the initial requests of the tenants.
+	// +kubebuilder:validation:Optional
+	Allocated corev1.ResourceList `json:"allocated,omitempty"`
+
+	// What is left to allocate, the allocatable minus the allocated resources. Negative if overcommitted.
+	// +kubebuilder:validation:Optional
+	Headroom corev1.ResourceList `json:"headroom,omitempty"`
+
+	// Number of schedulable nodes counted in the capacity.
+	// +kubebuilder:validation:Optional
+	Nodes int32 `json:"nodes,omitempty"`
+
+	// Number of tenants counted in the allocation.
+	// +kubebuilder:validation:Optional
+	Tenants int32 `json:"tenants,omitempty"`
+
+	// +listType=map
+	// +listMapKey=type
+	// +kubebuilder:validation:Optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// AllocationPolicy is the Schema for the allocationpolicies API. The policy named "cluster" limits the initial
+// requests of the tenants at their admission, and reports the capacity, the allocation and the headroom of the
+// cluster in its status.
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:validation:XValidation:rule="self.metadata.name == 'cluster'",message="the allocation policy must be named cluster"
+// +kubebuilder:printcolumn:name="Fair Share",type="boolean",JSONPath=".spec.fairShare"
+// +kubebuilder:printcolumn:name="Nodes",type="integer",JSONPath=".status.nodes"
+// +kubebuilder:printcolumn:name="Tenants",type="integer",JSONPath=".status.tenants"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type AllocationPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AllocationPolicySpec   `json:"spec,omitempty"`
+	Status AllocationPolicyStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// AllocationPolicyList contains a list of AllocationPolicy
+type AllocationPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AllocationPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AllocationPolicy{}, &AllocationPolicyList{})
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// These are the states of a role request.
+const (
+	RoleRequestStatePending  = "Pending"
+	RoleRequestStateApproved = "Approved"
+	RoleRequestStateDenied   = "Denied"
+	RoleRequestStateExpired  = "Expired"
+)
+
+// RoleRequestSpec defines the desired state of RoleRequest
+type RoleRequestSpec struct {
+	// Name of the user or the group requesting to join the tenant.
+	// +kubebuilder:validation:MaxLength=200
+	// +kubebuilder:validation:Required
+	Subject string `json:"subject"`
+
+	// Kind of the subject, can be User or Group.
+	// +kubebuilder:validation:Enum=User;Group
+	// +kubebuilder:default=User
+	// +kubebuilder:validation:Optional
+	Kind string `json:"kind"`
+
+	// The role requested inside the tenant.
+	// +kubebuilder:validation:Required
+	Role TenantRole `json:"role"`
+
+	// Message to the tenant admins explaining the request.
+	// +kubebuilder:validation:MaxLength=500
+	// +kubebuilder:validation:Optional
+	Message string `json:"message,omitempty"`
+}
+
+// RoleRequestStatus defines the observed state of RoleRequest
+type RoleRequestStatus struct {
+	// The decision of the tenant admins, set through the status subresource so the requester cannot approve its
+	// own request. If it is empty the request is pending. Once approved, a role binding is created in the core
+	// namespace and in all of the SubNamespaces of the tenant.
+	// +kubebuilder:validation:Enum=Approved;Denied
+	// +kubebuilder:validation:Optional
+	Decision string `json:"decision,omitempty"`
+
+	// The state can be Pending, Approved, Denied or Expired.
+	State string `json:"state,omitempty"`
+
+	// Additional description can be located here.
+	Message string `json:"message,omitempty"`
+
+	// The time the request expires if it is not approved or denied before.
+	Expiry *metav1.Time `json:"expiry,omitempty"`
+}
+
+// RoleRequest is the Schema for the rolerequests API
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Namespaced,shortName=rr
+// +kubebuilder:printcolumn:name="Subject",type="string",JSONPath=".spec.subject"
+// +kubebuilder:printcolumn:name="Role",type="string",JSONPath=".spec.role"
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type RoleRequest struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// The request cannot be changed once it is created, the decision is made on this request.
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="the role request cannot be changed, create a new one"
+	Spec   RoleRequestSpec   `json:"spec,omitempty"`
+	Status RoleRequestStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RoleRequestList contains a list of RoleRequest
+type RoleRequestList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RoleRequest `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RoleRequest{}, &RoleRequestList{})
+}
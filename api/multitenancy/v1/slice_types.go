@@ -0,0 +1,82 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SliceClaimReference points to the claim a slice is created for.
+type SliceClaimReference struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// SliceSpec defines the desired state of Slice
+type SliceSpec struct {
+	// The claim the slice is created for. Only the pods of the claiming namespace can run on the nodes.
+	// +kubebuilder:validation:Required
+	ClaimRef SliceClaimReference `json:"claimRef"`
+
+	SliceProfile `json:",inline"`
+}
+
+// SliceStatus defines the observed state of Slice
+type SliceStatus struct {
+	// The state can be Pending, Bound, Expired or Failed.
+	State string `json:"state,omitempty"`
+
+	// Additional description can be located here.
+	Message string `json:"message,omitempty"`
+
+	// Names of the reserved nodes.
+	Nodes []string `json:"nodes,omitempty"`
+
+	// The time the reservation ends.
+	Expiry *metav1.Time `json:"expiry,omitempty"`
+}
+
+// Slice is the Schema for the slices API. Slices are created by the controller for the slice claims, the
+// reserved nodes are labelled and tainted with the name of the slice.
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Namespace",type="string",JSONPath=".spec.claimRef.namespace"
+// +kubebuilder:printcolumn:name="Claim",type="string",JSONPath=".spec.claimRef.name"
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="Expiry",type="date",JSONPath=".status.expiry"
+type Slice struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="the slice cannot be changed"
+	Spec   SliceSpec   `json:"spec,omitempty"`
+	Status SliceStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// SliceList contains a list of Slice
+type SliceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Slice `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Slice{}, &SliceList{})
+}
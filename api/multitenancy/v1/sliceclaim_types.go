@@ -0,0 +1,114 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// These are the states of a slice and its claim.
+const (
+	// Not enough nodes are available, the reservation is retried periodically.
+	SliceStatePending = "Pending"
+
+	// The nodes are reserved for the claiming namespace.
+	SliceStateBound = "Bound"
+
+	// The reservation ended and the nodes are released.
+	SliceStateExpired = "Expired"
+
+	// The claim cannot be satisfied, the message contains the reason.
+	SliceStateFailed = "Failed"
+)
+
+// The nodes of a slice are labelled and tainted with this key, the value is the name of the slice.
+const SliceLabel = "edge-net.io/slice"
+
+// SliceProfile describes the nodes requested by a slice claim.
+type SliceProfile struct {
+	// Only the nodes matching the selector are reserved. If empty, any node can be reserved.
+	// +kubebuilder:validation:Optional
+	NodeSelector *metav1.LabelSelector `json:"nodeSelector,omitempty"`
+
+	// Number of nodes to reserve.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Required
+	Quantity int `json:"quantity"`
+
+	// Minimum allocatable resources of each node, for example cpu: 2 and memory: 4Gi.
+	// +kubebuilder:validation:Optional
+	Resources corev1.ResourceList `json:"resources,omitempty"`
+
+	// How long the nodes are reserved once the slice is bound, for example "24h".
+	// +kubebuilder:validation:Required
+	Duration metav1.Duration `json:"duration"`
+}
+
+// SliceClaimSpec defines the desired state of SliceClaim
+type SliceClaimSpec struct {
+	SliceProfile `json:",inline"`
+}
+
+// SliceClaimStatus defines the observed state of SliceClaim
+type SliceClaimStatus struct {
+	// The state can be Pending, Bound, Expired or Failed.
+	State string `json:"state,omitempty"`
+
+	// Additional description can be located here.
+	Message string `json:"message,omitempty"`
+
+	// Name of the slice created for the claim.
+	Slice string `json:"slice,omitempty"`
+
+	// Names of the reserved nodes.
+	Nodes []string `json:"nodes,omitempty"`
+
+	// The time the reservation ends.
+	Expiry *metav1.Time `json:"expiry,omitempty"`
+}
+
+// SliceClaim is the Schema for the sliceclaims API
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Namespaced,shortName=sc
+// +kubebuilder:printcolumn:name="Quantity",type="integer",JSONPath=".spec.quantity"
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="Expiry",type="date",JSONPath=".status.expiry"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type SliceClaim struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// The profile of the slice is fixed once the claim is created, a new claim should be created to change it.
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="the slice claim cannot be changed, create a new one"
+	Spec   SliceClaimSpec   `json:"spec,omitempty"`
+	Status SliceClaimStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// SliceClaimList contains a list of SliceClaim
+type SliceClaimList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SliceClaim `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SliceClaim{}, &SliceClaimList{})
+}
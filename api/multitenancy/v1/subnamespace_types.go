@@ -32,15 +32,32 @@ type SubNamespaceSpec struct {
 	Foo string `json:"foo,omitempty"`
 }
 
+// These are the states of a SubNamespace.
+const (
+	// The namespace is created and the role bindings are set.
+	SubNamespaceStateEstablished = "Established"
+
+	// The setup of the namespace failed, the message contains the reason.
+	SubNamespaceStateFailed = "Failed"
+
+	// The SubNamespace is marked for deletion and waits for its subtree to be removed.
+	SubNamespaceStateTerminating = "Terminating"
+)
+
 // SubNamespaceStatus defines the observed state of SubNamespace
 type SubNamespaceStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// The state can be Established, Failed or Terminating.
+	State string `json:"state,omitempty"`
+
+	// Additional description can be located here.
+	Message string `json:"message,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:scope=Namespaced,shortName=sns
+// +kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.state"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 // SubNamespace is the Schema for the subnamespaces API
 type SubNamespace struct {
 	metav1.TypeMeta   `json:",inline"`
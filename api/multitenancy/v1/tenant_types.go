@@ -24,6 +24,131 @@ import (
 
 const TenantAdminRoleName = "edgenet:tenant-admin"
 
+// These are the names of the ClusterRoles bound to the tenant members other than the admins.
+const (
+	TenantOwnerRoleName   = "edgenet:tenant-owner"
+	TenantManagerRoleName = "edgenet:tenant-manager"
+	TenantMemberRoleName  = "edgenet:tenant-member"
+)
+
+// TenantRole is the role of a member inside the tenant. Each role is mapped to a ClusterRole.
+// +kubebuilder:validation:Enum=owner;admin;manager;member
+type TenantRole string
+
+const (
+	TenantRoleOwner   TenantRole = "owner"
+	TenantRoleAdmin   TenantRole = "admin"
+	TenantRoleManager TenantRole = "manager"
+	TenantRoleMember  TenantRole = "member"
+)
+
+// TenantRoles lists all of the roles, from the most privileged to the least.
+var TenantRoles = []TenantRole{TenantRoleOwner, TenantRoleAdmin, TenantRoleManager, TenantRoleMember}
+
+// Returns true if the role is at least as privileged as the other role, see TenantRoles.
+func (r TenantRole) Includes(other TenantRole) bool {
+	for _, role := range TenantRoles {
+		switch role {
+		case r:
+			return true
+		case other:
+			return false
+		}
+	}
+	return false
+}
+
+// Returns the name of the ClusterRole the tenant role is mapped to.
+func (r TenantRole) ClusterRoleName() string {
+	switch r {
+	case TenantRoleOwner:
+		return TenantOwnerRoleName
+	case TenantRoleAdmin:
+		return TenantAdminRoleName
+	case TenantRoleManager:
+		return TenantManagerRoleName
+	default:
+		return TenantMemberRoleName
+	}
+}
+
+// TenantMember is a user or a group that has a role in the tenant.
+type TenantMember struct {
+	// Name of the user, the group or the service account. The username and group prefixes configured
+	// in the controller are added to the users and the groups.
+	// +kubebuilder:validation:MaxLength=200
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// Kind of the member, can be User, Group or ServiceAccount.
+	// +kubebuilder:validation:Enum=User;Group;ServiceAccount
+	// +kubebuilder:default=User
+	// +kubebuilder:validation:Optional
+	Kind string `json:"kind"`
+
+	// Namespace of the service account, only used if the kind is ServiceAccount.
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// Role of the member inside the tenant.
+	// +kubebuilder:validation:Required
+	Role TenantRole `json:"role"`
+}
+
+// NodeSchedulingPolicy defines how the nodes contributed by a tenant are shared with the other tenants.
+// +kubebuilder:validation:Enum=Shared;Priority;Exclusive
+type NodeSchedulingPolicy string
+
+const (
+	// The nodes are used by all of the tenants the same way.
+	NodeSchedulingShared NodeSchedulingPolicy = "Shared"
+
+	// The nodes are tainted with PreferNoSchedule, the pods of the other tenants are placed on them only if
+	// there is no other node available. The pods of the tenant prefer their own nodes.
+	NodeSchedulingPriority NodeSchedulingPolicy = "Priority"
+
+	// The nodes are tainted with NoSchedule, only the pods of the tenant can run on them.
+	NodeSchedulingExclusive NodeSchedulingPolicy = "Exclusive"
+)
+
+// These are the Pod Security Admission levels enforced in the namespaces of the tenants.
+const (
+	PodSecurityLevelPrivileged = "privileged"
+	PodSecurityLevelBaseline   = "baseline"
+	PodSecurityLevelRestricted = "restricted"
+)
+
+// NamespaceDefaults are applied to the core namespace and the SubNamespaces of a tenant. The pods without any
+// requests would be rejected by the quota of the tenant, they get the default requests from a LimitRange instead.
+type NamespaceDefaults struct {
+	// The Pod Security Admission level enforced in the namespaces.
+	// +kubebuilder:validation:Enum=privileged;baseline;restricted
+	// +kubebuilder:validation:Optional
+	PodSecurityLevel string `json:"podSecurityLevel,omitempty"`
+
+	// The requests of the containers that don't specify them.
+	// +kubebuilder:validation:Optional
+	DefaultRequests corev1.ResourceList `json:"defaultRequests,omitempty"`
+
+	// The limits of the containers that don't specify them.
+	// +kubebuilder:validation:Optional
+	DefaultLimits corev1.ResourceList `json:"defaultLimits,omitempty"`
+}
+
+// LocationRestriction limits the nodes the pods of a tenant can run on by their location. A node is allowed if
+// it is in one of the countries or in one of the continents.
+type LocationRestriction struct {
+	// ISO codes of the allowed countries, matched against the country label of the nodes.
+	// +kubebuilder:validation:Optional
+	Countries []string `json:"countries,omitempty"`
+
+	// The allowed continents, matched against the continent label of the nodes. The spaces are replaced with
+	// underscores, for example "North_America".
+	// +kubebuilder:validation:Optional
+	Continents []string `json:"continents,omitempty"`
+}
+
 // TenantSpec defines the desired state of Tenant
 type TenantSpec struct {
 	// Full name of the tenant.
@@ -38,12 +163,19 @@ type TenantSpec struct {
 
 	// This is the admin username for the tenant. A role binding will be created for user with this username.
 	// The username for some cases can also be emails. This was the old method. But with different identity
-	// providers this can be any name.
+	// providers this can be any name. Groups can be given as "group:<name>" and service accounts as
+	// "serviceaccount:<namespace>:<name>". The username and group prefixes configured in the controller
+	// are added to the users and the groups.
 	// +kubebuilder:validation:MaxLength=200
 	// +kubebuilder:validation:Required
-	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-.@_a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-.@_:a-z0-9]*[a-z0-9])?$`
 	Admin string `json:"admin"`
 
+	// Members of the tenant with their roles. The admin is always considered as a member with the admin role.
+	// A role binding is created for each role in the core namespace and in all of the SubNamespaces.
+	// +kubebuilder:validation:Optional
+	Members []TenantMember `json:"members,omitempty"`
+
 	// Website of the tenant.
 	// +kubebuilder:validation:Pattern=`^(https?://)?([\da-z\.-]+)\.([a-z\.]{2,6})([/\w \.-]*)*/?$`
 	// +kubebuilder:validation:Required
@@ -59,11 +191,113 @@ type TenantSpec struct {
 	// +kubebuilder:default=false
 	// +kubebuilder:validation:Optional
 	ClusterNetworkPolicy bool `json:"clusterNetworkPolicy"`
+
+	// How the nodes contributed by the tenant are shared with the other tenants. The pods created in the
+	// namespaces of the tenant tolerate the taints of its nodes.
+	// +kubebuilder:default=Shared
+	// +kubebuilder:validation:Optional
+	NodeScheduling NodeSchedulingPolicy `json:"nodeScheduling,omitempty"`
+
+	// Restricts the pods of the tenant to the nodes in these locations, for example for data sovereignty. The
+	// node affinity is added to the pods created in the core namespace and the SubNamespaces of the tenant.
+	// +kubebuilder:validation:Optional
+	AllowedLocations *LocationRestriction `json:"allowedLocations,omitempty"`
+
+	// Overrides the namespace defaults of the cluster for the core namespace and the SubNamespaces of the tenant.
+	// The fields not given are taken from the cluster.
+	// +kubebuilder:validation:Optional
+	NamespaceDefaults *NamespaceDefaults `json:"namespaceDefaults,omitempty"`
+
+	// Suspends the tenant without deleting its data. No new pods can be created in the namespaces of the
+	// tenant and the role bindings of the members and the role requests are removed until the tenant is resumed.
+	// +kubebuilder:default=false
+	// +kubebuilder:validation:Optional
+	Suspended bool `json:"suspended,omitempty"`
+
+	// Whether the Deployments and the StatefulSets of the tenant are scaled to zero while it is suspended. The
+	// replica counts are kept in an annotation and restored when the tenant is resumed.
+	// +kubebuilder:default=false
+	// +kubebuilder:validation:Optional
+	ScaleDownOnSuspension bool `json:"scaleDownOnSuspension,omitempty"`
+
+	// The tenant is suspended automatically at this date and deleted after the grace period configured in the
+	// controller. Warnings are sent to the tenant before the date.
+	// +kubebuilder:validation:Optional
+	ExpirationDate *metav1.Time `json:"expirationDate,omitempty"`
+
+	// The tenant is suspended automatically after being inactive for this many days, then deleted after the
+	// grace period. A tenant is active while it has running pods or creates new workloads, SubNamespaces or
+	// role requests.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Optional
+	InactivityDays int32 `json:"inactivityDays,omitempty"`
+
+	// Stops the email notifications sent to the admins and the owners of the tenant.
+	// +kubebuilder:default=false
+	// +kubebuilder:validation:Optional
+	DisableNotifications bool `json:"disableNotifications,omitempty"`
+}
+
+// These are the states of a tenant.
+const (
+	TenantStateEstablished = "Established"
+	TenantStateFailed      = "Failed"
+
+	// The tenant is marked for deletion and waits for its namespaces to be removed.
+	TenantStateTerminating = "Terminating"
+
+	// The tenant is suspended, its data is kept but its members cannot use it.
+	TenantStateSuspended = "Suspended"
+)
+
+// These are the condition types of a tenant.
+const (
+	// True while the tenant is suspended. The reason tells whether the suspension is complete.
+	TenantConditionSuspended = "Suspended"
+
+	// True while the tenant uses all of its quota for at least one of the resources.
+	TenantConditionQuotaExhausted = "QuotaExhausted"
+)
+
+// These are the reasons of the Suspended condition.
+const (
+	TenantReasonSuspended = "Suspended"
+	TenantReasonActive    = "Active"
+
+	// The tenant is suspended automatically because its expiration date has passed.
+	TenantReasonExpired = "Expired"
+
+	// The tenant is suspended automatically because it has been inactive for too long.
+	TenantReasonInactive = "Inactive"
+)
+
+// These are the reasons of the QuotaExhausted condition.
+const (
+	TenantReasonQuotaExhausted = "Exhausted"
+	TenantReasonQuotaAvailable = "Available"
+)
+
+// TenantReclamation keeps the progress of the automatic suspension and deletion of an expiring or inactive
+// tenant.
+type TenantReclamation struct {
+	// Why the tenant is reclaimed, Expired or Inactive.
+	Reason string `json:"reason"`
+
+	// The tenant is suspended at this time.
+	Deadline metav1.Time `json:"deadline"`
+
+	// The shortest lead time a warning has been sent for before the deadline.
+	// +kubebuilder:validation:Optional
+	WarnedLeadTime *metav1.Duration `json:"warnedLeadTime,omitempty"`
+
+	// When the tenant was suspended by the reclamation, the grace period before the deletion starts here.
+	// +kubebuilder:validation:Optional
+	SuspendedAt *metav1.Time `json:"suspendedAt,omitempty"`
 }
 
 // TenantStatus defines the observed state of Tenant
 type TenantStatus struct {
-	// The state can be Established or Failed.
+	// The state can be Established, Failed, Suspended or Terminating.
 	State string `json:"state"`
 
 	// Additional description can be located here.
@@ -71,6 +305,20 @@ type TenantStatus struct {
 
 	// Failed sets the backoff limit.
 	Failed int `json:"failed"`
+
+	// Conditions represent the latest observations of the tenant.
+	// +listType=map
+	// +listMapKey=type
+	// +kubebuilder:validation:Optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// The last time the controller observed an activity in the namespaces of the tenant.
+	// +kubebuilder:validation:Optional
+	LastActivity *metav1.Time `json:"lastActivity,omitempty"`
+
+	// Set when the tenant has an expiration date or an inactivity policy and approaches its deadline.
+	// +kubebuilder:validation:Optional
+	Reclamation *TenantReclamation `json:"reclamation,omitempty"`
 }
 
 // Tenant is the Schema for the tenants API
@@ -80,6 +328,7 @@ type TenantStatus struct {
 // +kubebuilder:printcolumn:name="Full Name",type="string",JSONPath=".spec.fullName"
 // +kubebuilder:printcolumn:name="URL",type="string",JSONPath=".spec.url"
 // +kubebuilder:printcolumn:name="Admin",type="string",JSONPath=".spec.admin"
+// +kubebuilder:printcolumn:name="Suspended",type="boolean",JSONPath=".spec.suspended"
 type Tenant struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -0,0 +1,106 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TenantUsageReportSpec defines the tenant and the billing period of the report
+type TenantUsageReportSpec struct {
+	// The tenant whose usage is reported.
+	// +kubebuilder:validation:Required
+	Tenant string `json:"tenant"`
+
+	// Full name of the tenant, usually the institution the usage is reported for. It is copied from the tenant so
+	// the report stays readable after the tenant is deleted.
+	// +kubebuilder:validation:Optional
+	FullName string `json:"fullName,omitempty"`
+
+	// Start of the billing period, inclusive.
+	// +kubebuilder:validation:Required
+	PeriodStart metav1.Time `json:"periodStart"`
+
+	// End of the billing period, exclusive.
+	// +kubebuilder:validation:Required
+	PeriodEnd metav1.Time `json:"periodEnd"`
+}
+
+// ResourceHours is the consumption of the resources over time. The values are rounded to a thousandth.
+type ResourceHours struct {
+	// CPU-hours, the number of cores multiplied by the hours they are consumed.
+	CPU resource.Quantity `json:"cpu"`
+
+	// Memory-GB-hours, the gigabytes of memory multiplied by the hours they are consumed.
+	Memory resource.Quantity `json:"memory"`
+}
+
+// TenantUsageReportStatus defines the usage accumulated over the billing period
+type TenantUsageReportStatus struct {
+	// Accumulated from the resource requests of the running pods of the tenant.
+	// +kubebuilder:validation:Optional
+	Requested ResourceHours `json:"requested,omitempty"`
+
+	// Accumulated from the actual usage of the pods reported by metrics.k8s.io. Only the samples taken while the
+	// metrics API is available are counted, see UsageSamples.
+	// +kubebuilder:validation:Optional
+	Used ResourceHours `json:"used,omitempty"`
+
+	// Number of samples taken over the period.
+	// +kubebuilder:validation:Optional
+	Samples int32 `json:"samples,omitempty"`
+
+	// Number of samples with the actual usage.
+	// +kubebuilder:validation:Optional
+	UsageSamples int32 `json:"usageSamples,omitempty"`
+
+	// Time of the last sample, the next sample accounts for the time elapsed since then.
+	// +kubebuilder:validation:Optional
+	LastSampleTime *metav1.Time `json:"lastSampleTime,omitempty"`
+}
+
+// TenantUsageReport is the Schema for the tenantusagereports API. There is a report for each tenant and billing
+// period, named after the tenant and the month of the period such as "lab-2024-06". The reports are kept after
+// the tenants are deleted.
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster,shortName=tur
+// +kubebuilder:printcolumn:name="Tenant",type="string",JSONPath=".spec.tenant"
+// +kubebuilder:printcolumn:name="Period Start",type="date",JSONPath=".spec.periodStart"
+// +kubebuilder:printcolumn:name="CPU Hours",type="string",JSONPath=".status.requested.cpu"
+// +kubebuilder:printcolumn:name="Memory GB Hours",type="string",JSONPath=".status.requested.memory"
+type TenantUsageReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   TenantUsageReportSpec   `json:"spec,omitempty"`
+	Status TenantUsageReportStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// TenantUsageReportList contains a list of TenantUsageReport
+type TenantUsageReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []TenantUsageReport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&TenantUsageReport{}, &TenantUsageReportList{})
+}
@@ -0,0 +1,127 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// PayloadFormat is the shape of the JSON body posted to an endpoint.
+// +kubebuilder:validation:Enum=Generic;Slack;Matrix
+type PayloadFormat string
+
+const (
+	// The whole event with its data, for the generic HTTP receivers.
+	PayloadFormatGeneric PayloadFormat = "Generic"
+
+	// A message for the Slack incoming webhooks, {"text": "..."}.
+	PayloadFormatSlack PayloadFormat = "Slack"
+
+	// A text message for the Matrix webhook bridges, {"msgtype": "m.text", "body": "..."}.
+	PayloadFormatMatrix PayloadFormat = "Matrix"
+)
+
+// SecretKeyReference points to a key of a secret in a namespace.
+type SecretKeyReference struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+
+	// +kubebuilder:default=secret
+	// +kubebuilder:validation:Optional
+	Key string `json:"key,omitempty"`
+}
+
+// WebhookEndpoint is an HTTP endpoint the events are posted to.
+type WebhookEndpoint struct {
+	// Name of the endpoint, used in the events recorded for the failed deliveries.
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+
+	// URL the events are posted to.
+	// +kubebuilder:validation:Pattern=`^https?://`
+	// +kubebuilder:validation:Required
+	URL string `json:"url"`
+
+	// +kubebuilder:default=Generic
+	// +kubebuilder:validation:Optional
+	Format PayloadFormat `json:"format,omitempty"`
+
+	// The secret used to sign the payloads. If given, the X-EdgeNet-Signature header contains
+	// "sha256=" followed by the hex encoded HMAC-SHA256 of the X-EdgeNet-Timestamp header, a dot and the body.
+	// +kubebuilder:validation:Optional
+	SigningSecretRef *SecretKeyReference `json:"signingSecretRef,omitempty"`
+}
+
+// NotificationPolicySpec defines the desired state of NotificationPolicy
+type NotificationPolicySpec struct {
+	// The events posted to the endpoints, for example TenantCreated, TenantReconciliationFailed, NodeLabelled,
+	// NodeRelocated or TenantQuotaExhausted. All of the events are posted if empty.
+	// +kubebuilder:validation:Optional
+	Events []string `json:"events,omitempty"`
+
+	// The endpoints the events are posted to.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:Required
+	Endpoints []WebhookEndpoint `json:"endpoints"`
+
+	// How many times a failed delivery is retried before it is recorded as a dead letter.
+	// +kubebuilder:default=3
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=10
+	// +kubebuilder:validation:Optional
+	Retries int32 `json:"retries,omitempty"`
+}
+
+// Checks if the policy selects the event.
+func (p *NotificationPolicy) Selects(event string) bool {
+	if len(p.Spec.Events) == 0 {
+		return true
+	}
+	for _, selected := range p.Spec.Events {
+		if selected == event {
+			return true
+		}
+	}
+	return false
+}
+
+// NotificationPolicy is the Schema for the notificationpolicies API. It selects the EdgeNet events posted to the
+// HTTP endpoints such as chat webhooks. The deliveries failing after all of the retries are recorded as events on
+// the policy.
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Events",type="string",JSONPath=".spec.events"
+type NotificationPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec NotificationPolicySpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// NotificationPolicyList contains a list of NotificationPolicy
+type NotificationPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NotificationPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&NotificationPolicy{}, &NotificationPolicyList{})
+}
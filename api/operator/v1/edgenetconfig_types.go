@@ -0,0 +1,178 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+)
+
+// The configuration is a singleton, only the configuration with this name is applied.
+const EdgeNetConfigName = "edgenet"
+
+// SecretReference points to a Secret in a namespace.
+type SecretReference struct {
+	// +kubebuilder:validation:Required
+	Namespace string `json:"namespace"`
+
+	// +kubebuilder:validation:Required
+	Name string `json:"name"`
+}
+
+// MaxMindConfig defines where the geolocation of the nodes is looked up.
+type MaxMindConfig struct {
+	// The endpoint of the MaxMind city lookup, the IP address is appended to it.
+	// +kubebuilder:default="https://geoip.maxmind.com/geoip/v2.1/city/"
+	// +kubebuilder:validation:Optional
+	URL string `json:"url,omitempty"`
+
+	// The Secret holding the account id and the token of MaxMind under the maxmind_accountid and maxmind_token
+	// keys, base64 encoded as in the mounted secret.
+	// +kubebuilder:validation:Required
+	SecretRef SecretReference `json:"secretRef"`
+}
+
+// NetworkProfile defines the network policies applied to the tenants by default.
+type NetworkProfile struct {
+	// Applies the cluster network policy to all of the tenants, including the ones not requesting it in their spec.
+	// +kubebuilder:validation:Optional
+	ClusterNetworkPolicy bool `json:"clusterNetworkPolicy,omitempty"`
+}
+
+// QuotaPolicy defines the defaults of the tenant namespaces and how long the tenants keep their resources.
+type QuotaPolicy struct {
+	// The LimitRange and the Pod Security Admission level applied to the namespaces of the tenants. The tenants
+	// can override them.
+	// +kubebuilder:validation:Optional
+	NamespaceDefaults *multitenancyv1.NamespaceDefaults `json:"namespaceDefaults,omitempty"`
+
+	// The lead times the tenants are warned at before they are suspended for expiry or inactivity.
+	// +kubebuilder:validation:Optional
+	ExpiryWarnings []metav1.Duration `json:"expiryWarnings,omitempty"`
+
+	// The duration a tenant suspended for expiry or inactivity is kept before it is deleted, zero disables the
+	// deletion.
+	// +kubebuilder:validation:Optional
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
+}
+
+// NotificationSettings defines how the notifications are delivered besides the NotificationPolicies.
+type NotificationSettings struct {
+	// The Secret holding the SMTP settings of the email notifications. The emails are not sent without it.
+	// +kubebuilder:validation:Optional
+	SMTPSecretRef *SecretReference `json:"smtpSecretRef,omitempty"`
+}
+
+// EdgeNetConfigSpec defines the settings of the controllers. The sections not given are taken from the flags of
+// the controller manager.
+type EdgeNetConfigSpec struct {
+	// Reconcilers disabled in addition to the ones given by the flags, such as Tenant or NodeLabeller. The
+	// reconcilers are set up at start, a change requires a restart of the controller manager.
+	// +kubebuilder:validation:Optional
+	DisabledReconcilers []string `json:"disabledReconcilers,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	MaxMind *MaxMindConfig `json:"maxmind,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	DefaultNetworkProfile *NetworkProfile `json:"defaultNetworkProfile,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	QuotaPolicy *QuotaPolicy `json:"quotaPolicy,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	Notifications *NotificationSettings `json:"notifications,omitempty"`
+}
+
+// These are the condition types of the configuration.
+const (
+	// The settings of the spec are applied to the controllers. False if the spec is invalid or a referenced
+	// Secret cannot be read, the previous settings are kept.
+	EdgeNetConfigConditionApplied = "Applied"
+
+	// The disabled reconcilers of the spec differ from the ones running, the controller manager should be
+	// restarted.
+	EdgeNetConfigConditionRestartRequired = "RestartRequired"
+
+	// The nodes are labelled with their geolocation. False if the MaxMind credentials are not given or MaxMind
+	// rejects them.
+	EdgeNetConfigConditionGeolocation = "Geolocation"
+)
+
+// EdgeNetConfigStatus defines the settings active in the controllers
+type EdgeNetConfigStatus struct {
+	// The generation of the spec the status reflects.
+	// +kubebuilder:validation:Optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// The reconcilers running in the controller manager.
+	// +kubebuilder:validation:Optional
+	ActiveReconcilers []string `json:"activeReconcilers,omitempty"`
+
+	// Whether the nodes are labelled with their geolocation. False if MaxMind rejects the credentials, the
+	// Geolocation condition tells why.
+	// +kubebuilder:validation:Optional
+	Geolocation bool `json:"geolocation,omitempty"`
+
+	// Whether the cluster network policy is applied to all of the tenants.
+	// +kubebuilder:validation:Optional
+	ClusterNetworkPolicy bool `json:"clusterNetworkPolicy,omitempty"`
+
+	// The namespace defaults applied to the tenants not overriding them.
+	// +kubebuilder:validation:Optional
+	NamespaceDefaults *multitenancyv1.NamespaceDefaults `json:"namespaceDefaults,omitempty"`
+
+	// Whether the notifications are sent by email.
+	// +kubebuilder:validation:Optional
+	EmailNotifications bool `json:"emailNotifications,omitempty"`
+
+	// +listType=map
+	// +listMapKey=type
+	// +kubebuilder:validation:Optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// EdgeNetConfig is the Schema for the edgenetconfigs API. The configuration named "edgenet" holds the settings of
+// the controllers, they are applied at runtime without restarting the controller manager where possible.
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:validation:XValidation:rule="self.metadata.name == 'edgenet'",message="the configuration must be named edgenet"
+// +kubebuilder:printcolumn:name="Applied",type="string",JSONPath=".status.conditions[?(@.type==\"Applied\")].status"
+// +kubebuilder:printcolumn:name="Restart Required",type="string",JSONPath=".status.conditions[?(@.type==\"RestartRequired\")].status"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type EdgeNetConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   EdgeNetConfigSpec   `json:"spec,omitempty"`
+	Status EdgeNetConfigStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// EdgeNetConfigList contains a list of EdgeNetConfig
+type EdgeNetConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []EdgeNetConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&EdgeNetConfig{}, &EdgeNetConfigList{})
+}
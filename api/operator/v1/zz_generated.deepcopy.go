@@ -0,0 +1,254 @@
+//go:build !ignore_autogenerated
+
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Code generated by controller-gen. DO NOT EDIT.
+
+package v1
+
+import (
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EdgeNetConfig) DeepCopyInto(out *EdgeNetConfig) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EdgeNetConfig.
+func (in *EdgeNetConfig) DeepCopy() *EdgeNetConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(EdgeNetConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EdgeNetConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EdgeNetConfigList) DeepCopyInto(out *EdgeNetConfigList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]EdgeNetConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EdgeNetConfigList.
+func (in *EdgeNetConfigList) DeepCopy() *EdgeNetConfigList {
+	if in == nil {
+		return nil
+	}
+	out := new(EdgeNetConfigList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *EdgeNetConfigList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EdgeNetConfigSpec) DeepCopyInto(out *EdgeNetConfigSpec) {
+	*out = *in
+	if in.DisabledReconcilers != nil {
+		in, out := &in.DisabledReconcilers, &out.DisabledReconcilers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MaxMind != nil {
+		in, out := &in.MaxMind, &out.MaxMind
+		*out = new(MaxMindConfig)
+		**out = **in
+	}
+	if in.DefaultNetworkProfile != nil {
+		in, out := &in.DefaultNetworkProfile, &out.DefaultNetworkProfile
+		*out = new(NetworkProfile)
+		**out = **in
+	}
+	if in.QuotaPolicy != nil {
+		in, out := &in.QuotaPolicy, &out.QuotaPolicy
+		*out = new(QuotaPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Notifications != nil {
+		in, out := &in.Notifications, &out.Notifications
+		*out = new(NotificationSettings)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EdgeNetConfigSpec.
+func (in *EdgeNetConfigSpec) DeepCopy() *EdgeNetConfigSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EdgeNetConfigSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EdgeNetConfigStatus) DeepCopyInto(out *EdgeNetConfigStatus) {
+	*out = *in
+	if in.ActiveReconcilers != nil {
+		in, out := &in.ActiveReconcilers, &out.ActiveReconcilers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NamespaceDefaults != nil {
+		in, out := &in.NamespaceDefaults, &out.NamespaceDefaults
+		*out = new(multitenancyv1.NamespaceDefaults)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EdgeNetConfigStatus.
+func (in *EdgeNetConfigStatus) DeepCopy() *EdgeNetConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(EdgeNetConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaxMindConfig) DeepCopyInto(out *MaxMindConfig) {
+	*out = *in
+	out.SecretRef = in.SecretRef
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaxMindConfig.
+func (in *MaxMindConfig) DeepCopy() *MaxMindConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(MaxMindConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkProfile) DeepCopyInto(out *NetworkProfile) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkProfile.
+func (in *NetworkProfile) DeepCopy() *NetworkProfile {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkProfile)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NotificationSettings) DeepCopyInto(out *NotificationSettings) {
+	*out = *in
+	if in.SMTPSecretRef != nil {
+		in, out := &in.SMTPSecretRef, &out.SMTPSecretRef
+		*out = new(SecretReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationSettings.
+func (in *NotificationSettings) DeepCopy() *NotificationSettings {
+	if in == nil {
+		return nil
+	}
+	out := new(NotificationSettings)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaPolicy) DeepCopyInto(out *QuotaPolicy) {
+	*out = *in
+	if in.NamespaceDefaults != nil {
+		in, out := &in.NamespaceDefaults, &out.NamespaceDefaults
+		*out = new(multitenancyv1.NamespaceDefaults)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ExpiryWarnings != nil {
+		in, out := &in.ExpiryWarnings, &out.ExpiryWarnings
+		*out = make([]metav1.Duration, len(*in))
+		copy(*out, *in)
+	}
+	if in.GracePeriod != nil {
+		in, out := &in.GracePeriod, &out.GracePeriod
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaPolicy.
+func (in *QuotaPolicy) DeepCopy() *QuotaPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretReference) DeepCopyInto(out *SecretReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretReference.
+func (in *SecretReference) DeepCopy() *SecretReference {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretReference)
+	in.DeepCopyInto(out)
+	return out
+}
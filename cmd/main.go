@@ -17,9 +17,13 @@ limitations under the License.
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"flag"
+	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -27,21 +31,37 @@ import (
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	antreav1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
 
+	appsv1 "github.com/edgenet-project/edgenet/api/apps/v1"
+	infrastructurev1 "github.com/edgenet-project/edgenet/api/infrastructure/v1"
 	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	notificationv1 "github.com/edgenet-project/edgenet/api/notification/v1"
+	operatorv1 "github.com/edgenet-project/edgenet/api/operator/v1"
+	"github.com/edgenet-project/edgenet/internal/accounting/v1"
+	appscontroller "github.com/edgenet-project/edgenet/internal/controller/apps"
+	infrastructurecontroller "github.com/edgenet-project/edgenet/internal/controller/infrastructure"
 	labellerscontroller "github.com/edgenet-project/edgenet/internal/controller/labellers"
 	multitenancycontroller "github.com/edgenet-project/edgenet/internal/controller/multitenancy"
+	operatorcontroller "github.com/edgenet-project/edgenet/internal/controller/operator"
 	"github.com/edgenet-project/edgenet/internal/labeller/v1"
+	"github.com/edgenet-project/edgenet/internal/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/notification/v1"
+	"github.com/edgenet-project/edgenet/internal/operator/v1"
 	"github.com/edgenet-project/edgenet/internal/utils"
+	webhookcorev1 "github.com/edgenet-project/edgenet/internal/webhook/core/v1"
+	webhookmultitenancyv1 "github.com/edgenet-project/edgenet/internal/webhook/multitenancy/v1"
 	//+kubebuilder:scaffold:imports
 )
 
@@ -55,6 +75,10 @@ func init() {
 
 	utilruntime.Must(antreav1alpha1.AddToScheme(scheme))
 	utilruntime.Must(multitenancyv1.AddToScheme(scheme))
+	utilruntime.Must(appsv1.AddToScheme(scheme))
+	utilruntime.Must(infrastructurev1.AddToScheme(scheme))
+	utilruntime.Must(notificationv1.AddToScheme(scheme))
+	utilruntime.Must(operatorv1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -69,12 +93,46 @@ func main() {
 	var maxmindUrl string
 	var maxmindAccountId string
 	var maxmindToken string
+	var maxmindRequired bool
+	var roleRequestExpiry time.Duration
+	var usernamePrefix string
+	var groupsPrefix string
+	var propagatedKinds utils.FlagList
+	var reliabilityWindow time.Duration
+	var capabilityRules string
+	var labellerDryRun bool
+	var tenantExpiryWarnings string
+	var tenantGracePeriod time.Duration
+	var smtpSecret string
+	var usageSampleInterval time.Duration
+	var podSecurityLevel string
+	var defaultContainerRequests string
+	var defaultContainerLimits string
+	var sliceMaxNodes int
+	var sliceMaxDuration time.Duration
 	flag.BoolVar(&debug, "debug", false, "Debug mode for the logger")
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.StringVar(&maxmindAccountId, "maxmind-accountid", "", "The account id of the maxmind geodatabase.")
 	flag.StringVar(&maxmindToken, "maxmind-token", "", "The access token of the maxmind geodatabase.")
 	flag.StringVar(&maxmindUrl, "maxmind-url", "https://geoip.maxmind.com/geoip/v2.1/city/", "The endpoint of the maxmind for the ip lookup to work.")
+	flag.BoolVar(&maxmindRequired, "maxmind-required", false, "Fail the readiness check while the maxmind credentials of the mounted secret are not available.")
+	flag.DurationVar(&roleRequestExpiry, "role-request-expiry", 72*time.Hour, "The duration after which a pending role request expires, zero disables the expiry.")
+	flag.StringVar(&usernamePrefix, "oidc-username-prefix", "", "The prefix added to the tenant users in the role bindings, should match the API server.")
+	flag.StringVar(&groupsPrefix, "oidc-groups-prefix", "", "The prefix added to the tenant groups in the role bindings, should match the API server.")
+	flag.StringVar(&capabilityRules, "capability-rules", "edgenet-system/edgenet-capability-rules", "The namespace/name of the ConfigMap holding the capability labelling rules.")
+	flag.BoolVar(&labellerDryRun, "labeller-dry-run", false, "Only report the label changes of the NodeLabeller as events and annotations instead of applying them.")
+	flag.DurationVar(&reliabilityWindow, "reliability-window", 24*time.Hour, "The sliding window the reliability of the nodes is computed over.")
+	flag.StringVar(&tenantExpiryWarnings, "tenant-expiry-warnings", "168h,24h", "Comma seperated lead times the tenants are warned at before they are suspended for expiry or inactivity.")
+	flag.DurationVar(&tenantGracePeriod, "tenant-grace-period", 30*24*time.Hour, "The duration a tenant suspended for expiry or inactivity is kept before it is deleted, zero disables the deletion.")
+	flag.StringVar(&smtpSecret, "smtp-secret", "", "The namespace/name of the Secret holding the SMTP settings of the email notifications, empty disables the notifications.")
+	flag.DurationVar(&usageSampleInterval, "usage-sample-interval", 5*time.Minute, "How often the usage of the tenants is sampled for the usage reports, zero disables the accounting.")
+	flag.StringVar(&podSecurityLevel, "pod-security-level", multitenancyv1.PodSecurityLevelBaseline, "The Pod Security Admission level enforced in the namespaces of the tenants, privileged, baseline or restricted. Empty leaves the namespaces unlabelled.")
+	flag.StringVar(&defaultContainerRequests, "default-container-requests", "cpu=100m,memory=128Mi", "Comma seperated default requests of the containers in the namespaces of the tenants, cpu=100m,memory=128Mi...")
+	flag.StringVar(&defaultContainerLimits, "default-container-limits", "", "Comma seperated default limits of the containers in the namespaces of the tenants, cpu=1,memory=1Gi...")
+	flag.IntVar(&sliceMaxNodes, "slice-max-nodes", 10, "The maximum number of nodes the slices of a tenant can reserve at the same time, zero disables the limit.")
+	flag.DurationVar(&sliceMaxDuration, "slice-max-duration", 7*24*time.Hour, "The maximum duration of a slice reservation, zero disables the limit.")
+	flag.Var(&propagatedKinds, "propagated-kinds", "Comma seperated kinds propagated to the SubNamespaces, Role,RoleBinding,Secret,ConfigMap,LimitRange...")
 	flag.Var(&disabledReconcilers, "disabled-reconcilers", "Comma seperated values of the reconciliers, Tenant,SubNamespace...")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
@@ -111,12 +169,16 @@ func main() {
 		TLSOpts: tlsOpts,
 	})
 
+	// The usage reports are exported on the metrics server, behind the same authorization as the metrics.
+	usageReports := &accounting.ReportHandler{}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme: scheme,
 		Metrics: metricsserver.Options{
 			BindAddress:   metricsAddr,
 			SecureServing: secureMetrics,
 			TLSOpts:       tlsOpts,
+			ExtraHandlers: map[string]http.Handler{accounting.ReportPath: usageReports},
 		},
 		WebhookServer:          webhookServer,
 		HealthProbeBindAddress: probeAddr,
@@ -139,14 +201,143 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Try to read the maxmind accountid, and token from the file.
-	maxmind, err := labeller.NewMaxMindFromSecret(maxmindUrl, maxmindAccountId, maxmindToken)
+	// The state of the tenants and the locations of the nodes are read from the cache of the manager on every scrape.
+	metrics.Registry.MustRegister(multitenancy.NewCollector(mgr.GetClient()), labeller.NewLocationCollector(mgr.GetClient()))
 
-	// If the error is not nil then we cannot get the maxmind config and we should not start node labeller reconcilier.
-	disableNodeLabeller := err != nil
+	usageReports.Reader = mgr.GetClient()
+	if usageSampleInterval > 0 {
+		if err := mgr.Add(&accounting.Accountant{
+			Client:        mgr.GetClient(),
+			MetricsReader: mgr.GetAPIReader(),
+			Interval:      usageSampleInterval,
+		}); err != nil {
+			setupLog.Error(err, "unable to set up the usage accounting")
+			os.Exit(1)
+		}
+	}
 
+	// The maxmind accountid and token given by the flags or the environment variables are used as they are.
+	// Otherwise the mounted secret is watched, the nodes are labelled with their geolocation once it is valid.
+	var maxmindUpdates <-chan event.GenericEvent
+	maxmind := labeller.NewMaxMindFromArgs(maxmindUrl, maxmindAccountId, maxmindToken)
+	if maxmind == nil {
+		maxmindWatcher := labeller.NewMaxMindWatcher(maxmindUrl, labeller.MaxMindSecretDir, labeller.DefaultMaxMindReloadInterval)
+		maxmindWatcher.Required = maxmindRequired
+		if !maxmindWatcher.Available() {
+			setupLog.Info("Cannot retrieve the MaxMind Account Token, the nodes are labelled without their geolocation until it is mounted")
+		}
+		if err := mgr.Add(maxmindWatcher); err != nil {
+			setupLog.Error(err, "unable to set up the maxmind watcher")
+			os.Exit(1)
+		}
+		if err := mgr.AddReadyzCheck("maxmind", maxmindWatcher.Check); err != nil {
+			setupLog.Error(err, "unable to set up the maxmind ready check")
+			os.Exit(1)
+		}
+		maxmind = maxmindWatcher
+		maxmindUpdates = maxmindWatcher.Updates()
+	}
+
+	capabilityRulesNamespace, capabilityRulesName, found := strings.Cut(capabilityRules, "/")
+	if !found {
+		setupLog.Error(nil, "the capability rules should be given as namespace/name", "capability-rules", capabilityRules)
+		os.Exit(1)
+	}
+
+	var smtpSecretName types.NamespacedName
+	if smtpSecret != "" {
+		var found bool
+		smtpSecretName.Namespace, smtpSecretName.Name, found = strings.Cut(smtpSecret, "/")
+		if !found {
+			setupLog.Error(nil, "the smtp secret should be given as namespace/name", "smtp-secret", smtpSecret)
+			os.Exit(1)
+		}
+	}
+
+	var tenantWarningLeadTimes []time.Duration
+	for _, value := range strings.Split(tenantExpiryWarnings, ",") {
+		if value = strings.TrimSpace(value); value == "" {
+			continue
+		}
+		leadTime, err := time.ParseDuration(value)
+		if err != nil {
+			setupLog.Error(err, "invalid tenant expiry warning lead time", "tenant-expiry-warnings", tenantExpiryWarnings)
+			os.Exit(1)
+		}
+		tenantWarningLeadTimes = append(tenantWarningLeadTimes, leadTime)
+	}
+
+	switch podSecurityLevel {
+	case "", multitenancyv1.PodSecurityLevelPrivileged, multitenancyv1.PodSecurityLevelBaseline, multitenancyv1.PodSecurityLevelRestricted:
+	default:
+		setupLog.Error(nil, "invalid pod security level", "pod-security-level", podSecurityLevel)
+		os.Exit(1)
+	}
+
+	defaultRequests, err := utils.ParseResourceList(defaultContainerRequests)
 	if err != nil {
-		setupLog.Info("Cannot retrieve the MaxMind Account Token, running without the NodeLabeller")
+		setupLog.Error(err, "invalid default container requests", "default-container-requests", defaultContainerRequests)
+		os.Exit(1)
+	}
+
+	defaultLimits, err := utils.ParseResourceList(defaultContainerLimits)
+	if err != nil {
+		setupLog.Error(err, "invalid default container limits", "default-container-limits", defaultContainerLimits)
+		os.Exit(1)
+	}
+
+	// The settings given by the flags can be changed at runtime by the EdgeNetConfig.
+	defaultSettings := operator.Settings{
+		MaxMind: maxmind,
+		NamespaceDefaults: multitenancyv1.NamespaceDefaults{
+			PodSecurityLevel: podSecurityLevel,
+			DefaultRequests:  defaultRequests,
+			DefaultLimits:    defaultLimits,
+		},
+		Reclamation: multitenancy.ReclamationPolicy{
+			WarningLeadTimes: tenantWarningLeadTimes,
+			GracePeriod:      tenantGracePeriod,
+		},
+		SMTPSecret: smtpSecretName,
+	}
+	settings := operator.NewStore(defaultSettings)
+	if err := mgr.Add(&operator.Loader{Reader: mgr.GetAPIReader(), Defaults: defaultSettings, Store: settings}); err != nil {
+		setupLog.Error(err, "unable to set up the EdgeNetConfig loader")
+		os.Exit(1)
+	}
+
+	// The reconcilers are set up only once, the ones disabled by the EdgeNetConfig are read before the start.
+	configDisabledReconcilers, err := operator.ReadDisabledReconcilers(context.Background(), mgr.GetAPIReader())
+	if err != nil {
+		setupLog.Error(err, "unable to read the EdgeNetConfig")
+		os.Exit(1)
+	}
+	flagDisabledReconcilers := append([]string(nil), disabledReconcilers...)
+	disabledReconcilers = append(disabledReconcilers, configDisabledReconcilers...)
+
+	// The notifications are posted to the endpoints of the NotificationPolicies, and sent by email if the SMTP
+	// settings are given. They are queued by the reconcilers and delivered in the background.
+	notifiers := notification.NewQueue(notification.Notifiers{
+		notification.NewWebhookNotifier(mgr.GetClient(), mgr.GetAPIReader(), utils.GetEventRecorder(mgr)),
+		notification.NewEmailNotifier(&operator.SMTPSender{Reader: mgr.GetAPIReader(), Settings: settings}),
+	}, notification.DefaultQueueSize, notification.DefaultQueueWorkers)
+	if err := mgr.Add(notifiers); err != nil {
+		setupLog.Error(err, "unable to set up the notification queue")
+		os.Exit(1)
+	}
+
+	// The settings shared by the multitenancy reconcilers.
+	multiTenancyConfig := multitenancy.Config{
+		Subjects: multitenancy.SubjectMapper{
+			UsernamePrefix: usernamePrefix,
+			GroupPrefix:    groupsPrefix,
+		},
+		PropagatedKinds:   propagatedKinds,
+		NamespaceDefaults: defaultSettings.NamespaceDefaults,
+		SliceLimits: multitenancy.SliceLimits{
+			MaxNodesPerTenant: sliceMaxNodes,
+			MaxDuration:       sliceMaxDuration,
+		},
 	}
 
 	// Setup reconcilers, we might want to add the list of reconcilers. This part is auto generated.
@@ -155,32 +346,161 @@ func main() {
 	// generated autside an if clause. ADD YOUR IF CLAUSE MANUALLY!
 	if !disabledReconcilers.Contains("Tenant") {
 		if err = (&multitenancycontroller.TenantReconciler{
-			Client: mgr.GetClient(),
-			Scheme: mgr.GetScheme(),
+			Client:      mgr.GetClient(),
+			Scheme:      mgr.GetScheme(),
+			Config:      multiTenancyConfig,
+			Reclamation: defaultSettings.Reclamation,
+			Notifier:    notifiers,
+			Settings:    settings,
 		}).SetupWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "Tenant")
 			os.Exit(1)
 		}
 	}
-	// For NodeLabeller to be activated, it should not be in the disabled reconciler list and the maxmind from secret
-	// should not return an error
-	if !disabledReconcilers.Contains("NodeLabeller") && !disableNodeLabeller {
+	if !disabledReconcilers.Contains("NodeLabeller") {
 		if err = (&labellerscontroller.NodeLabellerReconciler{
 			Client: mgr.GetClient(),
 			Scheme: mgr.GetScheme(),
 			// Add the maxming configuration to the reconcilier.
 			MaxMind: maxmind,
+			CapabilityRules: types.NamespacedName{
+				Namespace: capabilityRulesNamespace,
+				Name:      capabilityRulesName,
+			},
+			DryRun:         labellerDryRun,
+			Notifier:       notifiers,
+			Settings:       settings,
+			MaxMindUpdates: maxmindUpdates,
 		}).SetupWithManager(mgr); err != nil {
 			setupLog.Error(err, "unable to create controller", "controller", "NodeLabeller")
 			os.Exit(1)
 		}
 	}
+	if !disabledReconcilers.Contains("NodeOwner") {
+		if err = (&labellerscontroller.NodeOwnerReconciler{
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "NodeOwner")
+			os.Exit(1)
+		}
+	}
+	if !disabledReconcilers.Contains("NodeReliability") {
+		if err = (&labellerscontroller.NodeReliabilityReconciler{
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+			Window: reliabilityWindow,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "NodeReliability")
+			os.Exit(1)
+		}
+	}
 	if !disabledReconcilers.Contains("SubNamespace") {
 		if err = (&multitenancycontroller.SubNamespaceReconciler{
+			Client:   mgr.GetClient(),
+			Scheme:   mgr.GetScheme(),
+			Config:   multiTenancyConfig,
+			Settings: settings,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "SubNamespace")
+			os.Exit(1)
+		}
+	}
+	if !disabledReconcilers.Contains("RoleRequest") {
+		if err = (&multitenancycontroller.RoleRequestReconciler{
+			Client:   mgr.GetClient(),
+			Scheme:   mgr.GetScheme(),
+			Expiry:   roleRequestExpiry,
+			Config:   multiTenancyConfig,
+			Notifier: notifiers,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "RoleRequest")
+			os.Exit(1)
+		}
+	}
+	if !disabledReconcilers.Contains("Propagation") {
+		if err = (&multitenancycontroller.PropagationReconciler{
 			Client: mgr.GetClient(),
 			Scheme: mgr.GetScheme(),
+			Config: multiTenancyConfig,
 		}).SetupWithManager(mgr); err != nil {
-			setupLog.Error(err, "unable to create controller", "controller", "SubNamespace")
+			setupLog.Error(err, "unable to create controller", "controller", "Propagation")
+			os.Exit(1)
+		}
+	}
+	if !disabledReconcilers.Contains("SliceClaim") {
+		if err = (&multitenancycontroller.SliceClaimReconciler{
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+			Config: multiTenancyConfig,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "SliceClaim")
+			os.Exit(1)
+		}
+	}
+	if !disabledReconcilers.Contains("Slice") {
+		if err = (&multitenancycontroller.SliceReconciler{
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+			Config: multiTenancyConfig,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "Slice")
+			os.Exit(1)
+		}
+	}
+	if !disabledReconcilers.Contains("SelectiveDeployment") {
+		if err = (&appscontroller.SelectiveDeploymentReconciler{
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "SelectiveDeployment")
+			os.Exit(1)
+		}
+	}
+	if !disabledReconcilers.Contains("AllocationPolicy") {
+		if err = (&multitenancycontroller.AllocationPolicyReconciler{
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "AllocationPolicy")
+			os.Exit(1)
+		}
+	}
+	if !disabledReconcilers.Contains("NodeContribution") {
+		if err = (&infrastructurecontroller.NodeContributionReconciler{
+			Client: mgr.GetClient(),
+			Scheme: mgr.GetScheme(),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "NodeContribution")
+			os.Exit(1)
+		}
+	}
+	if !disabledReconcilers.Contains("EdgeNetConfig") {
+		if err = (&operatorcontroller.EdgeNetConfigReconciler{
+			Client:              mgr.GetClient(),
+			Scheme:              mgr.GetScheme(),
+			Reader:              mgr.GetAPIReader(),
+			Defaults:            defaultSettings,
+			Settings:            settings,
+			DisabledReconcilers: flagDisabledReconcilers,
+			ActiveReconcilers:   operator.ActiveReconcilers(disabledReconcilers),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "EdgeNetConfig")
+			os.Exit(1)
+		}
+	}
+	// The webhooks need the serving certificates, they can be disabled when running the controller locally.
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = webhookcorev1.SetupPodWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Pod")
+			os.Exit(1)
+		}
+		if err = webhookmultitenancyv1.SetupTenantWebhookWithManager(mgr, multiTenancyConfig.Subjects); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Tenant")
+			os.Exit(1)
+		}
+		if err = webhookmultitenancyv1.SetupRoleRequestWebhookWithManager(mgr, multiTenancyConfig.Subjects); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "RoleRequest")
 			os.Exit(1)
 		}
 	}
@@ -0,0 +1,308 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accounting
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+)
+
+const (
+	// The tenant of the report, the same label is used on the namespaces of the tenant.
+	TenantLabel = "edge-net.io/tenant"
+
+	// The billing period of the report as "2006-01", used to list the reports of a period.
+	BillingPeriodLabel = "edge-net.io/billing-period"
+
+	// The layout of the billing periods in the labels, the report names and the report endpoint.
+	BillingPeriodLayout = "2006-01"
+)
+
+// The pod metrics of a namespace, served by the metrics-server when it is installed.
+var podMetricsListGVK = schema.GroupVersionKind{Group: "metrics.k8s.io", Version: "v1beta1", Kind: "PodMetricsList"}
+
+//+kubebuilder:rbac:groups=multitenancy.edge-net.io,resources=tenantusagereports,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups=multitenancy.edge-net.io,resources=tenantusagereports/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=metrics.k8s.io,resources=pods,verbs=get;list
+
+// Usage is the amount of CPU in cores and memory in bytes consumed at a time.
+type Usage struct {
+	CPU    resource.Quantity
+	Memory resource.Quantity
+}
+
+// Adds the usage of another pod or container.
+func (u *Usage) Add(other Usage) {
+	u.CPU.Add(other.CPU)
+	u.Memory.Add(other.Memory)
+}
+
+// Sample is the usage of a tenant at the time it is sampled.
+type Sample struct {
+	// The resource requests of the running pods of the tenant.
+	Requested Usage
+
+	// The actual usage of the pods of the tenant, nil if the metrics API is not available.
+	Used *Usage
+}
+
+// Accountant periodically samples the usage of each tenant across its core namespace and SubNamespaces, and
+// accumulates it in the TenantUsageReport of the current billing period. It runs only on the leader.
+type Accountant struct {
+	// Reads the tenants, their namespaces and pods, and writes the reports.
+	Client client.Client
+
+	// Reads the pod metrics. The metrics API cannot be watched, this should not be the cached client.
+	MetricsReader client.Reader
+
+	// How often the tenants are sampled.
+	Interval time.Duration
+}
+
+var _ manager.Runnable = &Accountant{}
+
+// Samples the tenants on every interval until the context is done.
+func (a *Accountant) Start(ctx context.Context) error {
+	l := log.FromContext(ctx).WithName("accounting")
+
+	ticker := time.NewTicker(a.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			if err := a.SampleTenants(ctx, now); err != nil {
+				l.Error(err, "cannot account the usage of the tenants")
+			}
+		}
+	}
+}
+
+// Samples every tenant and records the samples in their reports. A failing tenant doesn't stop the others.
+func (a *Accountant) SampleTenants(ctx context.Context, now time.Time) error {
+	tenantList := &multitenancyv1.TenantList{}
+	if err := a.Client.List(ctx, tenantList); err != nil {
+		return err
+	}
+
+	errs := []error{}
+	for i := range tenantList.Items {
+		tenant := &tenantList.Items[i]
+
+		sample, err := a.SampleTenant(ctx, tenant)
+		if err == nil {
+			err = a.RecordSample(ctx, tenant, sample, now)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenant.GetName(), err))
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// Returns the requests of the running pods in the namespaces of the tenant, and their actual usage if the
+// metrics API is available.
+func (a *Accountant) SampleTenant(ctx context.Context, tenant *multitenancyv1.Tenant) (Sample, error) {
+	namespaceList := &corev1.NamespaceList{}
+	if err := a.Client.List(ctx, namespaceList, client.MatchingLabels{TenantLabel: tenant.GetName()}); err != nil {
+		return Sample{}, err
+	}
+
+	sample := Sample{Used: &Usage{}}
+	for _, namespace := range namespaceList.Items {
+		podList := &corev1.PodList{}
+		if err := a.Client.List(ctx, podList, client.InNamespace(namespace.GetName())); err != nil {
+			return Sample{}, err
+		}
+		for i := range podList.Items {
+			if pod := &podList.Items[i]; pod.Status.Phase == corev1.PodRunning {
+				sample.Requested.Add(PodRequests(pod))
+			}
+		}
+
+		// The usage is only known if the metrics of all of the namespaces are available.
+		if sample.Used != nil {
+			used, ok := a.namespaceUsage(ctx, namespace.GetName())
+			if ok {
+				sample.Used.Add(used)
+			} else {
+				sample.Used = nil
+			}
+		}
+	}
+
+	return sample, nil
+}
+
+// Sums the usage of the pods in the namespace reported by the metrics API. Returns false if the metrics API is
+// not available, for example the metrics-server is not installed.
+func (a *Accountant) namespaceUsage(ctx context.Context, namespace string) (Usage, bool) {
+	if a.MetricsReader == nil {
+		return Usage{}, false
+	}
+
+	podMetricsList := &unstructured.UnstructuredList{}
+	podMetricsList.SetGroupVersionKind(podMetricsListGVK)
+	if err := a.MetricsReader.List(ctx, podMetricsList, client.InNamespace(namespace)); err != nil {
+		return Usage{}, false
+	}
+
+	usage := Usage{}
+	for _, podMetrics := range podMetricsList.Items {
+		containers, _, _ := unstructured.NestedSlice(podMetrics.Object, "containers")
+		for _, container := range containers {
+			values, _, _ := unstructured.NestedStringMap(container.(map[string]interface{}), "usage")
+			if cpu, err := resource.ParseQuantity(values[string(corev1.ResourceCPU)]); err == nil {
+				usage.CPU.Add(cpu)
+			}
+			if memory, err := resource.ParseQuantity(values[string(corev1.ResourceMemory)]); err == nil {
+				usage.Memory.Add(memory)
+			}
+		}
+	}
+
+	return usage, true
+}
+
+// Returns the effective requests of the pod, as the scheduler computes them. The init containers run one by one
+// before the containers, the pod requests the larger of the sum of the containers and the largest init container.
+// The overhead of the runtime class is added on top.
+func PodRequests(pod *corev1.Pod) Usage {
+	requests := Usage{}
+	for _, container := range pod.Spec.Containers {
+		requests.Add(containerRequests(container))
+	}
+
+	for _, container := range pod.Spec.InitContainers {
+		init := containerRequests(container)
+		if init.CPU.Cmp(requests.CPU) > 0 {
+			requests.CPU = init.CPU
+		}
+		if init.Memory.Cmp(requests.Memory) > 0 {
+			requests.Memory = init.Memory
+		}
+	}
+
+	if overhead := pod.Spec.Overhead; overhead != nil {
+		requests.Add(Usage{CPU: overhead[corev1.ResourceCPU], Memory: overhead[corev1.ResourceMemory]})
+	}
+
+	return requests
+}
+
+func containerRequests(container corev1.Container) Usage {
+	return Usage{
+		CPU:    container.Resources.Requests[corev1.ResourceCPU],
+		Memory: container.Resources.Requests[corev1.ResourceMemory],
+	}
+}
+
+// Adds the sample to the report of the current billing period, the report is created if it doesn't exist. The
+// sample accounts for the time elapsed since the previous one. After a gap longer than two intervals, such as a
+// restart of the manager, only one interval is accounted since the usage in the meantime is not known.
+func (a *Accountant) RecordSample(ctx context.Context, tenant *multitenancyv1.Tenant, sample Sample, now time.Time) error {
+	start, end := BillingPeriod(now)
+
+	report := &multitenancyv1.TenantUsageReport{}
+	err := a.Client.Get(ctx, types.NamespacedName{Name: ReportName(tenant.GetName(), start)}, report)
+	if errors.IsNotFound(err) {
+		report = &multitenancyv1.TenantUsageReport{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: ReportName(tenant.GetName(), start),
+				Labels: map[string]string{
+					TenantLabel:        tenant.GetName(),
+					BillingPeriodLabel: start.Format(BillingPeriodLayout),
+				},
+			},
+			Spec: multitenancyv1.TenantUsageReportSpec{
+				Tenant:      tenant.GetName(),
+				FullName:    tenant.Spec.FullName,
+				PeriodStart: metav1.NewTime(start),
+				PeriodEnd:   metav1.NewTime(end),
+			},
+		}
+		err = a.Client.Create(ctx, report)
+	}
+	if err != nil {
+		return err
+	}
+
+	elapsed := a.Interval
+	if last := report.Status.LastSampleTime; last != nil {
+		since := now.Sub(last.Time)
+		if since <= 0 {
+			// Already sampled, for example by the previous leader.
+			return nil
+		}
+		if since < 2*a.Interval {
+			elapsed = since
+		}
+	}
+
+	AddHours(&report.Status.Requested, sample.Requested, elapsed)
+	report.Status.Samples++
+	if sample.Used != nil {
+		AddHours(&report.Status.Used, *sample.Used, elapsed)
+		report.Status.UsageSamples++
+	}
+	lastSampleTime := metav1.NewTime(now.Truncate(time.Second))
+	report.Status.LastSampleTime = &lastSampleTime
+
+	return a.Client.Status().Update(ctx, report)
+}
+
+// Adds the usage over the elapsed time to the total. The memory is counted in gigabytes, 10^9 bytes.
+func AddHours(total *multitenancyv1.ResourceHours, usage Usage, elapsed time.Duration) {
+	hours := elapsed.Hours()
+	total.CPU.Add(*milliQuantity(usage.CPU.AsApproximateFloat64() * hours))
+	total.Memory.Add(*milliQuantity(usage.Memory.AsApproximateFloat64() / 1e9 * hours))
+}
+
+func milliQuantity(value float64) *resource.Quantity {
+	return resource.NewMilliQuantity(int64(math.Round(value*1000)), resource.DecimalSI)
+}
+
+// Returns the billing period of the time, the calendar month in UTC.
+func BillingPeriod(t time.Time) (time.Time, time.Time) {
+	t = t.UTC()
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
+
+// Returns the name of the report of the tenant for the billing period starting at the given time.
+func ReportName(tenant string, start time.Time) string {
+	return fmt.Sprintf("%s-%s", tenant, start.UTC().Format(BillingPeriodLayout))
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accounting
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+)
+
+var _ = Describe("Accounting", func() {
+	ctx := context.Background()
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	container := func(cpu, memory string) corev1.Container {
+		return corev1.Container{Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(memory),
+		}}}
+	}
+
+	pod := func(name, namespace string, phase corev1.PodPhase, containers ...corev1.Container) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       corev1.PodSpec{Containers: containers},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+	}
+
+	newClient := func(objects ...client.Object) client.Client {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(multitenancyv1.AddToScheme(scheme)).To(Succeed())
+		return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).
+			WithStatusSubresource(&multitenancyv1.TenantUsageReport{}).Build()
+	}
+
+	It("should compute the effective requests of the pods", func() {
+		p := pod("web", "lab", corev1.PodRunning, container("500m", "1Gi"), container("250m", "512Mi"))
+		p.Spec.InitContainers = []corev1.Container{container("1", "256Mi")}
+		p.Spec.Overhead = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")}
+
+		requests := PodRequests(p)
+		Expect(requests.CPU.MilliValue()).To(Equal(int64(1100)))
+		Expect(requests.Memory.Value()).To(Equal(int64(1536 * 1024 * 1024)))
+	})
+
+	It("should name the reports after the billing period", func() {
+		start, end := BillingPeriod(now)
+		Expect(start).To(Equal(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)))
+		Expect(end).To(Equal(time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)))
+		Expect(ReportName("lab", start)).To(Equal("lab-2024-06"))
+	})
+
+	It("should accumulate the samples of the tenant", func() {
+		tenant := &multitenancyv1.Tenant{
+			ObjectMeta: metav1.ObjectMeta{Name: "lab"},
+			Spec:       multitenancyv1.TenantSpec{FullName: "Laboratory"},
+		}
+		c := newClient(
+			tenant,
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "lab", Labels: map[string]string{TenantLabel: "lab"}}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "lab-sub", Labels: map[string]string{TenantLabel: "lab"}}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other"}},
+			pod("a", "lab", corev1.PodRunning, container("1", "2G")),
+			pod("b", "lab-sub", corev1.PodRunning, container("1", "2G")),
+			pod("c", "lab-sub", corev1.PodSucceeded, container("4", "8G")),
+			pod("d", "other", corev1.PodRunning, container("4", "8G")),
+		)
+		accountant := &Accountant{Client: c, Interval: 30 * time.Minute}
+
+		sample, err := accountant.SampleTenant(ctx, tenant)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sample.Requested.CPU.Value()).To(Equal(int64(2)))
+		Expect(sample.Requested.Memory.Value()).To(Equal(int64(4e9)))
+		Expect(sample.Used).To(BeNil())
+
+		Expect(accountant.SampleTenants(ctx, now)).To(Succeed())
+		Expect(accountant.SampleTenants(ctx, now.Add(15*time.Minute))).To(Succeed())
+		// A gap longer than two intervals only accounts for one interval.
+		Expect(accountant.SampleTenants(ctx, now.Add(5*time.Hour))).To(Succeed())
+
+		report := &multitenancyv1.TenantUsageReport{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "lab-2024-06"}, report)).To(Succeed())
+		Expect(report.Spec.FullName).To(Equal("Laboratory"))
+		Expect(report.GetLabels()).To(HaveKeyWithValue(BillingPeriodLabel, "2024-06"))
+		Expect(report.Status.Samples).To(Equal(int32(3)))
+		Expect(report.Status.UsageSamples).To(BeZero())
+		Expect(report.Status.Requested.CPU.String()).To(Equal("2500m"))
+		Expect(report.Status.Requested.Memory.String()).To(Equal("5"))
+	})
+
+	It("should export the reports of the period", func() {
+		report := func(tenant, period string, cpu string) *multitenancyv1.TenantUsageReport {
+			start, _ := time.Parse(BillingPeriodLayout, period)
+			return &multitenancyv1.TenantUsageReport{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   ReportName(tenant, start),
+					Labels: map[string]string{TenantLabel: tenant, BillingPeriodLabel: period},
+				},
+				Spec: multitenancyv1.TenantUsageReportSpec{
+					Tenant:      tenant,
+					FullName:    "Lab " + tenant,
+					PeriodStart: metav1.NewTime(start),
+					PeriodEnd:   metav1.NewTime(start.AddDate(0, 1, 0)),
+				},
+				Status: multitenancyv1.TenantUsageReportStatus{
+					Requested: multitenancyv1.ResourceHours{CPU: resource.MustParse(cpu), Memory: resource.MustParse("2")},
+					Samples:   4,
+				},
+			}
+		}
+		handler := &ReportHandler{Reader: newClient(
+			report("beta", "2024-06", "1500m"),
+			report("alpha", "2024-06", "3"),
+			report("alpha", "2024-05", "7"),
+		)}
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, ReportPath+"?period=2024-06&format=csv", nil))
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Body.String()).To(Equal(
+			"tenant,fullName,periodStart,periodEnd,requestedCpuHours,requestedMemoryGBHours,usedCpuHours,usedMemoryGBHours,samples,usageSamples\n" +
+				"alpha,Lab alpha,2024-06-01T00:00:00Z,2024-07-01T00:00:00Z,3.000,2.000,0.000,0.000,4,0\n" +
+				"beta,Lab beta,2024-06-01T00:00:00Z,2024-07-01T00:00:00Z,1.500,2.000,0.000,0.000,4,0\n"))
+
+		recorder = httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, ReportPath+"?period=2024-05&tenant=alpha", nil))
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		Expect(recorder.Body.String()).To(ContainSubstring(`"requestedCpuHours":7`))
+		Expect(recorder.Body.String()).NotTo(ContainSubstring("beta"))
+
+		recorder = httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, ReportPath+"?period=june", nil))
+		Expect(recorder.Code).To(Equal(http.StatusBadRequest))
+	})
+})
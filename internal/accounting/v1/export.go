@@ -0,0 +1,152 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package accounting
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+)
+
+// The path of the report endpoint on the metrics server of the manager.
+const ReportPath = "/usage-reports"
+
+// ReportRow is a report in the exported CSV and JSON documents. The usage is in CPU-hours and memory-GB-hours.
+type ReportRow struct {
+	Tenant                 string  `json:"tenant"`
+	FullName               string  `json:"fullName"`
+	PeriodStart            string  `json:"periodStart"`
+	PeriodEnd              string  `json:"periodEnd"`
+	RequestedCPUHours      float64 `json:"requestedCpuHours"`
+	RequestedMemoryGBHours float64 `json:"requestedMemoryGBHours"`
+	UsedCPUHours           float64 `json:"usedCpuHours"`
+	UsedMemoryGBHours      float64 `json:"usedMemoryGBHours"`
+	Samples                int32   `json:"samples"`
+	UsageSamples           int32   `json:"usageSamples"`
+}
+
+var reportHeader = []string{
+	"tenant", "fullName", "periodStart", "periodEnd",
+	"requestedCpuHours", "requestedMemoryGBHours", "usedCpuHours", "usedMemoryGBHours",
+	"samples", "usageSamples",
+}
+
+// Converts the report to its exported row.
+func NewReportRow(report *multitenancyv1.TenantUsageReport) ReportRow {
+	return ReportRow{
+		Tenant:                 report.Spec.Tenant,
+		FullName:               report.Spec.FullName,
+		PeriodStart:            report.Spec.PeriodStart.UTC().Format(time.RFC3339),
+		PeriodEnd:              report.Spec.PeriodEnd.UTC().Format(time.RFC3339),
+		RequestedCPUHours:      report.Status.Requested.CPU.AsApproximateFloat64(),
+		RequestedMemoryGBHours: report.Status.Requested.Memory.AsApproximateFloat64(),
+		UsedCPUHours:           report.Status.Used.CPU.AsApproximateFloat64(),
+		UsedMemoryGBHours:      report.Status.Used.Memory.AsApproximateFloat64(),
+		Samples:                report.Status.Samples,
+		UsageSamples:           report.Status.UsageSamples,
+	}
+}
+
+func (r ReportRow) record() []string {
+	format := func(value float64) string {
+		return strconv.FormatFloat(value, 'f', 3, 64)
+	}
+	return []string{
+		r.Tenant, r.FullName, r.PeriodStart, r.PeriodEnd,
+		format(r.RequestedCPUHours), format(r.RequestedMemoryGBHours), format(r.UsedCPUHours), format(r.UsedMemoryGBHours),
+		strconv.Itoa(int(r.Samples)), strconv.Itoa(int(r.UsageSamples)),
+	}
+}
+
+// ReportHandler exports the usage reports of a billing period. The query parameters are:
+//   - period: the billing period as "2006-01", the current one by default.
+//   - format: "csv" or "json", json by default.
+//   - tenant: only export the report of this tenant.
+type ReportHandler struct {
+	// Reads the reports. The handler is registered before the manager is created, it responds with 503 until the
+	// reader is set.
+	Reader client.Reader
+}
+
+var _ http.Handler = &ReportHandler{}
+
+func (h *ReportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "only GET is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.Reader == nil {
+		http.Error(w, "the usage reports are not available yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	query := r.URL.Query()
+
+	period, _ := BillingPeriod(time.Now())
+	if value := query.Get("period"); value != "" {
+		var err error
+		if period, err = time.Parse(BillingPeriodLayout, value); err != nil {
+			http.Error(w, fmt.Sprintf("the period should be given as %s", BillingPeriodLayout), http.StatusBadRequest)
+			return
+		}
+	}
+
+	labels := client.MatchingLabels{BillingPeriodLabel: period.Format(BillingPeriodLayout)}
+	if tenant := query.Get("tenant"); tenant != "" {
+		labels[TenantLabel] = tenant
+	}
+
+	reportList := &multitenancyv1.TenantUsageReportList{}
+	if err := h.Reader.List(r.Context(), reportList, labels); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	rows := make([]ReportRow, 0, len(reportList.Items))
+	for i := range reportList.Items {
+		rows = append(rows, NewReportRow(&reportList.Items[i]))
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].Tenant < rows[j].Tenant
+	})
+
+	switch format := query.Get("format"); format {
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"usage-%s.csv\"", period.Format(BillingPeriodLayout)))
+
+		writer := csv.NewWriter(w)
+		_ = writer.Write(reportHeader)
+		for _, row := range rows {
+			_ = writer.Write(row.record())
+		}
+		writer.Flush()
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(rows)
+	default:
+		http.Error(w, fmt.Sprintf("unknown format %q, use csv or json", format), http.StatusBadRequest)
+	}
+}
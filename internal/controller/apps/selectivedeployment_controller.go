@@ -0,0 +1,183 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apps
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	kappsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsv1 "github.com/edgenet-project/edgenet/api/apps/v1"
+	"github.com/edgenet-project/edgenet/internal/events/v1"
+	"github.com/edgenet-project/edgenet/internal/selectivedeployment/v1"
+	"github.com/edgenet-project/edgenet/internal/utils"
+)
+
+// SelectiveDeploymentReconciler reconciles a SelectiveDeployment object
+type SelectiveDeploymentReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	recorder *events.Recorder
+}
+
+//+kubebuilder:rbac:groups=apps.edge-net.io,resources=selectivedeployments,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=apps.edge-net.io,resources=selectivedeployments/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=apps.edge-net.io,resources=selectivedeployments/finalizers,verbs=update
+//+kubebuilder:rbac:groups=apps,resources=deployments;daemonsets;statefulsets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+// The nodes are selected by their location labels and the workload is restricted to them. The workload is owned
+// by the selective deployment, so it is removed by the garbage collector without a finalizer.
+//
+// For more details, check Reconcile and its Result here:
+// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.17.0/pkg/reconcile
+func (r *SelectiveDeploymentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	l := log.FromContext(ctx)
+	sd := appsv1.SelectiveDeployment{}
+
+	if err := utils.GetResource(ctx, r.Client, &sd, req.NamespacedName); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !sd.GetDeletionTimestamp().IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	selectiveDeploymentManager, err := selectivedeployment.NewSelectiveDeploymentManager(ctx, r.Client)
+
+	if err != nil {
+		l.Error(err, "cannot create selective deployment manager")
+		return ctrl.Result{}, err
+	}
+
+	status := sd.Status.DeepCopy()
+	result, err := selectiveDeploymentManager.SelectNodes(ctx, &sd)
+
+	// An invalid selector cannot be fixed by retrying, wait for the spec to change.
+	if err != nil {
+		r.recorder.Error(ctx, &sd, events.SelectorsInvalid, err, "SelectiveDeployment selectors are invalid")
+		status.State = appsv1.SelectiveDeploymentStateFailure
+		status.Message = err.Error()
+		status.Nodes = nil
+		return ctrl.Result{}, r.updateStatus(ctx, &sd, status)
+	}
+
+	if err := selectiveDeploymentManager.ApplyWorkload(ctx, &sd, result.Nodes); err != nil {
+		r.recorder.Error(ctx, &sd, events.WorkloadFailed, err, "SelectiveDeployment workload failed")
+		status.State = appsv1.SelectiveDeploymentStateFailure
+		status.Message = err.Error()
+		if err := r.updateStatus(ctx, &sd, status); err != nil {
+			l.Error(err, "cannot update the status")
+		}
+		return ctrl.Result{Requeue: true}, err
+	}
+
+	status.Nodes = result.Nodes
+
+	switch {
+	case len(result.Nodes) == 0:
+		status.State = appsv1.SelectiveDeploymentStateFailure
+		status.Message = "No nodes match the selectors"
+	case len(result.Unsatisfied) != 0:
+		status.State = appsv1.SelectiveDeploymentStatePartial
+		status.Message = strings.Join(result.Unsatisfied, ", ")
+	default:
+		status.State = appsv1.SelectiveDeploymentStateSuccess
+		status.Message = fmt.Sprintf("%s is scheduled to %d nodes", sd.Spec.Kind, len(result.Nodes))
+	}
+
+	return ctrl.Result{}, r.updateStatus(ctx, &sd, status)
+}
+
+// Updates the status of the selective deployment if it is changed.
+func (r *SelectiveDeploymentReconciler) updateStatus(ctx context.Context, sd *appsv1.SelectiveDeployment, status *appsv1.SelectiveDeploymentStatus) error {
+	if reflect.DeepEqual(sd.Status, *status) {
+		return nil
+	}
+
+	sd.Status = *status
+	if err := r.Status().Update(ctx, sd); err != nil {
+		return err
+	}
+
+	r.recorder.Normal(ctx, sd, events.SelectiveDeploymentUpdated, status.Message)
+	return nil
+}
+
+// Maps a node to all of the selective deployments, any of them might select the node after a change.
+func (r *SelectiveDeploymentReconciler) mapNodeToSelectiveDeployments(ctx context.Context, obj client.Object) []reconcile.Request {
+	sdList := &appsv1.SelectiveDeploymentList{}
+	if err := r.List(ctx, sdList); err != nil {
+		log.FromContext(ctx).Error(err, "cannot list the selective deployments")
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, sd := range sdList.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: sd.GetName(), Namespace: sd.GetNamespace()}})
+	}
+
+	return requests
+}
+
+// Only the changes of the nodes that affect the selection trigger a reconciliation; the labels and the
+// schedulability of the node. The readiness doesn't change the selection.
+var nodeSelectionChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldNode, ok := e.ObjectOld.(*corev1.Node)
+		if !ok {
+			return false
+		}
+		newNode, ok := e.ObjectNew.(*corev1.Node)
+		if !ok {
+			return false
+		}
+		return !reflect.DeepEqual(oldNode.GetLabels(), newNode.GetLabels()) ||
+			oldNode.Spec.Unschedulable != newNode.Spec.Unschedulable ||
+			oldNode.GetDeletionTimestamp().IsZero() != newNode.GetDeletionTimestamp().IsZero()
+	},
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *SelectiveDeploymentReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Setup the event recorder
+	r.recorder = events.NewRecorder(utils.GetEventRecorder(mgr))
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&appsv1.SelectiveDeployment{}).
+		Owns(&kappsv1.Deployment{}).
+		Owns(&kappsv1.DaemonSet{}).
+		Owns(&kappsv1.StatefulSet{}).
+		Watches(&corev1.Node{}, handler.EnqueueRequestsFromMapFunc(r.mapNodeToSelectiveDeployments), builder.WithPredicates(nodeSelectionChanged)).
+		Complete(r)
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package apps
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	kappsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	appsv1 "github.com/edgenet-project/edgenet/api/apps/v1"
+	"github.com/edgenet-project/edgenet/internal/labeller/v1"
+)
+
+var _ = Describe("SelectiveDeployment Controller", func() {
+	Context("When reconciling a resource", func() {
+		const resourceName = "test-resource"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+
+		BeforeEach(func() {
+			By("creating a ready node in Paris")
+			node := &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "paris-1",
+					Labels: map[string]string{
+						labeller.CityLabel:    "Paris",
+						labeller.CountryLabel: "FR",
+					},
+				},
+			}
+			if err := k8sClient.Create(ctx, node); err != nil && !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+			node.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}
+			Expect(k8sClient.Status().Update(ctx, node)).To(Succeed())
+
+			By("creating the custom resource for the Kind SelectiveDeployment")
+			sd := &appsv1.SelectiveDeployment{}
+			if err := k8sClient.Get(ctx, typeNamespacedName, sd); err != nil && errors.IsNotFound(err) {
+				sd = &appsv1.SelectiveDeployment{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      resourceName,
+						Namespace: "default",
+					},
+					Spec: appsv1.SelectiveDeploymentSpec{
+						Kind: appsv1.WorkloadKindDeployment,
+						Template: runtime.RawExtension{
+							Raw: []byte(`{"template":{"spec":{"containers":[{"name":"nginx","image":"nginx"}]}}}`),
+						},
+						Selectors: []appsv1.Selector{{Countries: []string{"FR"}}},
+					},
+				}
+				Expect(k8sClient.Create(ctx, sd)).To(Succeed())
+			}
+		})
+
+		It("should schedule the workload to the selected nodes", func() {
+			controllerReconciler := &SelectiveDeploymentReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			deployment := &kappsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, deployment)).To(Succeed())
+			Expect(*deployment.Spec.Replicas).To(Equal(int32(1)))
+
+			terms := deployment.Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+			Expect(terms[0].MatchExpressions[0].Values).To(Equal([]string{"paris-1"}))
+
+			sd := &appsv1.SelectiveDeployment{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, sd)).To(Succeed())
+			Expect(sd.Status.State).To(Equal(appsv1.SelectiveDeploymentStateSuccess))
+			Expect(sd.Status.Nodes).To(Equal([]string{"paris-1"}))
+		})
+	})
+})
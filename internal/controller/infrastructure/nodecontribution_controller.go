@@ -0,0 +1,218 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	infrastructurev1 "github.com/edgenet-project/edgenet/api/infrastructure/v1"
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/events/v1"
+	"github.com/edgenet-project/edgenet/internal/infrastructure/v1"
+	"github.com/edgenet-project/edgenet/internal/utils"
+)
+
+// The interval to check again a contribution whose tenant doesn't exist.
+const contributionRetryInterval = time.Minute
+
+// NodeContributionReconciler reconciles a NodeContribution object
+type NodeContributionReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	recorder *events.Recorder
+}
+
+//+kubebuilder:rbac:groups=infrastructure.edge-net.io,resources=nodecontributions,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=infrastructure.edge-net.io,resources=nodecontributions/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=infrastructure.edge-net.io,resources=nodecontributions/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="multitenancy.edge-net.io",resources=tenants,verbs=get;list;watch
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+// Until the contributed node joins, a bootstrap token is kept valid for it. Once the node joins it is labelled
+// with the contributing tenant and its readiness is reported in the status.
+//
+// For more details, check Reconcile and its Result here:
+// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.17.0/pkg/reconcile
+func (r *NodeContributionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	l := log.FromContext(ctx)
+	nc := infrastructurev1.NodeContribution{}
+	isMarkedForDeletion, reconcileResult, err := utils.GetResourceWithFinalizer(ctx, r.Client, &nc, req.NamespacedName)
+
+	if !utils.IsObjectInitialized(&nc) {
+		return reconcileResult, err
+	}
+
+	infrastructureManager, err := infrastructure.NewInfrastructureManager(ctx, r.Client)
+
+	if err != nil {
+		l.Error(err, "cannot create infrastructure manager")
+		return ctrl.Result{}, err
+	}
+
+	if isMarkedForDeletion {
+		// Remove the tokens and the labels and allow contribution object for deletion
+		if err := infrastructureManager.NodeContributionCleanup(ctx, &nc); err != nil {
+			r.recorder.Error(ctx, &nc, events.CleanupFailed, err, "NodeContribution cleanup failed")
+			return ctrl.Result{Requeue: true}, err
+		}
+
+		return utils.AllowObjectDeletion(ctx, r.Client, &nc)
+	}
+
+	status := nc.Status.DeepCopy()
+	result := ctrl.Result{}
+
+	tenant := &multitenancyv1.Tenant{}
+	if err := r.Get(ctx, types.NamespacedName{Name: nc.Spec.Tenant}, tenant); err != nil {
+		if !errors.IsNotFound(err) {
+			return ctrl.Result{Requeue: true}, err
+		}
+		status.State = infrastructurev1.NodeContributionStateFailed
+		status.Message = fmt.Sprintf("Tenant %s doesn't exist", nc.Spec.Tenant)
+		return ctrl.Result{RequeueAfter: contributionRetryInterval}, r.updateStatus(ctx, &nc, status)
+	}
+
+	node, err := infrastructureManager.FindContributedNode(ctx, &nc)
+
+	if err != nil {
+		return ctrl.Result{Requeue: true}, err
+	}
+
+	if node == nil {
+		expiry, err := infrastructureManager.IssueBootstrapToken(ctx, &nc)
+
+		if err != nil {
+			r.recorder.Error(ctx, &nc, events.BootstrapTokenFailed, err, "Bootstrap token creation failed")
+			return ctrl.Result{Requeue: true}, err
+		}
+
+		tokenExpiry := metav1.NewTime(expiry)
+		status.State = infrastructurev1.NodeContributionStatePending
+		status.Message = "Waiting for the node to join the cluster"
+		status.JoinSecret = infrastructure.ResolveJoinSecretName(&nc)
+		status.TokenExpiry = &tokenExpiry
+		// Issue a new token when the current one expires.
+		result.RequeueAfter = time.Until(expiry)
+	} else {
+		if err := infrastructureManager.LinkContributedNode(ctx, &nc, node); err != nil {
+			r.recorder.Error(ctx, &nc, events.LabellingFailed, err, "Node labelling failed")
+			return ctrl.Result{Requeue: true}, err
+		}
+
+		status.Node = node.GetName()
+		status.JoinSecret = ""
+		status.TokenExpiry = nil
+		status.LastHeartbeat = nil
+
+		for _, condition := range node.Status.Conditions {
+			if condition.Type == corev1.NodeReady && !condition.LastHeartbeatTime.IsZero() {
+				heartbeat := condition.LastHeartbeatTime
+				status.LastHeartbeat = &heartbeat
+			}
+		}
+
+		if utils.IsNodeReady(node) {
+			status.State = infrastructurev1.NodeContributionStateReady
+			status.Message = fmt.Sprintf("Node %s is ready", node.GetName())
+		} else {
+			status.State = infrastructurev1.NodeContributionStateNotReady
+			status.Message = fmt.Sprintf("Node %s is not ready", node.GetName())
+		}
+	}
+
+	return result, r.updateStatus(ctx, &nc, status)
+}
+
+// Updates the status of the contribution if it is changed.
+func (r *NodeContributionReconciler) updateStatus(ctx context.Context, nc *infrastructurev1.NodeContribution, status *infrastructurev1.NodeContributionStatus) error {
+	if reflect.DeepEqual(nc.Status, *status) {
+		return nil
+	}
+
+	stateChanged := nc.Status.State != status.State
+	nc.Status = *status
+	if err := r.Status().Update(ctx, nc); err != nil {
+		return err
+	}
+
+	// The heartbeats change the status often, only the state changes are recorded.
+	if stateChanged {
+		r.recorder.Normal(ctx, nc, events.NodeContributionUpdated, status.Message)
+	}
+	return nil
+}
+
+// Maps a node to the contributions it might belong to.
+func (r *NodeContributionReconciler) mapNodeToContributions(ctx context.Context, obj client.Object) []reconcile.Request {
+	if contribution, ok := obj.GetLabels()[infrastructurev1.ContributionLabel]; ok {
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: contribution}}}
+	}
+
+	node, ok := obj.(*corev1.Node)
+	if !ok {
+		return nil
+	}
+
+	ncList := &infrastructurev1.NodeContributionList{}
+	if err := r.List(ctx, ncList); err != nil {
+		log.FromContext(ctx).Error(err, "cannot list the node contributions")
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, nc := range ncList.Items {
+		matches := nc.Spec.Host == node.GetName()
+		for _, address := range node.Status.Addresses {
+			if nc.Spec.ExpectedIP != "" && address.Address == nc.Spec.ExpectedIP {
+				matches = true
+			}
+		}
+		if matches {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: nc.GetName()}})
+		}
+	}
+
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *NodeContributionReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Setup the event recorder
+	r.recorder = events.NewRecorder(utils.GetEventRecorder(mgr))
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&infrastructurev1.NodeContribution{}).
+		Watches(&corev1.Node{}, handler.EnqueueRequestsFromMapFunc(r.mapNodeToContributions)).
+		Complete(r)
+}
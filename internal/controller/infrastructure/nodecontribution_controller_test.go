@@ -0,0 +1,140 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	infrastructurev1 "github.com/edgenet-project/edgenet/api/infrastructure/v1"
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/infrastructure/v1"
+)
+
+var _ = Describe("NodeContribution Controller", func() {
+	Context("When reconciling a resource", func() {
+		const tenantName = "test-nodecontribution"
+		const resourceName = "test-resource"
+		const hostName = "edge-1"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name: resourceName,
+		}
+
+		BeforeEach(func() {
+			By("creating the tenant and its core namespace")
+			tenant := &multitenancyv1.Tenant{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: tenantName,
+				},
+				Spec: multitenancyv1.TenantSpec{
+					FullName:       "Test Tenant",
+					Admin:          "testadmin",
+					URL:            "https://example.com",
+					InitialRequest: map[corev1.ResourceName]resource.Quantity{},
+				},
+			}
+			if err := k8sClient.Create(ctx, tenant); err != nil && !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: tenantName}}
+			if err := k8sClient.Create(ctx, namespace); err != nil && !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			By("creating the custom resource for the Kind NodeContribution")
+			nc := &infrastructurev1.NodeContribution{}
+			if err := k8sClient.Get(ctx, typeNamespacedName, nc); err != nil && errors.IsNotFound(err) {
+				nc = &infrastructurev1.NodeContribution{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: resourceName,
+					},
+					Spec: infrastructurev1.NodeContributionSpec{
+						Tenant: tenantName,
+						Host:   hostName,
+					},
+				}
+				Expect(k8sClient.Create(ctx, nc)).To(Succeed())
+			}
+		})
+
+		It("should issue a token and link the node once it joins", func() {
+			controllerReconciler := &NodeContributionReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			By("issuing a bootstrap token")
+			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+
+			nc := &infrastructurev1.NodeContribution{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, nc)).To(Succeed())
+			Expect(nc.Status.State).To(Equal(infrastructurev1.NodeContributionStatePending))
+			Expect(nc.Status.TokenExpiry).NotTo(BeNil())
+
+			tokens := &corev1.SecretList{}
+			Expect(k8sClient.List(ctx, tokens, client.InNamespace(metav1.NamespaceSystem),
+				client.MatchingLabels{infrastructurev1.ContributionLabel: resourceName})).To(Succeed())
+			Expect(tokens.Items).To(HaveLen(1))
+			Expect(tokens.Items[0].Type).To(Equal(corev1.SecretTypeBootstrapToken))
+
+			joinSecret := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: infrastructure.ResolveJoinSecretName(nc), Namespace: tenantName}, joinSecret)).To(Succeed())
+			Expect(joinSecret.Data).To(HaveKey(infrastructure.JoinTokenKey))
+
+			By("joining the node")
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: hostName}}
+			Expect(k8sClient.Create(ctx, node)).To(Succeed())
+			node.Status.Conditions = []corev1.NodeCondition{{
+				Type:              corev1.NodeReady,
+				Status:            corev1.ConditionTrue,
+				LastHeartbeatTime: metav1.Now(),
+			}}
+			Expect(k8sClient.Status().Update(ctx, node)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: hostName}, node)).To(Succeed())
+			Expect(node.GetLabels()).To(HaveKeyWithValue(infrastructurev1.OwnerTenantLabel, tenantName))
+			Expect(node.GetLabels()).To(HaveKeyWithValue(infrastructurev1.ContributionLabel, resourceName))
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, nc)).To(Succeed())
+			Expect(nc.Status.State).To(Equal(infrastructurev1.NodeContributionStateReady))
+			Expect(nc.Status.Node).To(Equal(hostName))
+			Expect(nc.Status.LastHeartbeat).NotTo(BeNil())
+
+			Expect(k8sClient.List(ctx, tokens, client.InNamespace(metav1.NamespaceSystem),
+				client.MatchingLabels{infrastructurev1.ContributionLabel: resourceName})).To(Succeed())
+			Expect(tokens.Items).To(BeEmpty())
+		})
+	})
+})
@@ -18,13 +18,26 @@ package labellers
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
+	"github.com/edgenet-project/edgenet/internal/events/v1"
 	"github.com/edgenet-project/edgenet/internal/labeller/v1"
+	"github.com/edgenet-project/edgenet/internal/notification/v1"
+	"github.com/edgenet-project/edgenet/internal/operator/v1"
 	"github.com/edgenet-project/edgenet/internal/utils"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	corev1 "k8s.io/api/core/v1"
 )
@@ -32,50 +45,183 @@ import (
 // NodeLabellerReconciler reconciles a NodeLabeller object
 type NodeLabellerReconciler struct {
 	client.Client
-	Scheme  *runtime.Scheme
+	Scheme *runtime.Scheme
+
+	// The geolocation labels of the nodes are kept as they are if the MaxMind configuration is not given.
 	MaxMind labeller.MaxMind
+
+	// The ConfigMap holding the capability rules, the default rules are used if it doesn't exist.
+	CapabilityRules types.NamespacedName
+
+	// Only report the label changes instead of applying them. Can be overridden on each node by an annotation.
+	DryRun bool
+
+	// Notifies the operators of the label changes and the relocated nodes, nil disables the notifications.
+	Notifier notification.Notifier
+
+	// The settings of the EdgeNetConfig, their MaxMind configuration overrides the one above. Nil uses the
+	// MaxMind configuration as it is.
+	Settings *operator.Store
+
+	// Receives an event when the MaxMind credentials are reloaded, the nodes are labelled again with their
+	// geolocation. Nil if the credentials cannot change.
+	MaxMindUpdates <-chan event.GenericEvent
+
+	recorder *events.Recorder
 }
 
-//+kubebuilder:rbac:groups=core,resources=node,verbs=get;list;watch;create;update;patch
+//+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
-// TODO(user): Modify the Reconcile function to compare the state specified by
-// the NodeLabeller object against the actual cluster state, and then
-// perform operations to make the cluster state reflect the state specified by
-// the user.
+// The labels of the node are computed by the labeller plugins, such as the geolocation and the capabilities,
+// and added to the node. In the dry-run mode the changes are recorded in an annotation and an event instead.
 //
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.17.0/pkg/reconcile
 func (r *NodeLabellerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	_ = log.FromContext(ctx)
-
 	// Get the node directly
 	node := &corev1.Node{}
 
 	if err := utils.GetResource(ctx, r.Client, node, req.NamespacedName); err != nil {
+		if errors.IsNotFound(err) {
+			labeller.RecordPendingLabelChanges(req.Name, 0)
+			return ctrl.Result{}, nil
+		}
 		return ctrl.Result{}, err
 	}
 
 	// Create the labeller manager
-	labellerManager, err := labeller.NewLabelManager(ctx, r.Client, r.MaxMind)
+	labellerManager, err := labeller.NewLabelManager(ctx, r.Client, r.labellers()...)
 
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
-	// Label the node
-	if err := labellerManager.LabelNode(ctx, node); err != nil {
+	if labeller.IsDryRun(node, r.DryRun) {
+		changes, reported, err := labellerManager.ReportNodeLabels(ctx, node)
+		err = labeller.IgnoreMaxMindUnavailable(err)
+
+		// The changes of the labellers that succeeded are reported even if one of them fails.
+		if reported && len(changes) != 0 {
+			messages := make([]string, 0, len(changes))
+			for _, change := range changes {
+				messages = append(messages, change.String())
+			}
+			r.recorder.Normal(ctx, node, events.LabelsPending, fmt.Sprintf("Labels not applied in dry-run mode: %s", strings.Join(messages, ", ")))
+		}
+
+		if changes != nil {
+			labeller.RecordPendingLabelChanges(node.GetName(), len(changes))
+		}
+
+		r.recordLabellerErrors(ctx, node, err)
+
 		return ctrl.Result{}, err
 	}
 
-	return ctrl.Result{}, nil
+	labeller.RecordPendingLabelChanges(node.GetName(), 0)
+
+	previousLocation := labeller.FormatLocation(node.GetLabels())
+
+	// Label the node
+	changes, err := labellerManager.LabelNode(ctx, node)
+	err = labeller.IgnoreMaxMindUnavailable(err)
+
+	// The changes applied are notified even if one of the labellers failed.
+	if len(changes) != 0 {
+		r.recorder.Normal(ctx, node, events.LabelsApplied, fmt.Sprintf("Labels applied: %s", labeller.FormatLabelChanges(changes)))
+
+		r.notify(ctx, node, notification.EventNodeLabelled, map[string]string{
+			"node":    node.GetName(),
+			"changes": labeller.FormatLabelChanges(changes),
+		})
+
+		if location := labeller.FormatLocation(node.GetLabels()); previousLocation != "" && location != "" && location != previousLocation {
+			r.notify(ctx, node, notification.EventNodeRelocated, map[string]string{
+				"node":     node.GetName(),
+				"previous": previousLocation,
+				"location": location,
+			})
+		}
+	}
+
+	r.recordLabellerErrors(ctx, node, err)
+
+	return ctrl.Result{}, err
+}
+
+// Records the failure of each labeller with its own reason, so a geolocation outage can be told apart from a
+// broken capability rule.
+func (r *NodeLabellerReconciler) recordLabellerErrors(ctx context.Context, node *corev1.Node, err error) {
+	for _, labellerErr := range labeller.LabellerErrors(err) {
+		switch labellerErr.Labeller {
+		case labeller.GeolocationLabellerName:
+			r.recorder.Error(ctx, node, events.GeolocationFailed, labellerErr.Err, "Geolocation of the node failed")
+		case labeller.CapabilityLabellerName:
+			r.recorder.Error(ctx, node, events.CapabilityDetectionFailed, labellerErr.Err, "Capability detection of the node failed")
+		default:
+			r.recorder.Error(ctx, node, events.LabellingFailed, labellerErr.Err, "Labelling of the node failed")
+		}
+	}
+}
+
+// Sends a notification about the node. The notifications are best effort, a failure is only recorded.
+func (r *NodeLabellerReconciler) notify(ctx context.Context, node *corev1.Node, event notification.Event, data map[string]string) {
+	if err := notification.Notify(ctx, r.Notifier, notification.Notification{Event: event, Data: data}); err != nil {
+		r.recorder.Error(ctx, node, events.NotificationFailed, err, fmt.Sprintf("Node notification %s failed", event))
+	}
+}
+
+// Returns the enabled labeller plugins.
+func (r *NodeLabellerReconciler) labellers() []labeller.Labeller {
+	maxMind := r.MaxMind
+	if r.Settings != nil {
+		maxMind = r.Settings.Get().MaxMind
+	}
+
+	// The geolocation labeller is always there, otherwise its labels would be removed from the nodes. While the
+	// credentials are not available it fails and the labels are kept, the nodes are labelled again once they are.
+	return []labeller.Labeller{
+		&labeller.CapabilityLabeller{Client: r.Client, ConfigMap: r.CapabilityRules},
+		&labeller.GeolocationLabeller{MaxMind: maxMind},
+	}
+}
+
+// All of the nodes are labelled again when the capability rules or the MaxMind credentials change.
+func (r *NodeLabellerReconciler) mapToNodes(ctx context.Context, obj client.Object) []reconcile.Request {
+	nodeList := &corev1.NodeList{}
+	if err := r.List(ctx, nodeList); err != nil {
+		log.FromContext(ctx).Error(err, "cannot list the nodes")
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, node := range nodeList.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: node.GetName()}})
+	}
+
+	return requests
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *NodeLabellerReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	// Setup the event recorder
+	r.recorder = events.NewRecorder(utils.GetEventRecorder(mgr))
+
+	isRulesConfigMap := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetNamespace() == r.CapabilityRules.Namespace && obj.GetName() == r.CapabilityRules.Name
+	})
+
+	b := ctrl.NewControllerManagedBy(mgr).
 		// Uncomment the following line adding a pointer to an instance of the controlled resource as an argument
 		For(&corev1.Node{}).
-		Complete(r)
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.mapToNodes), builder.WithPredicates(isRulesConfigMap))
+
+	if r.MaxMindUpdates != nil {
+		b = b.WatchesRawSource(&source.Channel{Source: r.MaxMindUpdates}, handler.EnqueueRequestsFromMapFunc(r.mapToNodes))
+	}
+
+	return b.Complete(r)
 }
@@ -17,7 +17,19 @@ limitations under the License.
 package labellers
 
 import (
+	"context"
+	"strings"
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/edgenet-project/edgenet/internal/labeller/v1"
 )
 
 var _ = Describe("NodeLabeller Controller", func() {
@@ -29,4 +41,91 @@ var _ = Describe("NodeLabeller Controller", func() {
 			// Example: If you expect a certain status condition after reconciliation, verify it here.
 		})
 	})
+
+	Context("When reconciling a node in the dry-run mode", func() {
+		const nodeName = "test-dry-run-node"
+
+		ctx := context.Background()
+		key := types.NamespacedName{Name: nodeName}
+
+		BeforeEach(func() {
+			By("creating the node")
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+				Name:        nodeName,
+				Annotations: map[string]string{labeller.DryRunAnnotation: "true"},
+			}}
+			if err := k8sClient.Create(ctx, node); err != nil && !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+
+		It("should report the changes, then apply them once the dry-run is disabled", func() {
+			controllerReconciler := &NodeLabellerReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			node := &corev1.Node{}
+			Expect(k8sClient.Get(ctx, key, node)).To(Succeed())
+			Expect(node.GetAnnotations()).To(HaveKeyWithValue(labeller.PendingLabelsAnnotation, "edge-net.io/device-class=server"))
+			Expect(node.GetLabels()).NotTo(HaveKey(labeller.DeviceClassLabel))
+
+			By("disabling the dry-run on the node")
+			node.GetAnnotations()[labeller.DryRunAnnotation] = "false"
+			Expect(k8sClient.Update(ctx, node)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, key, node)).To(Succeed())
+			Expect(node.GetAnnotations()).NotTo(HaveKey(labeller.PendingLabelsAnnotation))
+			Expect(node.GetLabels()).To(HaveKeyWithValue(labeller.DeviceClassLabel, "server"))
+		})
+	})
+
+	Context("When reconciling a node while MaxMind is not available", func() {
+		const nodeName = "test-geolocation-node"
+
+		ctx := context.Background()
+		key := types.NamespacedName{Name: nodeName}
+
+		BeforeEach(func() {
+			By("creating the node with the managed geolocation labels")
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+				Name: nodeName,
+				Labels: map[string]string{
+					labeller.CityLabel:      "Paris",
+					labeller.CountryLabel:   "FR",
+					labeller.ContinentLabel: "Europe",
+				},
+				Annotations: map[string]string{
+					labeller.ManagedLabelsAnnotation: strings.Join([]string{labeller.CityLabel, labeller.ContinentLabel, labeller.CountryLabel}, ","),
+				},
+			}}
+			if err := k8sClient.Create(ctx, node); err != nil && !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+
+		It("should keep the geolocation labels of the node", func() {
+			controllerReconciler := &NodeLabellerReconciler{
+				Client:  k8sClient,
+				Scheme:  k8sClient.Scheme(),
+				MaxMind: labeller.NewMaxMindWatcher("https://geoip.maxmind.com/geoip/v2.1/city/", GinkgoT().TempDir(), time.Minute),
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			node := &corev1.Node{}
+			Expect(k8sClient.Get(ctx, key, node)).To(Succeed())
+			Expect(node.GetLabels()).To(HaveKeyWithValue(labeller.CityLabel, "Paris"))
+			Expect(node.GetLabels()).To(HaveKeyWithValue(labeller.CountryLabel, "FR"))
+			Expect(node.GetLabels()).To(HaveKeyWithValue(labeller.ContinentLabel, "Europe"))
+			Expect(node.GetAnnotations()[labeller.ManagedLabelsAnnotation]).To(ContainSubstring(labeller.CityLabel))
+		})
+	})
 })
@@ -0,0 +1,110 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package labellers
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	infrastructurev1 "github.com/edgenet-project/edgenet/api/infrastructure/v1"
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/labeller/v1"
+)
+
+// NodeOwnerReconciler reconciles the ownership labels and taints of the nodes
+type NodeOwnerReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups=infrastructure.edge-net.io,resources=nodecontributions,verbs=get;list;watch
+//+kubebuilder:rbac:groups="multitenancy.edge-net.io",resources=tenants,verbs=get;list;watch
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+// The owner of the node is resolved from its node contribution or its annotations. The node is labelled with
+// the owner and tainted according to the node scheduling policy of the owner tenant.
+//
+// For more details, check Reconcile and its Result here:
+// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.17.0/pkg/reconcile
+func (r *NodeOwnerReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	l := log.FromContext(ctx)
+
+	node := &corev1.Node{}
+
+	if err := r.Get(ctx, req.NamespacedName, node); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	ownershipManager, err := labeller.NewOwnershipManager(ctx, r.Client)
+
+	if err != nil {
+		l.Error(err, "cannot create ownership manager")
+		return ctrl.Result{}, err
+	}
+
+	if err := ownershipManager.LabelNodeOwner(ctx, node); err != nil {
+		l.Error(err, "cannot label the owner of the node")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// Maps a node contribution to its node.
+func (r *NodeOwnerReconciler) mapContributionToNode(ctx context.Context, obj client.Object) []reconcile.Request {
+	nc, ok := obj.(*infrastructurev1.NodeContribution)
+	if !ok || nc.Status.Node == "" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: nc.Status.Node}}}
+}
+
+// Maps a tenant to the nodes it owns, so their taints follow the node scheduling policy.
+func (r *NodeOwnerReconciler) mapTenantToNodes(ctx context.Context, obj client.Object) []reconcile.Request {
+	nodeList := &corev1.NodeList{}
+	if err := r.List(ctx, nodeList, client.MatchingLabels{infrastructurev1.OwnerTenantLabel: obj.GetName()}); err != nil {
+		log.FromContext(ctx).Error(err, "cannot list the nodes of the tenant")
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, node := range nodeList.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: node.GetName()}})
+	}
+
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *NodeOwnerReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("nodeowner").
+		For(&corev1.Node{}).
+		Watches(&infrastructurev1.NodeContribution{}, handler.EnqueueRequestsFromMapFunc(r.mapContributionToNode)).
+		Watches(&multitenancyv1.Tenant{}, handler.EnqueueRequestsFromMapFunc(r.mapTenantToNodes)).
+		Complete(r)
+}
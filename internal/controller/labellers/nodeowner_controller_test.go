@@ -0,0 +1,106 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package labellers
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	infrastructurev1 "github.com/edgenet-project/edgenet/api/infrastructure/v1"
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/labeller/v1"
+)
+
+var _ = Describe("NodeOwner Controller", func() {
+	Context("When reconciling a node assigned to a tenant", func() {
+		const tenantName = "test-owner-tenant"
+		const nodeName = "test-owned-node"
+
+		ctx := context.Background()
+		key := types.NamespacedName{Name: nodeName}
+
+		BeforeEach(func() {
+			By("creating the tenant with exclusive nodes")
+			tenant := &multitenancyv1.Tenant{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: tenantName,
+				},
+				Spec: multitenancyv1.TenantSpec{
+					FullName:       "Test Tenant",
+					Admin:          "testadmin",
+					URL:            "https://example.com",
+					InitialRequest: map[corev1.ResourceName]resource.Quantity{},
+					NodeScheduling: multitenancyv1.NodeSchedulingExclusive,
+				},
+			}
+			if err := k8sClient.Create(ctx, tenant); err != nil && !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			By("creating the node with the owner annotations")
+			node := &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: nodeName,
+					Annotations: map[string]string{
+						infrastructurev1.OwnerTenantAnnotation: tenantName,
+						infrastructurev1.ContributorAnnotation: "Example University",
+					},
+				},
+			}
+			if err := k8sClient.Create(ctx, node); err != nil && !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+
+		It("should label and taint the node, then remove them with the annotations", func() {
+			controllerReconciler := &NodeOwnerReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			node := &corev1.Node{}
+			Expect(k8sClient.Get(ctx, key, node)).To(Succeed())
+			Expect(node.GetLabels()).To(HaveKeyWithValue(infrastructurev1.OwnerTenantLabel, tenantName))
+			Expect(node.GetLabels()).To(HaveKeyWithValue(infrastructurev1.ContributorLabel, "Example_University"))
+			Expect(node.Spec.Taints).To(ContainElement(HaveField("Key", labeller.OwnerTaintKey)))
+			Expect(node.Spec.Taints[len(node.Spec.Taints)-1].Effect).To(Equal(corev1.TaintEffectNoSchedule))
+
+			By("removing the annotations")
+			node.SetAnnotations(nil)
+			Expect(k8sClient.Update(ctx, node)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, key, node)).To(Succeed())
+			Expect(node.GetLabels()).NotTo(HaveKey(infrastructurev1.OwnerTenantLabel))
+			Expect(node.GetLabels()).NotTo(HaveKey(infrastructurev1.ContributorLabel))
+			Expect(node.Spec.Taints).NotTo(ContainElement(HaveField("Key", labeller.OwnerTaintKey)))
+		})
+	})
+})
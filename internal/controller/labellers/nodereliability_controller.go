@@ -0,0 +1,110 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package labellers
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/edgenet-project/edgenet/internal/labeller/v1"
+	"github.com/edgenet-project/edgenet/internal/utils"
+)
+
+// The score changes with time even if the node doesn't, the nodes are scored again after this interval.
+const reliabilityResyncInterval = 10 * time.Minute
+
+// NodeReliabilityReconciler reconciles the reliability labels of the nodes
+type NodeReliabilityReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// The sliding window the reliability is computed over.
+	Window time.Duration
+}
+
+//+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch;update;patch
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+// The transitions of the ready condition are recorded on the node. The uptime and the number of flaps over the
+// window give the reliability tier of the node, which is set as a label and exported as metrics.
+//
+// For more details, check Reconcile and its Result here:
+// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.17.0/pkg/reconcile
+func (r *NodeReliabilityReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	l := log.FromContext(ctx)
+
+	node := &corev1.Node{}
+
+	if err := r.Get(ctx, req.NamespacedName, node); err != nil {
+		if errors.IsNotFound(err) {
+			labeller.DeleteReliabilityMetrics(req.Name)
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	reliabilityManager, err := labeller.NewReliabilityManager(ctx, r.Client, r.Window)
+
+	if err != nil {
+		l.Error(err, "cannot create reliability manager")
+		return ctrl.Result{}, err
+	}
+
+	score, err := reliabilityManager.UpdateNodeReliability(ctx, node, time.Now())
+
+	if err != nil {
+		l.Error(err, "cannot update the reliability of the node")
+		return ctrl.Result{}, err
+	}
+
+	labeller.RecordReliabilityMetrics(node.GetName(), score)
+
+	return ctrl.Result{RequeueAfter: reliabilityResyncInterval}, nil
+}
+
+// The status of the nodes is updated by the heartbeats, only the changes of the ready condition are relevant.
+var nodeReadinessChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldNode, ok := e.ObjectOld.(*corev1.Node)
+		if !ok {
+			return false
+		}
+		newNode, ok := e.ObjectNew.(*corev1.Node)
+		if !ok {
+			return false
+		}
+		return utils.IsNodeReady(oldNode) != utils.IsNodeReady(newNode)
+	},
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *NodeReliabilityReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("nodereliability").
+		For(&corev1.Node{}, builder.WithPredicates(nodeReadinessChanged)).
+		Complete(r)
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package labellers
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/edgenet-project/edgenet/internal/labeller/v1"
+)
+
+var _ = Describe("NodeReliability Controller", func() {
+	Context("When reconciling a node", func() {
+		const nodeName = "test-reliability-node"
+
+		ctx := context.Background()
+		key := types.NamespacedName{Name: nodeName}
+
+		BeforeEach(func() {
+			By("creating the node")
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName}}
+			if err := k8sClient.Create(ctx, node); err != nil && !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+
+		It("should record the history and label the node", func() {
+			controllerReconciler := &NodeReliabilityReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				Window: 24 * time.Hour,
+			}
+
+			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(Equal(reliabilityResyncInterval))
+
+			node := &corev1.Node{}
+			Expect(k8sClient.Get(ctx, key, node)).To(Succeed())
+			Expect(node.GetAnnotations()).To(HaveKey(labeller.ReadyHistoryAnnotation))
+
+			// The node has no ready condition, so it is not ready.
+			Expect(node.GetLabels()).To(HaveKeyWithValue(labeller.ReliabilityLabel, labeller.ReliabilityLow))
+		})
+	})
+})
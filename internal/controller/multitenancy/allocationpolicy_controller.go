@@ -0,0 +1,137 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multitenancy
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/events/v1"
+	"github.com/edgenet-project/edgenet/internal/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/utils"
+)
+
+// AllocationPolicyReconciler reconciles an AllocationPolicy object
+type AllocationPolicyReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	recorder *events.Recorder
+}
+
+//+kubebuilder:rbac:groups=multitenancy.edge-net.io,resources=allocationpolicies,verbs=get;list;watch
+//+kubebuilder:rbac:groups=multitenancy.edge-net.io,resources=allocationpolicies/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=multitenancy.edge-net.io,resources=tenants,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+// The capacity of the schedulable nodes, the allocations of the tenants and the headroom left are computed again
+// whenever the nodes join or leave, or the tenants change their requests.
+//
+// For more details, check Reconcile and its Result here:
+// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.17.0/pkg/reconcile
+func (r *AllocationPolicyReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	// Only the singleton policy is enforced, the others cannot be created.
+	if req.Name != multitenancyv1.AllocationPolicyName {
+		return ctrl.Result{}, nil
+	}
+
+	policy := &multitenancyv1.AllocationPolicy{}
+	if err := r.Get(ctx, req.NamespacedName, policy); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	nodeList := &corev1.NodeList{}
+	if err := r.List(ctx, nodeList); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	tenantList := &multitenancyv1.TenantList{}
+	if err := r.List(ctx, tenantList); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	wasOvercommitted := meta.IsStatusConditionTrue(policy.Status.Conditions, multitenancyv1.AllocationPolicyConditionOvercommitted)
+	status := multitenancy.ComputeAllocationStatus(policy, nodeList.Items, tenantList.Items)
+
+	if equality.Semantic.DeepEqual(policy.Status, status) {
+		return ctrl.Result{}, nil
+	}
+
+	policy.Status = status
+	if err := r.Status().Update(ctx, policy); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	condition := meta.FindStatusCondition(status.Conditions, multitenancyv1.AllocationPolicyConditionOvercommitted)
+	switch isOvercommitted := condition.Status == metav1.ConditionTrue; {
+	case isOvercommitted && !wasOvercommitted:
+		r.recorder.Warning(ctx, policy, events.AllocationOvercommitted, condition.Message)
+	case !isOvercommitted && wasOvercommitted:
+		r.recorder.Normal(ctx, policy, events.AllocationRecovered, condition.Message)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// Any change of the nodes or the tenants updates the singleton policy.
+func (r *AllocationPolicyReconciler) mapToPolicy(ctx context.Context, obj client.Object) []reconcile.Request {
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: multitenancyv1.AllocationPolicyName}}}
+}
+
+// Only the nodes joining, leaving or changing their allocatable resources change the capacity. The status of the
+// nodes is updated often, the other changes are ignored.
+var nodeCapacityChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldNode, ok := e.ObjectOld.(*corev1.Node)
+		if !ok {
+			return true
+		}
+		newNode, ok := e.ObjectNew.(*corev1.Node)
+		if !ok {
+			return true
+		}
+		return utils.IsNodeSchedulable(oldNode) != utils.IsNodeSchedulable(newNode) ||
+			!equality.Semantic.DeepEqual(oldNode.Status.Allocatable, newNode.Status.Allocatable)
+	},
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *AllocationPolicyReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Setup the event recorder
+	r.recorder = events.NewRecorder(utils.GetEventRecorder(mgr))
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&multitenancyv1.AllocationPolicy{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&corev1.Node{}, handler.EnqueueRequestsFromMapFunc(r.mapToPolicy), builder.WithPredicates(nodeCapacityChanged)).
+		Watches(&multitenancyv1.Tenant{}, handler.EnqueueRequestsFromMapFunc(r.mapToPolicy), builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multitenancy
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/edgenet-project/edgenet/internal/events/v1"
+	"github.com/edgenet-project/edgenet/internal/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/utils"
+)
+
+// PropagationReconciler copies the objects of the parent namespaces into the SubNamespaces.
+type PropagationReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	recorder *events.Recorder
+
+	// Cluster-wide settings of the multitenancy manager.
+	Config multitenancy.Config
+}
+
+//+kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=roles;rolebindings,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=roles;clusterroles,verbs=escalate;bind
+//+kubebuilder:rbac:groups="",resources=secrets;configmaps;limitranges,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+// The request is the name of a SubNamespace namespace, the objects of its parent are copied into it.
+//
+// For more details, check Reconcile and its Result here:
+// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.17.0/pkg/reconcile
+func (r *PropagationReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	l := log.FromContext(ctx)
+	namespace := &corev1.Namespace{}
+
+	if err := utils.GetResource(ctx, r.Client, namespace, req.NamespacedName); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	multiTenancyManager, err := multitenancy.NewMultiTenancyManager(ctx, r.Client, r.Config)
+
+	if err != nil {
+		l.Error(err, "cannot create multitenancy manager")
+		return ctrl.Result{}, err
+	}
+
+	conflicts, err := multiTenancyManager.PropagateObjects(ctx, namespace)
+
+	if err != nil {
+		// The parent namespace might be removed before the child.
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		r.recorder.Error(ctx, namespace, events.PropagationFailed, err, "Namespace propagation failed")
+		return ctrl.Result{Requeue: true}, err
+	}
+
+	for _, conflict := range conflicts {
+		r.recorder.Warning(ctx, namespace, events.PropagationConflict, fmt.Sprintf("Cannot propagate %s, an object with the same name exists", conflict))
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// Maps a propagatable object to the namespaces it should be copied to. These are the direct children of the
+// namespace of the object. If the object itself is a copy, its namespace is reconciled as well to revert the
+// changes made on the copy.
+func (r *PropagationReconciler) mapObjectToNamespaces(ctx context.Context, obj client.Object) []reconcile.Request {
+	requests := []reconcile.Request{}
+
+	if _, ok := obj.GetLabels()[multitenancy.PropagatedFromLabel]; ok {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: obj.GetNamespace()}})
+	}
+
+	children := &corev1.NamespaceList{}
+	if err := r.List(ctx, children, client.MatchingLabels{"edge-net.io/parent": obj.GetNamespace()}); err != nil {
+		log.FromContext(ctx).Error(err, "cannot list the child namespaces", "namespace", obj.GetNamespace())
+		return requests
+	}
+
+	for _, child := range children.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: child.GetName()}})
+	}
+
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *PropagationReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Setup the event recorder
+	r.recorder = events.NewRecorder(utils.GetEventRecorder(mgr))
+
+	kinds := r.Config.PropagatedKinds
+	if len(kinds) == 0 {
+		kinds = multitenancy.DefaultPropagatedKinds
+	}
+
+	objects := map[string]client.Object{
+		"Role":        &rbacv1.Role{},
+		"RoleBinding": &rbacv1.RoleBinding{},
+		"Secret":      &corev1.Secret{},
+		"ConfigMap":   &corev1.ConfigMap{},
+		"LimitRange":  &corev1.LimitRange{},
+	}
+
+	// Only the SubNamespaces are reconciled.
+	isSubNamespace := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetLabels()["edge-net.io/kind"] == "sub"
+	})
+
+	b := ctrl.NewControllerManagedBy(mgr).
+		Named("propagation").
+		For(&corev1.Namespace{}, builder.WithPredicates(isSubNamespace))
+
+	for _, kind := range kinds {
+		obj, ok := objects[kind]
+		if !ok {
+			return fmt.Errorf("kind %q cannot be propagated", kind)
+		}
+		b = b.Watches(obj, handler.EnqueueRequestsFromMapFunc(r.mapObjectToNamespaces))
+	}
+
+	return b.Complete(r)
+}
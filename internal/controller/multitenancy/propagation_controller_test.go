@@ -0,0 +1,108 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multitenancy
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/edgenet-project/edgenet/internal/multitenancy/v1"
+)
+
+var _ = Describe("Propagation Controller", func() {
+	Context("When reconciling a SubNamespace namespace", func() {
+		const parentName = "test-propagation-parent"
+		const childName = "test-propagation-child"
+
+		ctx := context.Background()
+
+		BeforeEach(func() {
+			By("creating the parent and the child namespaces")
+			for _, namespace := range []*corev1.Namespace{
+				{ObjectMeta: metav1.ObjectMeta{Name: parentName}},
+				{ObjectMeta: metav1.ObjectMeta{Name: childName, Labels: map[string]string{
+					"edge-net.io/kind":   "sub",
+					"edge-net.io/parent": parentName,
+				}}},
+			} {
+				if err := k8sClient.Create(ctx, namespace); err != nil && !errors.IsAlreadyExists(err) {
+					Expect(err).NotTo(HaveOccurred())
+				}
+			}
+		})
+
+		It("should copy the roles of the parent and remove them with the source", func() {
+			role := &rbacv1.Role{
+				ObjectMeta: metav1.ObjectMeta{Name: "reader", Namespace: parentName},
+				Rules: []rbacv1.PolicyRule{
+					{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, role)).To(Succeed())
+
+			controllerReconciler := &PropagationReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			request := reconcile.Request{NamespacedName: types.NamespacedName{Name: childName}}
+
+			_, err := controllerReconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			propagated := &rbacv1.Role{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "reader", Namespace: childName}, propagated)).To(Succeed())
+			Expect(propagated.GetLabels()[multitenancy.PropagatedFromLabel]).To(Equal(parentName))
+			Expect(propagated.Rules).To(Equal(role.Rules))
+
+			By("deleting the source role")
+			Expect(k8sClient.Delete(ctx, role)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, request)
+			Expect(err).NotTo(HaveOccurred())
+
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: "reader", Namespace: childName}, propagated)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should not overwrite the objects created in the child", func() {
+			for _, namespace := range []string{parentName, childName} {
+				Expect(k8sClient.Create(ctx, &rbacv1.Role{
+					ObjectMeta: metav1.ObjectMeta{Name: "conflict", Namespace: namespace},
+				})).To(Succeed())
+			}
+
+			controllerReconciler := &PropagationReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: types.NamespacedName{Name: childName}})
+			Expect(err).NotTo(HaveOccurred())
+
+			existing := &rbacv1.Role{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "conflict", Namespace: childName}, existing)).To(Succeed())
+			Expect(existing.GetLabels()).NotTo(HaveKey(multitenancy.PropagatedFromLabel))
+		})
+	})
+})
@@ -0,0 +1,192 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multitenancy
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/events/v1"
+	"github.com/edgenet-project/edgenet/internal/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/notification/v1"
+	"github.com/edgenet-project/edgenet/internal/utils"
+)
+
+// RoleRequestReconciler reconciles a RoleRequest object
+type RoleRequestReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	recorder *events.Recorder
+
+	// Cluster-wide settings of the multitenancy manager.
+	Config multitenancy.Config
+
+	// The duration after which a pending role request expires. If zero, the requests never expire.
+	Expiry time.Duration
+
+	// Notifies the subject and the admins of the tenant of the approval, nil disables the notifications.
+	Notifier notification.Notifier
+}
+
+//+kubebuilder:rbac:groups=multitenancy.edge-net.io,resources=rolerequests,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=multitenancy.edge-net.io,resources=rolerequests/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=multitenancy.edge-net.io,resources=rolerequests/finalizers,verbs=update
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+// The role request is approved or denied by the tenant admins by setting the decision in the status, the
+// RoleRequest webhook checks the approvers.
+// Approved requests get a role binding in every namespace of the tenant, pending requests expire
+// after the configured duration.
+//
+// For more details, check Reconcile and its Result here:
+// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.17.0/pkg/reconcile
+func (r *RoleRequestReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	l := log.FromContext(ctx)
+	roleRequest := multitenancyv1.RoleRequest{}
+	isMarkedForDeletion, reconcileResult, err := utils.GetResourceWithFinalizer(ctx, r.Client, &roleRequest, req.NamespacedName)
+
+	if !utils.IsObjectInitialized(&roleRequest) {
+		return reconcileResult, err
+	}
+
+	multiTenancyManager, err := multitenancy.NewMultiTenancyManager(ctx, r.Client, r.Config)
+
+	if err != nil {
+		l.Error(err, "cannot create multitenancy manager")
+		return ctrl.Result{}, err
+	}
+
+	if isMarkedForDeletion {
+		// Remove the role bindings and allow role request object for deletion
+		if err := multiTenancyManager.RoleRequestCleanup(ctx, &roleRequest); err != nil {
+			r.recorder.Error(ctx, &roleRequest, events.CleanupFailed, err, "RoleRequest cleanup failed")
+			return ctrl.Result{Requeue: true}, err
+		}
+
+		return utils.AllowObjectDeletion(ctx, r.Client, &roleRequest)
+	}
+
+	status := roleRequest.Status.DeepCopy()
+	result := ctrl.Result{}
+
+	if r.Expiry > 0 && status.Expiry == nil {
+		expiry := metav1.NewTime(roleRequest.GetCreationTimestamp().Add(r.Expiry))
+		status.Expiry = &expiry
+	}
+
+	switch {
+	case status.State == multitenancyv1.RoleRequestStateExpired:
+		// An expired request cannot be approved anymore, a new request should be created.
+		status.Message = "The role request expired before a decision was made"
+	case status.Decision == multitenancyv1.RoleRequestStateApproved:
+		if err := multiTenancyManager.CreateRoleRequestRoleBindings(ctx, &roleRequest); err != nil {
+			r.recorder.Error(ctx, &roleRequest, events.RoleBindingFailed, err, "RoleRequest role binding failed")
+			return ctrl.Result{Requeue: true}, err
+		}
+		status.State = multitenancyv1.RoleRequestStateApproved
+		status.Message = "The role request is approved"
+	case status.Decision == multitenancyv1.RoleRequestStateDenied:
+		// The request might be approved before, revoke the permissions.
+		if err := multiTenancyManager.RoleRequestCleanup(ctx, &roleRequest); err != nil {
+			r.recorder.Error(ctx, &roleRequest, events.CleanupFailed, err, "RoleRequest cleanup failed")
+			return ctrl.Result{Requeue: true}, err
+		}
+		status.State = multitenancyv1.RoleRequestStateDenied
+		status.Message = "The role request is denied"
+	case status.Expiry != nil && !time.Now().Before(status.Expiry.Time):
+		status.State = multitenancyv1.RoleRequestStateExpired
+		status.Message = "The role request expired before a decision was made"
+	default:
+		status.State = multitenancyv1.RoleRequestStatePending
+		status.Message = "The role request is waiting for the approval of the tenant admins"
+		// Come back when the request expires.
+		if status.Expiry != nil {
+			result.RequeueAfter = time.Until(status.Expiry.Time)
+		}
+	}
+
+	if !reflect.DeepEqual(roleRequest.Status, *status) {
+		isApproved := roleRequest.Status.State != multitenancyv1.RoleRequestStateApproved && status.State == multitenancyv1.RoleRequestStateApproved
+
+		roleRequest.Status = *status
+		if err := r.Status().Update(ctx, &roleRequest); err != nil {
+			return ctrl.Result{Requeue: true}, err
+		}
+		r.recorder.Normal(ctx, &roleRequest, events.RoleRequestUpdated, status.Message)
+
+		if isApproved {
+			r.notifyApproval(ctx, &roleRequest)
+		}
+	}
+
+	return result, nil
+}
+
+// Notifies the subject of the role request and the admins of its tenant of the approval. The notifications are
+// best effort, a failure is only recorded.
+func (r *RoleRequestReconciler) notifyApproval(ctx context.Context, roleRequest *multitenancyv1.RoleRequest) {
+	if r.Notifier == nil {
+		return
+	}
+
+	// Role requests are created in the core namespace, which has the name of the tenant.
+	tenant := &multitenancyv1.Tenant{}
+	if err := r.Get(ctx, types.NamespacedName{Name: roleRequest.GetNamespace()}, tenant); err != nil {
+		r.recorder.Error(ctx, roleRequest, events.NotificationFailed, err, "RoleRequest notification failed")
+		return
+	}
+
+	var recipients []string
+	if roleRequest.Spec.Kind == "" || roleRequest.Spec.Kind == rbacv1.UserKind {
+		recipients = append(recipients, roleRequest.Spec.Subject)
+	}
+
+	err := r.Notifier.Notify(ctx, notification.Notification{
+		Event:      notification.EventRoleRequestApproved,
+		Tenant:     tenant,
+		Recipients: recipients,
+		Data: map[string]string{
+			"name":    roleRequest.GetName(),
+			"subject": roleRequest.Spec.Subject,
+			"role":    string(roleRequest.Spec.Role),
+		},
+	})
+	if err != nil {
+		r.recorder.Error(ctx, roleRequest, events.NotificationFailed, err, "RoleRequest notification failed")
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *RoleRequestReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Setup the event recorder
+	r.recorder = events.NewRecorder(utils.GetEventRecorder(mgr))
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&multitenancyv1.RoleRequest{}).
+		Complete(r)
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multitenancy
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/multitenancy/v1"
+)
+
+var _ = Describe("RoleRequest Controller", func() {
+	Context("When reconciling a resource", func() {
+		const tenantName = "test-rolerequest"
+		const resourceName = "test-resource"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: tenantName,
+		}
+
+		BeforeEach(func() {
+			By("creating the tenant and its core namespace")
+			tenant := &multitenancyv1.Tenant{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: tenantName,
+				},
+				Spec: multitenancyv1.TenantSpec{
+					FullName:       "Test Tenant",
+					Admin:          "testadmin",
+					URL:            "https://example.com",
+					InitialRequest: map[corev1.ResourceName]resource.Quantity{},
+				},
+			}
+			if err := k8sClient.Create(ctx, tenant); err != nil && !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			namespace := &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: tenantName,
+					Labels: map[string]string{
+						"edge-net.io/tenant": tenantName,
+						"edge-net.io/kind":   "core",
+					},
+				},
+			}
+			if err := k8sClient.Create(ctx, namespace); err != nil && !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			By("creating the custom resource for the Kind RoleRequest")
+			roleRequest := &multitenancyv1.RoleRequest{}
+			if err := k8sClient.Get(ctx, typeNamespacedName, roleRequest); err != nil && errors.IsNotFound(err) {
+				roleRequest = &multitenancyv1.RoleRequest{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      resourceName,
+						Namespace: tenantName,
+					},
+					Spec: multitenancyv1.RoleRequestSpec{
+						Subject: "student",
+						Kind:    "User",
+						Role:    multitenancyv1.TenantRoleMember,
+					},
+				}
+				Expect(k8sClient.Create(ctx, roleRequest)).To(Succeed())
+			}
+		})
+
+		It("should create the role binding once approved", func() {
+			controllerReconciler := &RoleRequestReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				Expiry: time.Hour,
+			}
+
+			By("reconciling the pending request")
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			roleRequest := &multitenancyv1.RoleRequest{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, roleRequest)).To(Succeed())
+			Expect(roleRequest.Status.State).To(Equal(multitenancyv1.RoleRequestStatePending))
+			Expect(roleRequest.Status.Expiry).NotTo(BeNil())
+
+			By("approving the request")
+			roleRequest.Status.Decision = multitenancyv1.RoleRequestStateApproved
+			Expect(k8sClient.Status().Update(ctx, roleRequest)).To(Succeed())
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			roleBinding := &rbacv1.RoleBinding{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{
+				Name:      multitenancy.ResolveRoleRequestRoleBindingName(roleRequest),
+				Namespace: tenantName,
+			}, roleBinding)).To(Succeed())
+			Expect(roleBinding.RoleRef.Name).To(Equal(multitenancyv1.TenantMemberRoleName))
+			Expect(roleBinding.Subjects[0].Name).To(Equal("student"))
+		})
+	})
+})
@@ -0,0 +1,161 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multitenancy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/events/v1"
+	"github.com/edgenet-project/edgenet/internal/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/utils"
+)
+
+// The interval to retry the reservation of a pending slice.
+const sliceRetryInterval = time.Minute
+
+// SliceReconciler reconciles a Slice object
+type SliceReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	recorder *events.Recorder
+
+	// Cluster-wide settings of the multitenancy manager.
+	Config multitenancy.Config
+}
+
+//+kubebuilder:rbac:groups=multitenancy.edge-net.io,resources=slices,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=multitenancy.edge-net.io,resources=slices/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=multitenancy.edge-net.io,resources=slices/finalizers,verbs=update
+//+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch;update;patch
+//+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;update;patch
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+// The nodes of the slice are reserved until the slice expires, then they are released. A slice that cannot
+// find enough free nodes stays pending and the reservation is retried, a slice exceeding the limits fails.
+//
+// For more details, check Reconcile and its Result here:
+// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.17.0/pkg/reconcile
+func (r *SliceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	l := log.FromContext(ctx)
+	slice := multitenancyv1.Slice{}
+	isMarkedForDeletion, reconcileResult, err := utils.GetResourceWithFinalizer(ctx, r.Client, &slice, req.NamespacedName)
+
+	if !utils.IsObjectInitialized(&slice) {
+		return reconcileResult, err
+	}
+
+	multiTenancyManager, err := multitenancy.NewMultiTenancyManager(ctx, r.Client, r.Config)
+
+	if err != nil {
+		l.Error(err, "cannot create multitenancy manager")
+		return ctrl.Result{}, err
+	}
+
+	if isMarkedForDeletion {
+		// Release the nodes and allow slice object for deletion
+		if err := multiTenancyManager.ReleaseSliceNodes(ctx, &slice); err != nil {
+			r.recorder.Error(ctx, &slice, events.SliceReleaseFailed, err, "Slice release failed")
+			return ctrl.Result{Requeue: true}, err
+		}
+
+		return utils.AllowObjectDeletion(ctx, r.Client, &slice)
+	}
+
+	status := slice.Status.DeepCopy()
+	result := ctrl.Result{}
+
+	switch {
+	case status.State == multitenancyv1.SliceStateExpired || status.State == multitenancyv1.SliceStateFailed:
+		// An expired or failed slice is not reserved again, a new claim should be created.
+	case status.Expiry != nil && !time.Now().Before(status.Expiry.Time):
+		if err := multiTenancyManager.ReleaseSliceNodes(ctx, &slice); err != nil {
+			r.recorder.Error(ctx, &slice, events.SliceReleaseFailed, err, "Slice release failed")
+			return ctrl.Result{Requeue: true}, err
+		}
+		status.State = multitenancyv1.SliceStateExpired
+		status.Message = "The reservation expired and the nodes are released"
+		status.Nodes = nil
+	default:
+		nodes, err := multiTenancyManager.ReserveSliceNodes(ctx, &slice)
+
+		if errors.Is(err, multitenancy.ErrSliceLimitExceeded) {
+			// The nodes might be reserved before the limits are lowered.
+			if err := multiTenancyManager.ReleaseSliceNodes(ctx, &slice); err != nil {
+				r.recorder.Error(ctx, &slice, events.SliceReleaseFailed, err, "Slice release failed")
+				return ctrl.Result{Requeue: true}, err
+			}
+			status.State = multitenancyv1.SliceStateFailed
+			status.Message = err.Error()
+			status.Nodes = nil
+			break
+		}
+
+		if err != nil {
+			if !errors.Is(err, multitenancy.ErrInsufficientNodes) {
+				r.recorder.Error(ctx, &slice, events.SliceReservationFailed, err, "Slice reservation failed")
+				return ctrl.Result{Requeue: true}, err
+			}
+			status.State = multitenancyv1.SliceStatePending
+			status.Message = err.Error()
+			result.RequeueAfter = sliceRetryInterval
+			break
+		}
+
+		// The reservation time starts when the slice is bound for the first time.
+		if status.Expiry == nil {
+			expiry := metav1.NewTime(time.Now().Add(slice.Spec.Duration.Duration))
+			status.Expiry = &expiry
+		}
+		status.State = multitenancyv1.SliceStateBound
+		status.Message = fmt.Sprintf("%d nodes are reserved", len(nodes))
+		status.Nodes = nodes
+		// Come back when the slice expires.
+		result.RequeueAfter = time.Until(status.Expiry.Time)
+	}
+
+	if !reflect.DeepEqual(slice.Status, *status) {
+		slice.Status = *status
+		if err := r.Status().Update(ctx, &slice); err != nil {
+			return ctrl.Result{Requeue: true}, err
+		}
+		r.recorder.Normal(ctx, &slice, events.SliceUpdated, status.Message)
+	}
+
+	return result, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *SliceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Setup the event recorder
+	r.recorder = events.NewRecorder(utils.GetEventRecorder(mgr))
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&multitenancyv1.Slice{}).
+		Complete(r)
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multitenancy
+
+import (
+	"context"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/events/v1"
+	"github.com/edgenet-project/edgenet/internal/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/utils"
+)
+
+// SliceClaimReconciler reconciles a SliceClaim object
+type SliceClaimReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	recorder *events.Recorder
+
+	// Cluster-wide settings of the multitenancy manager.
+	Config multitenancy.Config
+}
+
+//+kubebuilder:rbac:groups=multitenancy.edge-net.io,resources=sliceclaims,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=multitenancy.edge-net.io,resources=sliceclaims/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=multitenancy.edge-net.io,resources=sliceclaims/finalizers,verbs=update
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+// A slice is created for each claim and the status of the slice is reflected on the claim. Deleting the claim
+// deletes the slice, which releases the nodes.
+//
+// For more details, check Reconcile and its Result here:
+// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.17.0/pkg/reconcile
+func (r *SliceClaimReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	l := log.FromContext(ctx)
+	claim := multitenancyv1.SliceClaim{}
+	isMarkedForDeletion, reconcileResult, err := utils.GetResourceWithFinalizer(ctx, r.Client, &claim, req.NamespacedName)
+
+	if !utils.IsObjectInitialized(&claim) {
+		return reconcileResult, err
+	}
+
+	multiTenancyManager, err := multitenancy.NewMultiTenancyManager(ctx, r.Client, r.Config)
+
+	if err != nil {
+		l.Error(err, "cannot create multitenancy manager")
+		return ctrl.Result{}, err
+	}
+
+	if isMarkedForDeletion {
+		// Delete the slice and allow claim object for deletion
+		if err := multiTenancyManager.SliceClaimCleanup(ctx, &claim); err != nil {
+			r.recorder.Error(ctx, &claim, events.CleanupFailed, err, "SliceClaim cleanup failed")
+			return ctrl.Result{Requeue: true}, err
+		}
+
+		return utils.AllowObjectDeletion(ctx, r.Client, &claim)
+	}
+
+	slice, err := multiTenancyManager.CreateClaimSlice(ctx, &claim)
+
+	if err != nil {
+		r.recorder.Error(ctx, &claim, events.SliceCreationFailed, err, "Slice creation failed")
+		return ctrl.Result{Requeue: true}, err
+	}
+
+	status := multitenancyv1.SliceClaimStatus{
+		State:   slice.Status.State,
+		Message: slice.Status.Message,
+		Slice:   slice.GetName(),
+		Nodes:   slice.Status.Nodes,
+		Expiry:  slice.Status.Expiry,
+	}
+
+	if status.State == "" {
+		status.State = multitenancyv1.SliceStatePending
+		status.Message = "Waiting for the nodes to be reserved"
+	}
+
+	if !reflect.DeepEqual(claim.Status, status) {
+		claim.Status = status
+		if err := r.Status().Update(ctx, &claim); err != nil {
+			return ctrl.Result{Requeue: true}, err
+		}
+		r.recorder.Normal(ctx, &claim, events.SliceUpdated, status.Message)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// Maps a slice to its claim so the status changes of the slice are reflected on the claim.
+func (r *SliceClaimReconciler) mapSliceToClaim(ctx context.Context, obj client.Object) []reconcile.Request {
+	slice, ok := obj.(*multitenancyv1.Slice)
+	if !ok {
+		return nil
+	}
+
+	return []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Name: slice.Spec.ClaimRef.Name, Namespace: slice.Spec.ClaimRef.Namespace}},
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *SliceClaimReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Setup the event recorder
+	r.recorder = events.NewRecorder(utils.GetEventRecorder(mgr))
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&multitenancyv1.SliceClaim{}).
+		Watches(&multitenancyv1.Slice{}, handler.EnqueueRequestsFromMapFunc(r.mapSliceToClaim)).
+		Complete(r)
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multitenancy
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/multitenancy/v1"
+)
+
+var _ = Describe("SliceClaim Controller", func() {
+	Context("When reconciling a resource", func() {
+		const namespaceName = "test-sliceclaim"
+		const resourceName = "test-resource"
+		const nodeName = "test-slice-node"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: namespaceName,
+		}
+
+		BeforeEach(func() {
+			By("creating the namespace and a ready node")
+			namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespaceName}}
+			if err := k8sClient.Create(ctx, namespace); err != nil && !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeName, Labels: map[string]string{"edge-net.io/country-iso": "FR"}}}
+			if err := k8sClient.Create(ctx, node); err != nil && !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+			node.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}
+			node.Status.Allocatable = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")}
+			Expect(k8sClient.Status().Update(ctx, node)).To(Succeed())
+
+			By("creating the custom resource for the Kind SliceClaim")
+			claim := &multitenancyv1.SliceClaim{}
+			if err := k8sClient.Get(ctx, typeNamespacedName, claim); err != nil && errors.IsNotFound(err) {
+				claim = &multitenancyv1.SliceClaim{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      resourceName,
+						Namespace: namespaceName,
+					},
+					Spec: multitenancyv1.SliceClaimSpec{
+						SliceProfile: multitenancyv1.SliceProfile{
+							NodeSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"edge-net.io/country-iso": "FR"}},
+							Quantity:     1,
+							Resources:    corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+							Duration:     metav1.Duration{Duration: time.Hour},
+						},
+					},
+				}
+				Expect(k8sClient.Create(ctx, claim)).To(Succeed())
+			}
+		})
+
+		It("should reserve the nodes and release them when the claim is deleted", func() {
+			claimReconciler := &SliceClaimReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			sliceReconciler := &SliceReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			By("creating the slice of the claim")
+			_, err := claimReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			claim := &multitenancyv1.SliceClaim{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, claim)).To(Succeed())
+			sliceName := types.NamespacedName{Name: multitenancy.ResolveSliceName(claim)}
+
+			By("reserving the nodes")
+			_, err = sliceReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: sliceName})
+			Expect(err).NotTo(HaveOccurred())
+
+			slice := &multitenancyv1.Slice{}
+			Expect(k8sClient.Get(ctx, sliceName, slice)).To(Succeed())
+			Expect(slice.Status.State).To(Equal(multitenancyv1.SliceStateBound))
+			Expect(slice.Status.Nodes).To(Equal([]string{nodeName}))
+			Expect(slice.Status.Expiry).NotTo(BeNil())
+
+			node := &corev1.Node{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: nodeName}, node)).To(Succeed())
+			Expect(node.GetLabels()).To(HaveKeyWithValue(multitenancyv1.SliceLabel, slice.GetName()))
+			Expect(node.Spec.Taints).To(ContainElement(HaveField("Key", multitenancyv1.SliceLabel)))
+
+			namespace := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			Expect(namespace.GetAnnotations()).To(HaveKey(multitenancy.DefaultTolerationsAnnotation))
+
+			By("reflecting the slice on the claim")
+			_, err = claimReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(k8sClient.Get(ctx, typeNamespacedName, claim)).To(Succeed())
+			Expect(claim.Status.State).To(Equal(multitenancyv1.SliceStateBound))
+
+			By("deleting the claim")
+			Expect(k8sClient.Delete(ctx, claim)).To(Succeed())
+			_, err = claimReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = sliceReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: sliceName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: nodeName}, node)).To(Succeed())
+			Expect(node.GetLabels()).NotTo(HaveKey(multitenancyv1.SliceLabel))
+			Expect(node.Spec.Taints).To(BeEmpty())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace)).To(Succeed())
+			Expect(namespace.GetAnnotations()).NotTo(HaveKey(multitenancy.DefaultTolerationsAnnotation))
+		})
+	})
+})
@@ -18,23 +18,36 @@ package multitenancy
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/events/v1"
 	"github.com/edgenet-project/edgenet/internal/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/operator/v1"
 	"github.com/edgenet-project/edgenet/internal/utils"
 )
 
+// The interval to check again while the subtree of a SubNamespace is being deleted.
+const cleanupRequeueInterval = 5 * time.Second
+
 // SubNamespaceReconciler reconciles a SubNamespace object
 type SubNamespaceReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
-	recorder record.EventRecorder
+	recorder *events.Recorder
+
+	// Cluster-wide settings of the multitenancy manager.
+	Config multitenancy.Config
+
+	// The settings of the EdgeNetConfig, they override the namespace defaults of the configuration above. Nil
+	// uses the configuration as it is.
+	Settings *operator.Store
 }
 
 //+kubebuilder:rbac:groups=multitenancy.edge-net.io,resources=subnamespaces,verbs=get;list;watch;create;update;patch;delete
@@ -59,7 +72,12 @@ func (r *SubNamespaceReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return reconcileResult, err
 	}
 
-	multiTenancyManager, err := multitenancy.NewMultiTenancyManager(ctx, r.Client)
+	config := r.Config
+	if r.Settings != nil {
+		config = r.Settings.Get().MultiTenancyConfig(config)
+	}
+
+	multiTenancyManager, err := multitenancy.NewMultiTenancyManager(ctx, r.Client, config)
 
 	if err != nil {
 		l.Error(err, "cannot create multitenancy manager")
@@ -69,7 +87,14 @@ func (r *SubNamespaceReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	if isMarkedForDeletion {
 		// Do a cleanup and allow tenant object for deletion
 		if err := multiTenancyManager.SubNamespaceCleanup(ctx, &sns); err != nil {
-			utils.RecordEventError(&l, r.recorder, &sns, "SubNamespace cleanup failed")
+			// The children are still being deleted, keep the finalizer and check again later.
+			if errors.Is(err, multitenancy.ErrCleanupPending) {
+				if err := r.updateStatus(ctx, &sns, multitenancyv1.SubNamespaceStateTerminating, err.Error()); err != nil {
+					return ctrl.Result{Requeue: true}, err
+				}
+				return ctrl.Result{RequeueAfter: cleanupRequeueInterval}, nil
+			}
+			r.recorder.Error(ctx, &sns, events.CleanupFailed, err, "SubNamespace cleanup failed")
 			return ctrl.Result{Requeue: true}, err
 		}
 
@@ -77,19 +102,38 @@ func (r *SubNamespaceReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	} else {
 		// Run the setup, this might be divided into create_subnamespace + create_rolebinding
 		if err := multiTenancyManager.SetupSubNamespace(ctx, &sns); err != nil {
-			utils.RecordEventError(&l, r.recorder, &sns, "SubNamespace setup failed")
+			r.recorder.Error(ctx, &sns, events.SubNamespaceSetupFailed, err, "SubNamespace setup failed")
+			if err := r.updateStatus(ctx, &sns, multitenancyv1.SubNamespaceStateFailed, err.Error()); err != nil {
+				l.Error(err, "cannot update the status")
+			}
+			return ctrl.Result{Requeue: true}, err
+		}
+
+		if err := r.updateStatus(ctx, &sns, multitenancyv1.SubNamespaceStateEstablished, "SubNamespace is established"); err != nil {
 			return ctrl.Result{Requeue: true}, err
 		}
 	}
 
-	utils.RecordEventInfo(&l, r.recorder, &sns, "SubNamespace reconciliation successfull")
+	r.recorder.Normal(ctx, &sns, events.SubNamespaceReconciled, "SubNamespace reconciliation successfull")
 	return ctrl.Result{}, nil
 }
 
+// Updates the status of the SubNamespace if it is changed.
+func (r *SubNamespaceReconciler) updateStatus(ctx context.Context, sns *multitenancyv1.SubNamespace, state, message string) error {
+	if sns.Status.State == state && sns.Status.Message == message {
+		return nil
+	}
+
+	sns.Status.State = state
+	sns.Status.Message = message
+
+	return r.Status().Update(ctx, sns)
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *SubNamespaceReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	// Setup the event recorder
-	r.recorder = utils.GetEventRecorder(mgr)
+	r.recorder = events.NewRecorder(utils.GetEventRecorder(mgr))
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&multitenancyv1.SubNamespace{}).
@@ -17,66 +17,105 @@ limitations under the License.
 package multitenancy
 
 import (
-	// "context"
+	"context"
 
 	. "github.com/onsi/ginkgo/v2"
-	// . "github.com/onsi/gomega"
-	// "k8s.io/apimachinery/pkg/types"
-	// "k8s.io/apimachinery/pkg/types"
-	// "sigs.k8s.io/controller-runtime/pkg/reconcile"
-	// metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	// multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/utils"
 )
 
 var _ = Describe("SubNamespace Controller", func() {
-	Context("When reconciling a resource", func() {
-		// const resourceName = "test-resource"
-
-		// ctx := context.Background()
-
-		// typeNamespacedName := types.NamespacedName{
-		// 	Name:      resourceName,
-		// 	Namespace: "default", // TODO(user):Modify as needed
-		// }
-		// subnamespace := &multitenancyv1.SubNamespace{}
-
-		// BeforeEach(func() {
-		// 	By("creating the custom resource for the Kind SubNamespace")
-		// 	err := k8sClient.Get(ctx, typeNamespacedName, subnamespace)
-		// 	if err != nil && errors.IsNotFound(err) {
-		// 		resource := &multitenancyv1.SubNamespace{
-		// 			ObjectMeta: metav1.ObjectMeta{
-		// 				Name:      resourceName,
-		// 				Namespace: "default",
-		// 			},
-		// 			// TODO(user): Specify other spec details if needed.
-		// 		}
-		// 		Expect(k8sClient.Create(ctx, resource)).To(Succeed())
-		// 	}
-		// })
-
-		// AfterEach(func() {
-		// 	// TODO(user): Cleanup logic after each test, like removing the resource instance.
-		// 	resource := &multitenancyv1.SubNamespace{}
-		// 	err := k8sClient.Get(ctx, typeNamespacedName, resource)
-		// 	Expect(err).NotTo(HaveOccurred())
-
-		// 	By("Cleanup the specific resource instance SubNamespace")
-		// 	Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
-		// })
-		// It("should successfully reconcile the resource", func() {
-		// 	By("Reconciling the created resource")
-		// 	controllerReconciler := &SubNamespaceReconciler{
-		// 		Client: k8sClient,
-		// 		Scheme: k8sClient.Scheme(),
-		// 	}
-
-		// 	_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
-		// 		NamespacedName: typeNamespacedName,
-		// 	})
-		// 	Expect(err).NotTo(HaveOccurred())
-		// 	// TODO(user): Add more specific assertions depending on your controller's reconciliation logic.
-		// 	// Example: If you expect a certain status condition after reconciliation, verify it here.
-		// })
+	Context("When a SubNamespace with children is deleted", func() {
+		const tenantName = "test-subnamespace"
+
+		ctx := context.Background()
+
+		BeforeEach(func() {
+			By("creating the tenant and its core namespace")
+			tenant := &multitenancyv1.Tenant{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: tenantName,
+				},
+				Spec: multitenancyv1.TenantSpec{
+					FullName:       "Test Tenant",
+					Admin:          "testadmin",
+					URL:            "https://example.com",
+					InitialRequest: map[corev1.ResourceName]resource.Quantity{},
+				},
+			}
+			if err := k8sClient.Create(ctx, tenant); err != nil && !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+
+			namespace := &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: tenantName,
+					Labels: map[string]string{
+						"edge-net.io/tenant": tenantName,
+						"edge-net.io/kind":   "core",
+					},
+				},
+			}
+			if err := k8sClient.Create(ctx, namespace); err != nil && !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+
+		It("should delete the children before the namespace", func() {
+			controllerReconciler := &SubNamespaceReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			By("creating the parent SubNamespace")
+			parent := &multitenancyv1.SubNamespace{
+				ObjectMeta: metav1.ObjectMeta{Name: "parent", Namespace: tenantName},
+			}
+			Expect(k8sClient.Create(ctx, parent)).To(Succeed())
+			parentKey := types.NamespacedName{Name: parent.GetName(), Namespace: tenantName}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: parentKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, parentKey, parent)).To(Succeed())
+			Expect(parent.Status.State).To(Equal(multitenancyv1.SubNamespaceStateEstablished))
+
+			By("creating the child SubNamespace inside the namespace of the parent")
+			childNamespace := utils.ResolveSubNamespaceName(parent)
+			child := &multitenancyv1.SubNamespace{
+				ObjectMeta: metav1.ObjectMeta{Name: "child", Namespace: childNamespace},
+			}
+			Expect(k8sClient.Create(ctx, child)).To(Succeed())
+			childKey := types.NamespacedName{Name: child.GetName(), Namespace: childNamespace}
+
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: childKey})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("deleting the parent SubNamespace")
+			Expect(k8sClient.Delete(ctx, parent)).To(Succeed())
+
+			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: parentKey})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+
+			Expect(k8sClient.Get(ctx, childKey, child)).To(Succeed())
+			Expect(child.GetDeletionTimestamp().IsZero()).To(BeFalse())
+
+			Expect(k8sClient.Get(ctx, parentKey, parent)).To(Succeed())
+			Expect(parent.Status.State).To(Equal(multitenancyv1.SubNamespaceStateTerminating))
+
+			// The namespace of the parent is kept until the child is removed.
+			namespace := &corev1.Namespace{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: childNamespace}, namespace)).To(Succeed())
+			Expect(namespace.GetDeletionTimestamp().IsZero()).To(BeTrue())
+		})
 	})
 })
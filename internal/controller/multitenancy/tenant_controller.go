@@ -18,15 +18,28 @@ package multitenancy
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/client-go/tools/record"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/events/v1"
 	"github.com/edgenet-project/edgenet/internal/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/notification/v1"
+	"github.com/edgenet-project/edgenet/internal/operator/v1"
 	"github.com/edgenet-project/edgenet/internal/utils"
 )
 
@@ -34,15 +47,46 @@ import (
 type TenantReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
-	recorder record.EventRecorder
+	recorder *events.Recorder
+
+	// Cluster-wide settings of the multitenancy manager.
+	Config multitenancy.Config
+
+	// How the expiring and the inactive tenants are warned, suspended and deleted.
+	Reclamation multitenancy.ReclamationPolicy
+
+	// Sends the lifecycle notifications to the admins of the tenants, nil disables the notifications.
+	Notifier notification.Notifier
+
+	// The settings of the EdgeNetConfig, they override the namespace defaults, the network profile and the
+	// reclamation policy above. Nil uses the fields as they are.
+	Settings *operator.Store
+}
+
+// Returns the configuration of the manager and the reclamation policy completed by the runtime settings.
+func (r *TenantReconciler) settings() (multitenancy.Config, multitenancy.ReclamationPolicy) {
+	if r.Settings == nil {
+		return r.Config, r.Reclamation
+	}
+	settings := r.Settings.Get()
+	return settings.MultiTenancyConfig(r.Config), settings.Reclamation
 }
 
+// The activity of the tenants with an inactivity policy is observed at least this often. The last activity in the
+// status is only updated when it moves by this much, to avoid updating the status on every reconciliation.
+const reclamationActivityInterval = time.Hour
+
 // These are required to have the permissions.
 //+kubebuilder:rbac:groups="multitenancy.edge-net.io",resources=tenants,verbs=get;list;watch;create;update;patch;delete
-//+kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=rolebindings,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=rolebindings,verbs=get;list;watch;create;update;patch;delete;deletecollection
+//+kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=clusterroles,verbs=bind
 //+kubebuilder:rbac:groups="networking.k8s.io",resources=networkpolicies;clusternetworkpolicies,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="crd.antrea.io",resources=clusternetworkpolicies,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=resourcequotas,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="",resources=limitranges,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups="apps",resources=deployments;statefulsets,verbs=get;list;watch;update;patch
 //+kubebuilder:rbac:groups="multitenancy.edge-net.io",resources=tenants/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups="multitenancy.edge-net.io",resources=tenants/finalizers,verbs=update
 
@@ -55,7 +99,7 @@ type TenantReconciler struct {
 //
 // For more details, check Reconcile and its Result here:
 // - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.16.3/pkg/reconcile
-func (r *TenantReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *TenantReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
 	l := log.FromContext(ctx)
 	tenant := multitenancyv1.Tenant{}
 	isMarkedForDeletion, reconcileResult, err := utils.GetResourceWithFinalizer(ctx, r.Client, &tenant, req.NamespacedName)
@@ -64,52 +108,365 @@ func (r *TenantReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		return reconcileResult, err
 	}
 
-	multiTenancyManager, err := multitenancy.NewMultiTenancyManager(ctx, r.Client)
+	config, _ := r.settings()
+	multiTenancyManager, err := multitenancy.NewMultiTenancyManager(ctx, r.Client, config)
 
 	if err != nil {
 		l.Error(err, "cannot create multitenancy manager")
 		return ctrl.Result{}, err
 	}
 
+	// Mark the tenant as failed when the reconciliation fails, the operators are notified of the first failure.
+	// The transient errors are retried without marking the tenant.
+	defer func() {
+		if err != nil {
+			r.recordFailure(ctx, &tenant, err)
+		}
+	}()
+
 	if isMarkedForDeletion {
 		// Do a cleanup and allow tenant object for deletion
 		if err := multiTenancyManager.TenantCleanup(ctx, &tenant); err != nil {
-			utils.RecordEventError(&l, r.recorder, &tenant, "Tenant cleanup failed")
+			// The namespaces of the tenant are still being deleted, keep the finalizer and check again later.
+			if errors.Is(err, multitenancy.ErrCleanupPending) {
+				if tenant.Status.State != multitenancyv1.TenantStateTerminating || tenant.Status.Message != err.Error() {
+					tenant.Status.State = multitenancyv1.TenantStateTerminating
+					tenant.Status.Message = err.Error()
+					if err := r.Status().Update(ctx, &tenant); err != nil {
+						return ctrl.Result{Requeue: true}, err
+					}
+				}
+				return ctrl.Result{RequeueAfter: cleanupRequeueInterval}, nil
+			}
+			r.recorder.Error(ctx, &tenant, events.CleanupFailed, err, "Tenant cleanup failed")
 			return ctrl.Result{Requeue: true}, err
 		}
 
 		return utils.AllowObjectDeletion(ctx, r.Client, &tenant)
 	} else {
+		status := tenant.Status.DeepCopy()
+
+		// Suspend or delete the tenant if it is expired or inactive for too long.
+		requeueAfter, deleted, err := r.reconcileReclamation(ctx, multiTenancyManager, &tenant)
+		if err != nil {
+			r.recorder.Error(ctx, &tenant, events.ReclamationFailed, err, "Tenant reclamation failed")
+			return ctrl.Result{Requeue: true}, err
+		}
+		result.RequeueAfter = requeueAfter
+
+		if deleted {
+			return ctrl.Result{}, nil
+		}
+
+		// Look at the core namespace and its quota before they are created or updated to notify the changes.
+		coreNamespaceName := utils.ResolveCoreNamespaceName(tenant.GetName())
+		err = r.Get(ctx, types.NamespacedName{Name: coreNamespaceName}, &corev1.Namespace{})
+		if client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{Requeue: true}, err
+		}
+		isNewTenant := err != nil
+
+		previousQuota := &corev1.ResourceQuota{}
+		err = r.Get(ctx, types.NamespacedName{Name: coreNamespaceName, Namespace: coreNamespaceName}, previousQuota)
+		if client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{Requeue: true}, err
+		}
+		isQuotaFound := err == nil
+		isQuotaChanged := isQuotaFound && !equality.Semantic.DeepEqual(previousQuota.Spec.Hard, tenant.Spec.InitialRequest)
+
 		// Create a core namespace for the tenant.
 		if err := multiTenancyManager.CreateCoreNamespaceLocal(ctx, &tenant); err != nil {
-			utils.RecordEventError(&l, r.recorder, &tenant, "Tenant Core Namespace creation failed")
+			r.recorder.Error(ctx, &tenant, events.NamespaceFailed, err, "Tenant core namespace creation failed")
 			return ctrl.Result{Requeue: true}, err
 		}
 
-		// Create the role binding in the core namespace of the tenant.
-		if err := multiTenancyManager.CreateTenantAdminRoleBinding(ctx, &tenant); err != nil {
-			utils.RecordEventError(&l, r.recorder, &tenant, "Tenant admin role binding failed")
-			return ctrl.Result{Requeue: true}, err
+		if isNewTenant {
+			r.recorder.Normal(ctx, &tenant, events.NamespaceCreated, fmt.Sprintf("Tenant core namespace %s created", coreNamespaceName))
+			r.notify(ctx, &tenant, notification.EventTenantCreated, nil)
+		}
+
+		if isQuotaChanged {
+			r.recorder.Normal(ctx, &tenant, events.QuotaUpdated, fmt.Sprintf("Tenant quota updated from %s to %s",
+				notification.FormatResourceList(previousQuota.Spec.Hard), notification.FormatResourceList(tenant.Spec.InitialRequest)))
+			r.notify(ctx, &tenant, notification.EventTenantQuotaChanged, map[string]string{
+				"previous": notification.FormatResourceList(previousQuota.Spec.Hard),
+				"quota":    notification.FormatResourceList(tenant.Spec.InitialRequest),
+			})
+		}
+
+		if isQuotaFound {
+			r.updateQuotaStatus(ctx, &tenant, status, previousQuota)
+		}
+
+		if tenant.Spec.Suspended {
+			// Freeze the tenant, the namespaces and the objects inside them are kept.
+			if err := multiTenancyManager.SuspendTenant(ctx, &tenant); err != nil {
+				r.recorder.Error(ctx, &tenant, events.SuspensionFailed, err, "Tenant suspension failed")
+				return ctrl.Result{Requeue: true}, err
+			}
+
+			if !meta.IsStatusConditionTrue(status.Conditions, multitenancyv1.TenantConditionSuspended) {
+				data := map[string]string{}
+				if reclamation := tenant.Status.Reclamation; reclamation != nil && reclamation.SuspendedAt != nil {
+					data["reason"] = strings.ToLower(reclamation.Reason)
+				}
+				r.recorder.Normal(ctx, &tenant, events.TenantSuspended, "Tenant suspended")
+				r.notify(ctx, &tenant, notification.EventTenantSuspended, data)
+			}
+		} else {
+			// Only a tenant suspended before has something to restore.
+			if meta.IsStatusConditionTrue(tenant.Status.Conditions, multitenancyv1.TenantConditionSuspended) {
+				if err := multiTenancyManager.ResumeTenant(ctx, &tenant); err != nil {
+					r.recorder.Error(ctx, &tenant, events.ResumptionFailed, err, "Tenant resumption failed")
+					return ctrl.Result{Requeue: true}, err
+				}
+				r.recorder.Normal(ctx, &tenant, events.TenantResumed, "Tenant resumed")
+			}
+
+			// Create the role bindings of the members in the core namespace and the SubNamespaces of the tenant.
+			if err := multiTenancyManager.CreateTenantRoleBindings(ctx, &tenant); err != nil {
+				r.recorder.Error(ctx, &tenant, events.RoleBindingFailed, err, "Tenant role bindings failed")
+				return ctrl.Result{Requeue: true}, err
+			}
 		}
 
 		// Create the network policy. This restricts pod communication. Don't need to clean after
 		// deletion of the tenant.
 		if err := multiTenancyManager.CreateTenantNetworkPolicy(ctx, &tenant); err != nil {
-			utils.RecordEventError(&l, r.recorder, &tenant, "Tenant antrea network policy failed")
+			r.recorder.Error(ctx, &tenant, events.NetworkPolicyFailed, err, "Tenant network policy failed")
 			return ctrl.Result{Requeue: true}, err
 		}
+
+		// Keep the default LimitRange and the Pod Security level of the namespaces in sync with the cluster and
+		// tenant settings.
+		if err := multiTenancyManager.ApplyTenantNamespaceDefaults(ctx, &tenant); err != nil {
+			r.recorder.Error(ctx, &tenant, events.DefaultsFailed, err, "Tenant namespace defaults failed")
+			return ctrl.Result{Requeue: true}, err
+		}
+
+		r.updateSuspensionStatus(&tenant)
+
+		if !equality.Semantic.DeepEqual(status, &tenant.Status) {
+			if err := r.Status().Update(ctx, &tenant); err != nil {
+				return ctrl.Result{Requeue: true}, err
+			}
+		}
+	}
+
+	r.recorder.Normal(ctx, &tenant, events.TenantReconciled, "Tenant reconciled")
+	return result, nil
+}
+
+// Evaluates the expiration date and the inactivity policy of the tenant. Warnings are sent as the deadline
+// approaches, the tenant is suspended at the deadline and deleted after the grace period. Returns when the tenant
+// should be reconciled again and whether it is deleted.
+func (r *TenantReconciler) reconcileReclamation(ctx context.Context, m multitenancy.MultiTenancyManager, tenant *multitenancyv1.Tenant) (time.Duration, bool, error) {
+	now := time.Now()
+
+	// The tenant is resumed by an administrator after an automatic suspension, the inactivity is counted again
+	// from now. An expired tenant is suspended again unless its expiration date is extended.
+	if reclamation := tenant.Status.Reclamation; reclamation != nil && reclamation.SuspendedAt != nil && !tenant.Spec.Suspended {
+		tenant.Status.Reclamation = nil
+		tenant.Status.LastActivity = &metav1.Time{Time: now.Truncate(time.Second)}
+	}
+
+	if tenant.Spec.ExpirationDate == nil && tenant.Spec.InactivityDays == 0 {
+		tenant.Status.Reclamation = nil
+		return 0, false, nil
+	}
+
+	if tenant.Spec.InactivityDays > 0 {
+		activity, err := m.GetTenantActivity(ctx, tenant)
+		if err != nil {
+			return 0, false, err
+		}
+
+		lastActivity := tenant.Status.LastActivity
+		if !activity.IsZero() && (lastActivity == nil || activity.Sub(lastActivity.Time) >= reclamationActivityInterval) {
+			tenant.Status.LastActivity = &metav1.Time{Time: activity.Truncate(time.Second)}
+		}
+	}
+
+	_, reclamation := r.settings()
+	decision := multitenancy.EvaluateReclamation(tenant, reclamation, now)
+	deadline := metav1.NewTime(decision.Deadline)
+
+	switch decision.Action {
+	case multitenancy.ReclamationActionDelete:
+		r.recorder.Warning(ctx, tenant, events.TenantReclaimed,
+			"The grace period of the suspended tenant is over, the tenant is deleted")
+		if err := client.IgnoreNotFound(r.Delete(ctx, tenant)); err != nil {
+			return 0, false, err
+		}
+		return 0, true, nil
+	case multitenancy.ReclamationActionSuspend:
+		if !tenant.Spec.Suspended {
+			// The update returns the status stored in the cluster, keep the changes made above.
+			status := tenant.Status.DeepCopy()
+			tenant.Spec.Suspended = true
+			if err := r.Update(ctx, tenant); err != nil {
+				return 0, false, err
+			}
+			tenant.Status = *status
+		}
+
+		suspendedAt := metav1.NewTime(now.Truncate(time.Second))
+		tenant.Status.Reclamation = &multitenancyv1.TenantReclamation{
+			Reason:      decision.Reason,
+			Deadline:    deadline,
+			SuspendedAt: &suspendedAt,
+		}
+		r.recorder.Warning(ctx, tenant, events.TenantSuspended,
+			fmt.Sprintf("The tenant is suspended since it is %s, set suspended to false in its spec to resume it", strings.ToLower(decision.Reason)))
+	case multitenancy.ReclamationActionWarn:
+		tenant.Status.Reclamation = &multitenancyv1.TenantReclamation{
+			Reason:         decision.Reason,
+			Deadline:       deadline,
+			WarnedLeadTime: &metav1.Duration{Duration: decision.LeadTime},
+		}
+		r.recorder.Warning(ctx, tenant, events.ReclamationWarning,
+			fmt.Sprintf("The tenant will be suspended at %s since it is %s", decision.Deadline.Format(time.RFC3339), strings.ToLower(decision.Reason)))
+		r.notify(ctx, tenant, notification.EventTenantExpiring, map[string]string{
+			"deadline": decision.Deadline.Format(time.RFC3339),
+			"reason":   strings.ToLower(decision.Reason),
+		})
+	default:
+		// Keep the progress unless the deadline is moved, for example the expiration date is extended.
+		if reclamation := tenant.Status.Reclamation; reclamation == nil || (reclamation.SuspendedAt == nil && !reclamation.Deadline.Equal(&deadline)) {
+			tenant.Status.Reclamation = &multitenancyv1.TenantReclamation{
+				Reason:   decision.Reason,
+				Deadline: deadline,
+			}
+		}
+	}
+
+	requeueAfter := decision.RequeueAfter
+	if tenant.Spec.InactivityDays > 0 && (requeueAfter == 0 || requeueAfter > reclamationActivityInterval) {
+		requeueAfter = reclamationActivityInterval
+	}
+
+	return requeueAfter, false, nil
+}
+
+// Sets the QuotaExhausted condition from the usage of the quota of the core namespace. The operators are notified
+// when the tenant runs out of a resource.
+func (r *TenantReconciler) updateQuotaStatus(ctx context.Context, tenant *multitenancyv1.Tenant, previous *multitenancyv1.TenantStatus, quota *corev1.ResourceQuota) {
+	condition := metav1.Condition{
+		Type:               multitenancyv1.TenantConditionQuotaExhausted,
+		Status:             metav1.ConditionFalse,
+		Reason:             multitenancyv1.TenantReasonQuotaAvailable,
+		Message:            "The quota of the tenant is not used up",
+		ObservedGeneration: tenant.GetGeneration(),
+	}
+
+	if exhausted := multitenancy.ExhaustedResources(quota); len(exhausted) != 0 {
+		resources := strings.Join(exhausted, ", ")
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = multitenancyv1.TenantReasonQuotaExhausted
+		condition.Message = fmt.Sprintf("The quota of %s is used up", resources)
+
+		if !meta.IsStatusConditionTrue(previous.Conditions, multitenancyv1.TenantConditionQuotaExhausted) {
+			r.notify(ctx, tenant, notification.EventTenantQuotaExhausted, map[string]string{"resources": resources})
+		}
+	}
+
+	meta.SetStatusCondition(&tenant.Status.Conditions, condition)
+}
+
+// Marks the tenant as failed with the error. Only the first failure of a series is notified, the following ones are
+// counted in the status. The transient errors, such as the conflicts of the optimistic locking, are only retried.
+func (r *TenantReconciler) recordFailure(ctx context.Context, tenant *multitenancyv1.Tenant, err error) {
+	if utils.IsTransientError(err) {
+		return
+	}
+
+	isFirstFailure := tenant.Status.State != multitenancyv1.TenantStateFailed
+
+	tenant.Status.State = multitenancyv1.TenantStateFailed
+	tenant.Status.Message = err.Error()
+	tenant.Status.Failed++
+
+	if err := r.Status().Update(ctx, tenant); err != nil {
+		log.FromContext(ctx).Error(err, "cannot update the status of the failed tenant")
+		return
+	}
+
+	if isFirstFailure {
+		r.notify(ctx, tenant, notification.EventTenantReconciliationFailed, map[string]string{"error": err.Error()})
+	}
+}
+
+// Sends a notification about the tenant. The notifications are best effort, a failure is only recorded.
+func (r *TenantReconciler) notify(ctx context.Context, tenant *multitenancyv1.Tenant, event notification.Event, data map[string]string) {
+	err := notification.Notify(ctx, r.Notifier, notification.Notification{Event: event, Tenant: tenant, Data: data})
+	if err != nil {
+		r.recorder.Error(ctx, tenant, events.NotificationFailed, err, fmt.Sprintf("Tenant notification %s failed", event))
+	}
+}
+
+// Sets the state and the Suspended condition of the tenant from its spec. The reason tells if the tenant is
+// suspended by the reclamation.
+func (r *TenantReconciler) updateSuspensionStatus(tenant *multitenancyv1.Tenant) {
+	state := multitenancyv1.TenantStateEstablished
+	message := "The tenant is established"
+	condition := metav1.Condition{
+		Type:               multitenancyv1.TenantConditionSuspended,
+		Status:             metav1.ConditionFalse,
+		Reason:             multitenancyv1.TenantReasonActive,
+		Message:            "The tenant is active",
+		ObservedGeneration: tenant.GetGeneration(),
+	}
+
+	if tenant.Spec.Suspended {
+		state = multitenancyv1.TenantStateSuspended
+		message = "The tenant is suspended"
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = multitenancyv1.TenantReasonSuspended
+		condition.Message = "No pods can be created and the role bindings of the tenant are removed"
+		if tenant.Spec.ScaleDownOnSuspension {
+			condition.Message += ", the workloads are scaled down"
+		}
+		if reclamation := tenant.Status.Reclamation; reclamation != nil && reclamation.SuspendedAt != nil {
+			condition.Reason = reclamation.Reason
+			message = fmt.Sprintf("The tenant is suspended since it is %s", strings.ToLower(reclamation.Reason))
+		}
+	}
+
+	meta.SetStatusCondition(&tenant.Status.Conditions, condition)
+	tenant.Status.State = state
+	tenant.Status.Message = message
+	tenant.Status.Failed = 0
+}
+
+// The usage of the quota of the core namespace is reflected in the status of the tenant. The quota has the name of
+// the core namespace, the other quotas such as the suspension quota are ignored.
+func (r *TenantReconciler) mapQuotaToTenant(ctx context.Context, obj client.Object) []reconcile.Request {
+	tenant, ok := obj.GetLabels()["edge-net.io/tenant"]
+	if !ok || obj.GetName() != obj.GetNamespace() {
+		return nil
+	}
+
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: tenant}}}
+}
+
+// The default LimitRanges of the namespaces are restored when they are changed or deleted by the users.
+func (r *TenantReconciler) mapLimitRangeToTenant(ctx context.Context, obj client.Object) []reconcile.Request {
+	tenant, ok := obj.GetLabels()["edge-net.io/tenant"]
+	if !ok || obj.GetName() != multitenancy.NamespaceDefaultsName {
+		return nil
 	}
 
-	utils.RecordEventInfo(&l, r.recorder, &tenant, "Tenant reconciliation successfull")
-	return ctrl.Result{}, nil
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: tenant}}}
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *TenantReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	// Setup the event recorder
-	r.recorder = utils.GetEventRecorder(mgr)
+	r.recorder = events.NewRecorder(utils.GetEventRecorder(mgr))
 
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&multitenancyv1.Tenant{}).
+		Watches(&corev1.ResourceQuota{}, handler.EnqueueRequestsFromMapFunc(r.mapQuotaToTenant)).
+		Watches(&corev1.LimitRange{}, handler.EnqueueRequestsFromMapFunc(r.mapLimitRangeToTenant)).
 		Complete(r)
 }
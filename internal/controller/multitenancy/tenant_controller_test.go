@@ -21,7 +21,9 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/types"
@@ -29,6 +31,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/multitenancy/v1"
 )
 
 var _ = Describe("Tenant Controller", func() {
@@ -89,4 +92,129 @@ var _ = Describe("Tenant Controller", func() {
 			// // Example: If you expect a certain status condition after reconciliation, verify it here.
 		})
 	})
+
+	Context("When the members of a tenant change", func() {
+		ctx := context.Background()
+
+		tenant := &multitenancyv1.Tenant{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "test-members",
+			},
+			Spec: multitenancyv1.TenantSpec{
+				Admin: "testadmin",
+				Members: []multitenancyv1.TenantMember{
+					{Name: "testowner", Kind: "User", Role: multitenancyv1.TenantRoleOwner},
+					{Name: "students", Kind: "Group", Role: multitenancyv1.TenantRoleMember},
+				},
+			},
+		}
+
+		BeforeEach(func() {
+			By("creating a namespace labelled with the tenant")
+			namespace := &v1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   tenant.GetName(),
+					Labels: map[string]string{"edge-net.io/tenant": tenant.GetName()},
+				},
+			}
+			err := k8sClient.Create(ctx, namespace)
+			if err != nil && !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+
+		It("should converge the role bindings of the members", func() {
+			manager, err := multitenancy.NewMultiTenancyManager(ctx, k8sClient, multitenancy.Config{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(manager.CreateTenantRoleBindings(ctx, tenant)).To(Succeed())
+
+			roleBinding := &rbacv1.RoleBinding{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: multitenancyv1.TenantOwnerRoleName, Namespace: tenant.GetName()}, roleBinding)).To(Succeed())
+			Expect(roleBinding.Subjects).To(HaveLen(1))
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: multitenancyv1.TenantAdminRoleName, Namespace: tenant.GetName()}, roleBinding)).To(Succeed())
+			Expect(roleBinding.Subjects[0].Name).To(Equal("testadmin"))
+
+			By("removing the owner from the members")
+			tenant.Spec.Members = tenant.Spec.Members[1:]
+			Expect(manager.CreateTenantRoleBindings(ctx, tenant)).To(Succeed())
+
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: multitenancyv1.TenantOwnerRoleName, Namespace: tenant.GetName()}, roleBinding)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: multitenancyv1.TenantMemberRoleName, Namespace: tenant.GetName()}, roleBinding)).To(Succeed())
+			Expect(roleBinding.Subjects[0].Kind).To(Equal("Group"))
+		})
+	})
+
+	Context("When a tenant is suspended", func() {
+		ctx := context.Background()
+
+		tenant := &multitenancyv1.Tenant{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "test-suspension",
+			},
+			Spec: multitenancyv1.TenantSpec{
+				Admin:                 "testadmin",
+				Suspended:             true,
+				ScaleDownOnSuspension: true,
+			},
+		}
+
+		BeforeEach(func() {
+			By("creating a namespace labelled with the tenant")
+			namespace := &v1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   tenant.GetName(),
+					Labels: map[string]string{"edge-net.io/tenant": tenant.GetName()},
+				},
+			}
+			err := k8sClient.Create(ctx, namespace)
+			if err != nil && !errors.IsAlreadyExists(err) {
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+
+		It("should freeze the tenant and restore it on resume", func() {
+			replicas := int32(3)
+			labels := map[string]string{"app": "web"}
+			deployment := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: tenant.GetName()},
+				Spec: appsv1.DeploymentSpec{
+					Replicas: &replicas,
+					Selector: &metav1.LabelSelector{MatchLabels: labels},
+					Template: v1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: labels},
+						Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "web", Image: "nginx"}}},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, deployment)).To(Succeed())
+
+			manager, err := multitenancy.NewMultiTenancyManager(ctx, k8sClient, multitenancy.Config{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(manager.CreateTenantRoleBindings(ctx, tenant)).To(Succeed())
+			Expect(manager.SuspendTenant(ctx, tenant)).To(Succeed())
+
+			quota := &v1.ResourceQuota{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: multitenancy.SuspensionQuotaName, Namespace: tenant.GetName()}, quota)).To(Succeed())
+			Expect(quota.Spec.Hard.Pods().IsZero()).To(BeTrue())
+
+			roleBinding := &rbacv1.RoleBinding{}
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: multitenancyv1.TenantAdminRoleName, Namespace: tenant.GetName()}, roleBinding)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "web", Namespace: tenant.GetName()}, deployment)).To(Succeed())
+			Expect(*deployment.Spec.Replicas).To(BeZero())
+			Expect(deployment.GetAnnotations()).To(HaveKeyWithValue(multitenancy.SuspendedReplicasAnnotation, "3"))
+
+			By("resuming the tenant")
+			Expect(manager.ResumeTenant(ctx, tenant)).To(Succeed())
+
+			err = k8sClient.Get(ctx, types.NamespacedName{Name: multitenancy.SuspensionQuotaName, Namespace: tenant.GetName()}, quota)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "web", Namespace: tenant.GetName()}, deployment)).To(Succeed())
+			Expect(*deployment.Spec.Replicas).To(Equal(int32(3)))
+			Expect(deployment.GetAnnotations()).NotTo(HaveKey(multitenancy.SuspendedReplicasAnnotation))
+		})
+	})
 })
@@ -0,0 +1,201 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	"context"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	operatorv1 "github.com/edgenet-project/edgenet/api/operator/v1"
+	"github.com/edgenet-project/edgenet/internal/events/v1"
+	"github.com/edgenet-project/edgenet/internal/labeller/v1"
+	"github.com/edgenet-project/edgenet/internal/operator/v1"
+	"github.com/edgenet-project/edgenet/internal/utils"
+)
+
+// EdgeNetConfigReconciler reconciles a EdgeNetConfig object
+type EdgeNetConfigReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+
+	// Used to read the referenced Secrets, only their metadata is cached.
+	Reader client.Reader
+
+	// The settings given by the flags, they are applied if the configuration doesn't exist.
+	Defaults operator.Settings
+
+	// The settings read by the other reconcilers.
+	Settings *operator.Store
+
+	// The reconcilers disabled by the flags and the ones set up at start.
+	DisabledReconcilers []string
+	ActiveReconcilers   []string
+
+	recorder *events.Recorder
+}
+
+//+kubebuilder:rbac:groups=operator.edge-net.io,resources=edgenetconfigs,verbs=get;list;watch
+//+kubebuilder:rbac:groups=operator.edge-net.io,resources=edgenetconfigs/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
+
+// Reconcile is part of the main kubernetes reconciliation loop which aims to
+// move the current state of the cluster closer to the desired state.
+// The settings of the configuration are resolved and applied to the other reconcilers. An invalid configuration
+// keeps the previous settings, the status tells which settings are active.
+//
+// For more details, check Reconcile and its Result here:
+// - https://pkg.go.dev/sigs.k8s.io/controller-runtime@v0.17.0/pkg/reconcile
+func (r *EdgeNetConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	l := log.FromContext(ctx)
+
+	// Only the singleton configuration is applied, the others cannot be created.
+	if req.Name != operatorv1.EdgeNetConfigName {
+		return ctrl.Result{}, nil
+	}
+
+	config := &operatorv1.EdgeNetConfig{}
+	if err := r.Get(ctx, req.NamespacedName, config); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			// The configuration is removed, fall back to the flags.
+			l.Info("EdgeNetConfig not found, applying the settings of the flags")
+			r.Settings.Set(r.Defaults)
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	status := config.Status.DeepCopy()
+	status.ObservedGeneration = config.GetGeneration()
+	status.ActiveReconcilers = r.ActiveReconcilers
+
+	settings, err := operator.Resolve(ctx, r.Reader, r.Defaults, config.Spec)
+	if err != nil {
+		if meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:    operatorv1.EdgeNetConfigConditionApplied,
+			Status:  metav1.ConditionFalse,
+			Reason:  "Invalid",
+			Message: err.Error(),
+		}) {
+			r.recorder.Error(ctx, config, events.ConfigInvalid, err, "EdgeNetConfig cannot be applied, the previous settings are kept")
+		}
+	} else {
+		r.Settings.Set(settings)
+		if meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+			Type:    operatorv1.EdgeNetConfigConditionApplied,
+			Status:  metav1.ConditionTrue,
+			Reason:  "Applied",
+			Message: "The settings are applied to the controllers",
+		}) {
+			r.recorder.Normal(ctx, config, events.ConfigApplied, "EdgeNetConfig applied")
+		}
+	}
+
+	// The status shows the settings in use, which are the previous ones if the configuration is invalid.
+	active := r.Settings.Get()
+	geolocation := metav1.Condition{
+		Type:    operatorv1.EdgeNetConfigConditionGeolocation,
+		Status:  metav1.ConditionTrue,
+		Reason:  "Available",
+		Message: "The nodes are labelled with their geolocation",
+	}
+	credentialsErr := labeller.MaxMindCredentialsError(active.MaxMind)
+	switch {
+	case !labeller.IsMaxMindAvailable(active.MaxMind):
+		geolocation.Status = metav1.ConditionFalse
+		geolocation.Reason = "NotConfigured"
+		geolocation.Message = "The MaxMind credentials are not given"
+	case credentialsErr != nil:
+		geolocation.Status = metav1.ConditionFalse
+		geolocation.Reason = "InvalidCredentials"
+		geolocation.Message = credentialsErr.Error()
+	}
+	if meta.SetStatusCondition(&status.Conditions, geolocation) && credentialsErr != nil {
+		r.recorder.Error(ctx, config, events.MaxMindCredentialsRejected, credentialsErr, "MaxMind rejects the credentials, the nodes are not labelled with their geolocation")
+	}
+	status.Geolocation = geolocation.Status == metav1.ConditionTrue
+	status.ClusterNetworkPolicy = active.ClusterNetworkPolicy
+	status.NamespaceDefaults = active.NamespaceDefaults.DeepCopy()
+	status.EmailNotifications = active.SMTPSecret.Name != ""
+
+	restart := metav1.Condition{
+		Type:    operatorv1.EdgeNetConfigConditionRestartRequired,
+		Status:  metav1.ConditionFalse,
+		Reason:  "UpToDate",
+		Message: "The running reconcilers match the configuration",
+	}
+	if !slices.Equal(operator.ActiveReconcilers(r.DisabledReconcilers, config.Spec.DisabledReconcilers), r.ActiveReconcilers) {
+		restart.Status = metav1.ConditionTrue
+		restart.Reason = "ReconcilersChanged"
+		restart.Message = "The disabled reconcilers changed, restart the controller manager to apply them"
+	}
+	if meta.SetStatusCondition(&status.Conditions, restart) && restart.Status == metav1.ConditionTrue {
+		r.recorder.Warning(ctx, config, events.RestartRequired, restart.Message)
+	}
+
+	if !equality.Semantic.DeepEqual(&config.Status, status) {
+		config.Status = *status
+		if err := r.Status().Update(ctx, config); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// MaxMind rejects the credentials during the lookups of the node labeller, the status is checked again
+	// periodically to report them.
+	if active.MaxMind != nil {
+		return ctrl.Result{RequeueAfter: labeller.DefaultMaxMindReloadInterval}, nil
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// The configuration is resolved again when one of the Secrets it references changes.
+func (r *EdgeNetConfigReconciler) mapSecretToConfig(ctx context.Context, obj client.Object) []reconcile.Request {
+	config := &operatorv1.EdgeNetConfig{}
+	if err := r.Get(ctx, types.NamespacedName{Name: operatorv1.EdgeNetConfigName}, config); err != nil {
+		return nil
+	}
+
+	if !operator.ReferencesSecret(config.Spec, client.ObjectKeyFromObject(obj)) {
+		return nil
+	}
+
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: operatorv1.EdgeNetConfigName}}}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *EdgeNetConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	// Setup the event recorder
+	r.recorder = events.NewRecorder(utils.GetEventRecorder(mgr))
+
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&operatorv1.EdgeNetConfig{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.mapSecretToConfig), builder.OnlyMetadata).
+		Complete(r)
+}
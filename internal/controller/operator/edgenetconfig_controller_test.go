@@ -0,0 +1,93 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	operatorv1 "github.com/edgenet-project/edgenet/api/operator/v1"
+	"github.com/edgenet-project/edgenet/internal/operator/v1"
+)
+
+var _ = Describe("EdgeNetConfig Controller", func() {
+	Context("When reconciling a resource", func() {
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name: operatorv1.EdgeNetConfigName,
+		}
+
+		BeforeEach(func() {
+			By("creating the custom resource for the Kind EdgeNetConfig")
+			config := &operatorv1.EdgeNetConfig{}
+			if err := k8sClient.Get(ctx, typeNamespacedName, config); err != nil && errors.IsNotFound(err) {
+				config = &operatorv1.EdgeNetConfig{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: operatorv1.EdgeNetConfigName,
+					},
+					Spec: operatorv1.EdgeNetConfigSpec{
+						DisabledReconcilers:   []string{"SelectiveDeployment"},
+						DefaultNetworkProfile: &operatorv1.NetworkProfile{ClusterNetworkPolicy: true},
+						QuotaPolicy: &operatorv1.QuotaPolicy{
+							NamespaceDefaults: &multitenancyv1.NamespaceDefaults{PodSecurityLevel: multitenancyv1.PodSecurityLevelRestricted},
+						},
+					},
+				}
+				Expect(k8sClient.Create(ctx, config)).To(Succeed())
+			}
+		})
+
+		AfterEach(func() {
+			config := &operatorv1.EdgeNetConfig{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, config)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, config)).To(Succeed())
+		})
+
+		It("should apply the settings and report the required restart", func() {
+			settings := operator.NewStore(operator.Settings{})
+			controllerReconciler := &EdgeNetConfigReconciler{
+				Client:            k8sClient,
+				Scheme:            k8sClient.Scheme(),
+				Reader:            k8sClient,
+				Settings:          settings,
+				ActiveReconcilers: operator.ActiveReconcilers(),
+			}
+
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(settings.Get().ClusterNetworkPolicy).To(BeTrue())
+			Expect(settings.Get().NamespaceDefaults.PodSecurityLevel).To(Equal(multitenancyv1.PodSecurityLevelRestricted))
+
+			config := &operatorv1.EdgeNetConfig{}
+			Expect(k8sClient.Get(ctx, typeNamespacedName, config)).To(Succeed())
+			Expect(meta.IsStatusConditionTrue(config.Status.Conditions, operatorv1.EdgeNetConfigConditionApplied)).To(BeTrue())
+			Expect(meta.IsStatusConditionTrue(config.Status.Conditions, operatorv1.EdgeNetConfigConditionRestartRequired)).To(BeTrue())
+			Expect(config.Status.ClusterNetworkPolicy).To(BeTrue())
+			Expect(config.Status.ObservedGeneration).To(Equal(config.GetGeneration()))
+		})
+	})
+})
@@ -0,0 +1,184 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/cache"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// Reason is the machine readable reason of an event. The reasons are stable, they can be used to filter the events
+// with kubectl or by the alerting.
+type Reason string
+
+// The reasons of the events recorded by the tenant reconciler.
+const (
+	NamespaceCreated    Reason = "NamespaceCreated"
+	NamespaceFailed     Reason = "NamespaceFailed"
+	QuotaUpdated        Reason = "QuotaUpdated"
+	RoleBindingFailed   Reason = "RoleBindingFailed"
+	NetworkPolicyFailed Reason = "NetworkPolicyFailed"
+	DefaultsFailed      Reason = "DefaultsFailed"
+	CleanupFailed       Reason = "CleanupFailed"
+	TenantSuspended     Reason = "TenantSuspended"
+	TenantResumed       Reason = "TenantResumed"
+	SuspensionFailed    Reason = "SuspensionFailed"
+	ResumptionFailed    Reason = "ResumptionFailed"
+	ReclamationWarning  Reason = "ReclamationWarning"
+	ReclamationFailed   Reason = "ReclamationFailed"
+	TenantReclaimed     Reason = "TenantReclaimed"
+	TenantReconciled    Reason = "TenantReconciled"
+)
+
+// The reasons of the events recorded by the node labeller.
+const (
+	LabelsApplied             Reason = "LabelsApplied"
+	LabelsPending             Reason = "LabelsPending"
+	GeolocationFailed         Reason = "GeolocationFailed"
+	CapabilityDetectionFailed Reason = "CapabilityDetectionFailed"
+	LabellingFailed           Reason = "LabellingFailed"
+)
+
+// The reasons of the events recorded by the allocation policy reconciler.
+const (
+	AllocationOvercommitted Reason = "AllocationOvercommitted"
+	AllocationRecovered     Reason = "AllocationRecovered"
+)
+
+// The reasons of the events recorded by the configuration reconciler.
+const (
+	ConfigApplied   Reason = "ConfigApplied"
+	ConfigInvalid   Reason = "ConfigInvalid"
+	RestartRequired Reason = "RestartRequired"
+
+	MaxMindCredentialsRejected Reason = "MaxMindCredentialsRejected"
+)
+
+// The reasons of the events recorded by the SubNamespace and the propagation reconcilers.
+const (
+	SubNamespaceSetupFailed Reason = "SubNamespaceSetupFailed"
+	SubNamespaceReconciled  Reason = "SubNamespaceReconciled"
+	PropagationFailed       Reason = "PropagationFailed"
+	PropagationConflict     Reason = "PropagationConflict"
+)
+
+// The reasons of the events recorded by the RoleRequest reconciler, it also records RoleBindingFailed and
+// CleanupFailed.
+const (
+	RoleRequestUpdated Reason = "RoleRequestUpdated"
+)
+
+// The reasons of the events recorded by the Slice and the SliceClaim reconcilers.
+const (
+	SliceCreationFailed    Reason = "SliceCreationFailed"
+	SliceReservationFailed Reason = "SliceReservationFailed"
+	SliceReleaseFailed     Reason = "SliceReleaseFailed"
+	SliceUpdated           Reason = "SliceUpdated"
+)
+
+// The reasons of the events recorded by the NodeContribution reconciler, it also records LabellingFailed and
+// CleanupFailed.
+const (
+	BootstrapTokenFailed    Reason = "BootstrapTokenFailed"
+	NodeContributionUpdated Reason = "NodeContributionUpdated"
+)
+
+// The reasons of the events recorded by the SelectiveDeployment reconciler.
+const (
+	SelectorsInvalid           Reason = "SelectorsInvalid"
+	WorkloadFailed             Reason = "WorkloadFailed"
+	SelectiveDeploymentUpdated Reason = "SelectiveDeploymentUpdated"
+)
+
+// NotificationFailed is recorded on the object a notification is about when it cannot be delivered.
+const NotificationFailed Reason = "NotificationFailed"
+
+// An identical event of an object is only recorded once in this window. The reconcilers are requeued often, the
+// same event would otherwise be recorded on every reconciliation.
+const DefaultAggregationWindow = 10 * time.Minute
+
+// The number of recent events kept for the aggregation, the oldest ones are recorded again when it is exceeded.
+const aggregationCacheSize = 4096
+
+// Recorder records the events of the objects with a typed reason and writes them to the log of the reconciliation.
+// The log always has the event, the recording of the identical events is aggregated. A nil recorder only logs.
+type Recorder struct {
+	recorder record.EventRecorder
+	window   time.Duration
+	recent   *cache.LRUExpireCache
+}
+
+// Creates a recorder on top of the event recorder of the manager with the default aggregation window.
+func NewRecorder(recorder record.EventRecorder) *Recorder {
+	return NewRecorderWithClock(recorder, DefaultAggregationWindow, clock{})
+}
+
+// Creates a recorder with the given aggregation window, the clock decides when the window is over.
+func NewRecorderWithClock(recorder record.EventRecorder, window time.Duration, c cache.Clock) *Recorder {
+	return &Recorder{
+		recorder: recorder,
+		window:   window,
+		recent:   cache.NewLRUExpireCacheWithClock(aggregationCacheSize, c),
+	}
+}
+
+type clock struct{}
+
+func (clock) Now() time.Time { return time.Now() }
+
+// Records an informational event on the object.
+func (r *Recorder) Normal(ctx context.Context, obj client.Object, reason Reason, message string) {
+	log.FromContext(ctx).Info(message, "reason", reason)
+	r.record(obj, corev1.EventTypeNormal, reason, message)
+}
+
+// Records a warning event on the object, for a situation that needs attention but is not a failure.
+func (r *Recorder) Warning(ctx context.Context, obj client.Object, reason Reason, message string) {
+	log.FromContext(ctx).Info(message, "reason", reason)
+	r.record(obj, corev1.EventTypeWarning, reason, message)
+}
+
+// Records a failure on the object. The error is logged as it is and appended to the message of the event.
+func (r *Recorder) Error(ctx context.Context, obj client.Object, reason Reason, err error, message string) {
+	log.FromContext(ctx).Error(err, message, "reason", reason)
+	if err != nil {
+		message = fmt.Sprintf("%s: %v", message, err)
+	}
+	r.record(obj, corev1.EventTypeWarning, reason, message)
+}
+
+// Records the event unless the identical event of the object is already recorded in the aggregation window.
+func (r *Recorder) record(obj client.Object, eventType string, reason Reason, message string) {
+	if r == nil || r.recorder == nil {
+		return
+	}
+
+	key := fmt.Sprintf("%s/%s/%s/%s", obj.GetUID(), eventType, reason, message)
+	if _, ok := r.recent.Get(key); ok {
+		return
+	}
+	r.recent.Add(key, struct{}{}, r.window)
+
+	r.recorder.Event(obj, eventType, string(reason), message)
+}
@@ -0,0 +1,98 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package events
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// A clock moved forward by the tests.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+var _ = Describe("Recorder", func() {
+	ctx := context.Background()
+
+	var (
+		fake     *record.FakeRecorder
+		clock    *fakeClock
+		recorder *Recorder
+		node     *corev1.Node
+	)
+
+	BeforeEach(func() {
+		fake = record.NewFakeRecorder(10)
+		clock = &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+		recorder = NewRecorderWithClock(fake, time.Minute, clock)
+		node = &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1", UID: "uid-1"}}
+	})
+
+	It("records the events with their reason", func() {
+		recorder.Normal(ctx, node, LabelsApplied, "Labels applied")
+		recorder.Warning(ctx, node, ReclamationWarning, "The tenant will be suspended")
+
+		Expect(fake.Events).To(Receive(Equal("Normal LabelsApplied Labels applied")))
+		Expect(fake.Events).To(Receive(Equal("Warning ReclamationWarning The tenant will be suspended")))
+	})
+
+	It("appends the error to the message", func() {
+		recorder.Error(ctx, node, GeolocationFailed, errors.New("maxmind unavailable"), "Geolocation failed")
+
+		Expect(fake.Events).To(Receive(Equal("Warning GeolocationFailed Geolocation failed: maxmind unavailable")))
+	})
+
+	It("aggregates the identical events in the window", func() {
+		recorder.Normal(ctx, node, TenantReconciled, "Tenant reconciled")
+		recorder.Normal(ctx, node, TenantReconciled, "Tenant reconciled")
+		Expect(fake.Events).To(HaveLen(1))
+
+		// A different message, reason or object is recorded.
+		recorder.Normal(ctx, node, TenantReconciled, "Tenant reconciled again")
+		recorder.Warning(ctx, node, NotificationFailed, "Tenant reconciled")
+		other := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-2", UID: "uid-2"}}
+		recorder.Normal(ctx, other, TenantReconciled, "Tenant reconciled")
+		Expect(fake.Events).To(HaveLen(4))
+
+		clock.now = clock.now.Add(2 * time.Minute)
+		recorder.Normal(ctx, node, TenantReconciled, "Tenant reconciled")
+		Expect(fake.Events).To(HaveLen(5))
+	})
+
+	It("only logs without an event recorder", func() {
+		var recorder *Recorder
+		Expect(func() {
+			recorder.Error(ctx, node, CleanupFailed, errors.New("failed"), "Tenant cleanup failed")
+		}).NotTo(Panic())
+
+		Expect(func() {
+			NewRecorder(nil).Normal(ctx, node, TenantReconciled, "Tenant reconciled")
+		}).NotTo(Panic())
+	})
+})
@@ -0,0 +1,346 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package infrastructure
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"time"
+
+	infrastructurev1 "github.com/edgenet-project/edgenet/api/infrastructure/v1"
+	"github.com/edgenet-project/edgenet/internal/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// These are the keys of the bootstrap token secrets, see
+// https://kubernetes.io/docs/reference/access-authn-authz/bootstrap-tokens/
+const (
+	bootstrapTokenSecretPrefix        = "bootstrap-token-"
+	bootstrapTokenIDKey               = "token-id"
+	bootstrapTokenSecretKey           = "token-secret"
+	bootstrapTokenExpirationKey       = "expiration"
+	bootstrapTokenDescriptionKey      = "description"
+	bootstrapTokenExtraGroupsKey      = "auth-extra-groups"
+	bootstrapTokenUsageAuthentication = "usage-bootstrap-authentication"
+	bootstrapTokenUsageSigning        = "usage-bootstrap-signing"
+
+	// The group kubeadm uses to let the bootstrap tokens join the nodes.
+	bootstrapTokenKubeadmGroup = "system:bootstrappers:kubeadm:default-node-token"
+
+	// Key of the token in the join secret given to the tenant.
+	JoinTokenKey = "token"
+)
+
+const (
+	tokenCharacters   = "abcdefghijklmnopqrstuvwxyz0123456789"
+	tokenIDLength     = 6
+	tokenSecretLength = 16
+)
+
+// This interface contains the necessary functions to perform the operations related to the infrastructure
+// of the cluster, such as the nodes contributed by the tenants.
+type InfrastructureManager interface {
+	// Issues a bootstrap token for the contribution unless there is one that is still valid. The token is copied
+	// into a secret in the core namespace of the tenant. Returns the expiry of the token.
+	IssueBootstrapToken(context.Context, *infrastructurev1.NodeContribution) (time.Time, error)
+
+	// Finds the node joined with the contribution. Returns nil if the node didn't join yet.
+	FindContributedNode(context.Context, *infrastructurev1.NodeContribution) (*corev1.Node, error)
+
+	// Labels the node with the contributing tenant and removes the bootstrap token, which is not needed anymore.
+	LinkContributedNode(context.Context, *infrastructurev1.NodeContribution, *corev1.Node) error
+
+	// Removes the tokens of the contribution and the labels of its node. The node itself is not removed.
+	NodeContributionCleanup(context.Context, *infrastructurev1.NodeContribution) error
+}
+
+type infrastructureManager struct {
+	InfrastructureManager
+	client client.Client
+}
+
+func NewInfrastructureManager(ctx context.Context, client client.Client) (InfrastructureManager, error) {
+	return &infrastructureManager{
+		client: client,
+	}, nil
+}
+
+// Resolves the name of the secret holding the join token in the core namespace of the tenant.
+func ResolveJoinSecretName(nc *infrastructurev1.NodeContribution) string {
+	return fmt.Sprintf("nodecontribution-%s", nc.GetName())
+}
+
+// Issues a new bootstrap token if the previous one is expired or missing. The bootstrap token secret is created
+// in the kube-system namespace where the API server looks for it.
+func (m *infrastructureManager) IssueBootstrapToken(ctx context.Context, nc *infrastructurev1.NodeContribution) (time.Time, error) {
+	secrets, err := m.listBootstrapTokens(ctx, nc)
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	now := time.Now()
+	for _, secret := range secrets {
+		expiration, err := time.Parse(time.RFC3339, string(secret.Data[bootstrapTokenExpirationKey]))
+		if err == nil && now.Before(expiration) {
+			// The join secret might be removed by the tenant, make sure it exists.
+			return expiration, m.createJoinSecret(ctx, nc, &secret, expiration)
+		}
+	}
+
+	// All of the tokens are expired, remove them before issuing a new one.
+	for i := range secrets {
+		if err := m.client.Delete(ctx, &secrets[i]); err != nil && !errors.IsNotFound(err) {
+			return time.Time{}, err
+		}
+	}
+
+	tokenID, err := randomToken(tokenIDLength)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	tokenSecret, err := randomToken(tokenSecretLength)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	ttl := nc.Spec.TokenTTL.Duration
+	if ttl <= 0 {
+		ttl = 24 * time.Hour
+	}
+	expiration := now.Add(ttl).UTC().Truncate(time.Second)
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      bootstrapTokenSecretPrefix + tokenID,
+			Namespace: metav1.NamespaceSystem,
+			Labels: map[string]string{
+				"edge-net.io/generated":            "true",
+				infrastructurev1.ContributionLabel: nc.GetName(),
+			},
+		},
+		Type: corev1.SecretTypeBootstrapToken,
+		StringData: map[string]string{
+			bootstrapTokenIDKey:               tokenID,
+			bootstrapTokenSecretKey:           tokenSecret,
+			bootstrapTokenExpirationKey:       expiration.Format(time.RFC3339),
+			bootstrapTokenDescriptionKey:      fmt.Sprintf("Node contribution %s of tenant %s", nc.GetName(), nc.Spec.Tenant),
+			bootstrapTokenExtraGroupsKey:      bootstrapTokenKubeadmGroup,
+			bootstrapTokenUsageAuthentication: "true",
+			bootstrapTokenUsageSigning:        "true",
+		},
+	}
+
+	if err := m.client.Create(ctx, secret); err != nil {
+		return time.Time{}, err
+	}
+
+	secret.Data = map[string][]byte{
+		bootstrapTokenIDKey:     []byte(tokenID),
+		bootstrapTokenSecretKey: []byte(tokenSecret),
+	}
+
+	return expiration, m.createJoinSecret(ctx, nc, secret, expiration)
+}
+
+// Copies the token into the core namespace of the tenant, so the tenant admins can join the node.
+func (m *infrastructureManager) createJoinSecret(ctx context.Context, nc *infrastructurev1.NodeContribution, tokenSecret *corev1.Secret, expiration time.Time) error {
+	token := fmt.Sprintf("%s.%s", tokenSecret.Data[bootstrapTokenIDKey], tokenSecret.Data[bootstrapTokenSecretKey])
+
+	secret := &corev1.Secret{}
+	key := types.NamespacedName{Name: ResolveJoinSecretName(nc), Namespace: utils.ResolveCoreNamespaceName(nc.Spec.Tenant)}
+
+	if err := m.client.Get(ctx, key, secret); err == nil {
+		if string(secret.Data[JoinTokenKey]) == token {
+			return nil
+		}
+		secret.Data = nil
+		secret.StringData = map[string]string{
+			JoinTokenKey:                token,
+			bootstrapTokenExpirationKey: expiration.Format(time.RFC3339),
+		}
+		return m.client.Update(ctx, secret)
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+
+	secret = &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      key.Name,
+			Namespace: key.Namespace,
+			Labels: map[string]string{
+				"edge-net.io/generated":            "true",
+				infrastructurev1.ContributionLabel: nc.GetName(),
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		StringData: map[string]string{
+			JoinTokenKey:                token,
+			bootstrapTokenExpirationKey: expiration.Format(time.RFC3339),
+		},
+	}
+
+	return m.client.Create(ctx, secret)
+}
+
+// Lists the bootstrap tokens issued for the contribution.
+func (m *infrastructureManager) listBootstrapTokens(ctx context.Context, nc *infrastructurev1.NodeContribution) ([]corev1.Secret, error) {
+	secretList := &corev1.SecretList{}
+
+	if err := m.client.List(ctx, secretList, client.InNamespace(metav1.NamespaceSystem), client.MatchingLabels{infrastructurev1.ContributionLabel: nc.GetName()}); err != nil {
+		return nil, err
+	}
+
+	return secretList.Items, nil
+}
+
+// The node is found by the contribution label first, then by the hostname and finally by the expected IP
+// address. Nodes linked to another contribution are never matched.
+func (m *infrastructureManager) FindContributedNode(ctx context.Context, nc *infrastructurev1.NodeContribution) (*corev1.Node, error) {
+	nodeList := &corev1.NodeList{}
+
+	if err := m.client.List(ctx, nodeList); err != nil {
+		return nil, err
+	}
+
+	var byHost, byIP *corev1.Node
+
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+		contribution, linked := node.GetLabels()[infrastructurev1.ContributionLabel]
+
+		if linked {
+			if contribution == nc.GetName() {
+				return node, nil
+			}
+			continue
+		}
+
+		if node.GetName() == nc.Spec.Host {
+			byHost = node
+		}
+
+		if nc.Spec.ExpectedIP != "" && byIP == nil {
+			for _, address := range node.Status.Addresses {
+				if address.Address == nc.Spec.ExpectedIP {
+					byIP = node
+					break
+				}
+			}
+		}
+	}
+
+	if byHost != nil {
+		return byHost, nil
+	}
+
+	return byIP, nil
+}
+
+// Sets the ownership labels of the node. The bootstrap token is removed once the node joined. The labels are
+// patched, so the status updates of the kubelet don't conflict with them.
+func (m *infrastructureManager) LinkContributedNode(ctx context.Context, nc *infrastructurev1.NodeContribution, node *corev1.Node) error {
+	labels := map[string]string{
+		infrastructurev1.OwnerTenantLabel:  nc.Spec.Tenant,
+		infrastructurev1.ContributionLabel: nc.GetName(),
+	}
+
+	if !utils.ContainsLabels(node.GetLabels(), labels) {
+		original := node.DeepCopy()
+		node.SetLabels(utils.MergeLabels(node.GetLabels(), labels))
+		if err := m.client.Patch(ctx, node, client.MergeFrom(original)); err != nil {
+			return err
+		}
+	}
+
+	return m.deleteTokens(ctx, nc)
+}
+
+// Removes the tokens and unlinks the node.
+func (m *infrastructureManager) NodeContributionCleanup(ctx context.Context, nc *infrastructurev1.NodeContribution) error {
+	if err := m.deleteTokens(ctx, nc); err != nil {
+		return err
+	}
+
+	nodeList := &corev1.NodeList{}
+
+	if err := m.client.List(ctx, nodeList, client.MatchingLabels{infrastructurev1.ContributionLabel: nc.GetName()}); err != nil {
+		return err
+	}
+
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+		original := node.DeepCopy()
+		labels := node.GetLabels()
+		delete(labels, infrastructurev1.ContributionLabel)
+		delete(labels, infrastructurev1.OwnerTenantLabel)
+		node.SetLabels(labels)
+
+		if err := m.client.Patch(ctx, node, client.MergeFrom(original)); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Deletes the bootstrap tokens and the join secret of the contribution.
+func (m *infrastructureManager) deleteTokens(ctx context.Context, nc *infrastructurev1.NodeContribution) error {
+	secrets, err := m.listBootstrapTokens(ctx, nc)
+
+	if err != nil {
+		return err
+	}
+
+	for i := range secrets {
+		if err := m.client.Delete(ctx, &secrets[i]); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	joinSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ResolveJoinSecretName(nc),
+			Namespace: utils.ResolveCoreNamespaceName(nc.Spec.Tenant),
+		},
+	}
+
+	return client.IgnoreNotFound(m.client.Delete(ctx, joinSecret))
+}
+
+// Generates a random string from the characters allowed in the bootstrap tokens.
+func randomToken(length int) (string, error) {
+	token := make([]byte, length)
+	max := big.NewInt(int64(len(tokenCharacters)))
+
+	for i := range token {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		token[i] = tokenCharacters[n.Int64()]
+	}
+
+	return string(token), nil
+}
@@ -0,0 +1,298 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package labeller
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+// These are the labels set on the nodes from their capabilities.
+const (
+	ArchLabel        = "edge-net.io/arch"
+	OSFamilyLabel    = "edge-net.io/os-family"
+	KernelMajorLabel = "edge-net.io/kernel-major"
+	CPUClassLabel    = "edge-net.io/cpu-class"
+	MemoryClassLabel = "edge-net.io/memory-class"
+	DeviceClassLabel = "edge-net.io/device-class"
+)
+
+// CapabilityLabelKeys are all of the labels set from the capabilities.
+var CapabilityLabelKeys = []string{
+	ArchLabel,
+	OSFamilyLabel,
+	KernelMajorLabel,
+	CPUClassLabel,
+	MemoryClassLabel,
+	DeviceClassLabel,
+}
+
+// The key of the rules in the ConfigMap.
+const CapabilityRulesKey = "rules.yaml"
+
+// CapabilityRules configure how the capabilities of the nodes are turned into labels.
+type CapabilityRules struct {
+	// Classes of the CPU capacity, from the smallest to the largest.
+	CPUClasses []ResourceClass `json:"cpuClasses,omitempty"`
+
+	// Classes of the memory capacity, from the smallest to the largest.
+	MemoryClasses []ResourceClass `json:"memoryClasses,omitempty"`
+
+	// The OS families matched against the OS image of the node, the first match is used.
+	OSFamilies []OSFamilyRule `json:"osFamilies,omitempty"`
+
+	// The device classes, the first match is used.
+	DeviceClasses []DeviceClassRule `json:"deviceClasses,omitempty"`
+
+	// The device class of the nodes that don't match any of the rules. The label is not set if it is empty.
+	DefaultDeviceClass string `json:"defaultDeviceClass,omitempty"`
+}
+
+// ResourceClass is a bucket of a resource. A node is in the first class whose maximum is not exceeded, a class
+// without a maximum matches all of the nodes.
+type ResourceClass struct {
+	Name string             `json:"name"`
+	Max  *resource.Quantity `json:"max,omitempty"`
+}
+
+// OSFamilyRule maps the OS images to a family, for example "Ubuntu 22.04.3 LTS" to "ubuntu".
+type OSFamilyRule struct {
+	Name string `json:"name"`
+
+	// Regular expression matched against the OS image of the node.
+	Pattern string `json:"pattern"`
+}
+
+// DeviceClassRule matches the nodes of a kind of device, such as the Raspberry Pis. All of the given conditions
+// should be satisfied.
+type DeviceClassRule struct {
+	Name          string             `json:"name"`
+	Architectures []string           `json:"architectures,omitempty"`
+	OSFamilies    []string           `json:"osFamilies,omitempty"`
+	MaxCPU        *resource.Quantity `json:"maxCPU,omitempty"`
+	MaxMemory     *resource.Quantity `json:"maxMemory,omitempty"`
+}
+
+// The rules used when there is no rules ConfigMap.
+var DefaultCapabilityRules = CapabilityRules{
+	CPUClasses: []ResourceClass{
+		{Name: "small", Max: resourcePointer("2")},
+		{Name: "medium", Max: resourcePointer("8")},
+		{Name: "large"},
+	},
+	MemoryClasses: []ResourceClass{
+		{Name: "small", Max: resourcePointer("2Gi")},
+		{Name: "medium", Max: resourcePointer("16Gi")},
+		{Name: "large"},
+	},
+	OSFamilies: []OSFamilyRule{
+		{Name: "raspbian", Pattern: `(?i)raspbian|raspberry pi os`},
+		{Name: "ubuntu", Pattern: `(?i)ubuntu`},
+		{Name: "debian", Pattern: `(?i)debian`},
+		{Name: "rhel", Pattern: `(?i)red hat|centos|rocky|almalinux`},
+		{Name: "fedora", Pattern: `(?i)fedora`},
+	},
+	DeviceClasses: []DeviceClassRule{
+		{Name: "raspberry-pi", Architectures: []string{"arm", "arm64"}, OSFamilies: []string{"raspbian"}},
+		{Name: "single-board", Architectures: []string{"arm", "arm64"}, MaxMemory: resourcePointer("8Gi")},
+	},
+	DefaultDeviceClass: "server",
+}
+
+// Parses the rules and validates the patterns.
+func ParseCapabilityRules(data string) (*CapabilityRules, error) {
+	rules := &CapabilityRules{}
+
+	if err := yaml.UnmarshalStrict([]byte(data), rules); err != nil {
+		return nil, err
+	}
+
+	for _, family := range rules.OSFamilies {
+		if _, err := regexp.Compile(family.Pattern); err != nil {
+			return nil, fmt.Errorf("invalid pattern of the os family %s: %w", family.Name, err)
+		}
+	}
+
+	return rules, nil
+}
+
+// Computes the capability labels of the node. The labels that cannot be derived from the node are left out.
+func (r *CapabilityRules) Labels(node *corev1.Node) map[string]string {
+	labels := map[string]string{}
+	info := node.Status.NodeInfo
+
+	if arch := SanitizeLabelValue(info.Architecture); arch != "" {
+		labels[ArchLabel] = arch
+	}
+
+	family := ""
+	for _, rule := range r.OSFamilies {
+		if matched, err := regexp.MatchString(rule.Pattern, info.OSImage); err == nil && matched {
+			family = rule.Name
+			break
+		}
+	}
+	if family == "" && info.OSImage != "" {
+		family = "other"
+	}
+	if family = SanitizeLabelValue(family); family != "" {
+		labels[OSFamilyLabel] = family
+	}
+
+	if major := kernelMajorVersion(info.KernelVersion); major != "" {
+		labels[KernelMajorLabel] = major
+	}
+
+	cpu, hasCPU := node.Status.Capacity[corev1.ResourceCPU]
+	if class := resourceClass(r.CPUClasses, cpu); hasCPU && class != "" {
+		labels[CPUClassLabel] = class
+	}
+
+	memory, hasMemory := node.Status.Capacity[corev1.ResourceMemory]
+	if class := resourceClass(r.MemoryClasses, memory); hasMemory && class != "" {
+		labels[MemoryClassLabel] = class
+	}
+
+	deviceClass := r.DefaultDeviceClass
+	for _, rule := range r.DeviceClasses {
+		if rule.matches(info.Architecture, family, cpu, memory) {
+			deviceClass = rule.Name
+			break
+		}
+	}
+	if deviceClass = SanitizeLabelValue(deviceClass); deviceClass != "" {
+		labels[DeviceClassLabel] = deviceClass
+	}
+
+	return labels
+}
+
+// Checks all of the conditions of the rule.
+func (r *DeviceClassRule) matches(arch, family string, cpu, memory resource.Quantity) bool {
+	if len(r.Architectures) != 0 && !contains(r.Architectures, arch) {
+		return false
+	}
+	if len(r.OSFamilies) != 0 && !contains(r.OSFamilies, family) {
+		return false
+	}
+	if r.MaxCPU != nil && cpu.Cmp(*r.MaxCPU) > 0 {
+		return false
+	}
+	if r.MaxMemory != nil && memory.Cmp(*r.MaxMemory) > 0 {
+		return false
+	}
+	return true
+}
+
+// Returns the first class whose maximum is not exceeded.
+func resourceClass(classes []ResourceClass, quantity resource.Quantity) string {
+	for _, class := range classes {
+		if class.Max == nil || quantity.Cmp(*class.Max) <= 0 {
+			return SanitizeLabelValue(class.Name)
+		}
+	}
+	return ""
+}
+
+// Returns the major version of the kernel, for example "6" for "6.1.0-18-amd64".
+func kernelMajorVersion(version string) string {
+	major, _, _ := strings.Cut(strings.TrimPrefix(version, "v"), ".")
+	for _, c := range major {
+		if c < '0' || c > '9' {
+			return ""
+		}
+	}
+	return major
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func resourcePointer(value string) *resource.Quantity {
+	quantity := resource.MustParse(value)
+	return &quantity
+}
+
+// CapabilityLabeller labels the nodes with their capabilities, such as the architecture and the memory class.
+// The rules are read from a ConfigMap on each call, so they can be changed without restarting the controller.
+type CapabilityLabeller struct {
+	Client client.Client
+
+	// The ConfigMap holding the rules, the default rules are used if it doesn't exist.
+	ConfigMap types.NamespacedName
+}
+
+// The name of the CapabilityLabeller, the reconciler tells its failures apart by it.
+const CapabilityLabellerName = "capability"
+
+var _ Labeller = &CapabilityLabeller{}
+
+func (c *CapabilityLabeller) Name() string {
+	return CapabilityLabellerName
+}
+
+func (c *CapabilityLabeller) Keys() []string {
+	return CapabilityLabelKeys
+}
+
+func (c *CapabilityLabeller) Labels(ctx context.Context, node *corev1.Node) (map[string]string, error) {
+	rules, err := c.rules(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rules.Labels(node), nil
+}
+
+// Reads the rules from the ConfigMap.
+func (c *CapabilityLabeller) rules(ctx context.Context) (*CapabilityRules, error) {
+	if c.ConfigMap.Name == "" {
+		return &DefaultCapabilityRules, nil
+	}
+
+	configMap := &corev1.ConfigMap{}
+
+	if err := c.Client.Get(ctx, c.ConfigMap, configMap); err != nil {
+		if errors.IsNotFound(err) {
+			return &DefaultCapabilityRules, nil
+		}
+		return nil, err
+	}
+
+	data, ok := configMap.Data[CapabilityRulesKey]
+	if !ok {
+		return nil, fmt.Errorf("the ConfigMap %s doesn't have the %s key", c.ConfigMap, CapabilityRulesKey)
+	}
+
+	return ParseCapabilityRules(data)
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package labeller
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/yaml"
+)
+
+func capabilityNode(arch, osImage, kernel, cpu, memory string) *corev1.Node {
+	return &corev1.Node{
+		Status: corev1.NodeStatus{
+			NodeInfo: corev1.NodeSystemInfo{Architecture: arch, OSImage: osImage, KernelVersion: kernel},
+			Capacity: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(memory),
+			},
+		},
+	}
+}
+
+var _ = Describe("Capability", func() {
+	It("should label a Raspberry Pi", func() {
+		node := capabilityNode("arm64", "Raspbian GNU/Linux 11 (bullseye)", "6.1.21-v8+", "4", "4Gi")
+		Expect(DefaultCapabilityRules.Labels(node)).To(Equal(map[string]string{
+			ArchLabel:        "arm64",
+			OSFamilyLabel:    "raspbian",
+			KernelMajorLabel: "6",
+			CPUClassLabel:    "medium",
+			MemoryClassLabel: "medium",
+			DeviceClassLabel: "raspberry-pi",
+		}))
+	})
+
+	It("should label a server", func() {
+		node := capabilityNode("amd64", "Ubuntu 22.04.3 LTS", "5.15.0-91-generic", "32", "128Gi")
+		Expect(DefaultCapabilityRules.Labels(node)).To(Equal(map[string]string{
+			ArchLabel:        "amd64",
+			OSFamilyLabel:    "ubuntu",
+			KernelMajorLabel: "5",
+			CPUClassLabel:    "large",
+			MemoryClassLabel: "large",
+			DeviceClassLabel: "server",
+		}))
+	})
+
+	It("should leave out the unknown capabilities", func() {
+		labels := DefaultCapabilityRules.Labels(&corev1.Node{})
+		Expect(labels).To(Equal(map[string]string{DeviceClassLabel: "server"}))
+	})
+
+	It("should reject invalid rules", func() {
+		_, err := ParseCapabilityRules("osFamilies:\n- name: broken\n  pattern: \"(\"\n")
+		Expect(err).To(HaveOccurred())
+		_, err = ParseCapabilityRules("unknown: true\n")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should ship the default rules in the ConfigMap", func() {
+		data, err := os.ReadFile(filepath.Join("..", "..", "..", "config", "manager", "capability_rules.yaml"))
+		Expect(err).NotTo(HaveOccurred())
+
+		configMap := &corev1.ConfigMap{}
+		Expect(yaml.Unmarshal(data, configMap)).To(Succeed())
+
+		rules, err := ParseCapabilityRules(configMap.Data[CapabilityRulesKey])
+		Expect(err).NotTo(HaveOccurred())
+
+		for _, node := range []*corev1.Node{
+			capabilityNode("arm", "Raspberry Pi OS", "5.10.0", "1", "1Gi"),
+			capabilityNode("arm64", "Debian GNU/Linux 12", "6.1.0", "8", "8Gi"),
+			capabilityNode("amd64", "Rocky Linux 9.3", "5.14.0", "16", "64Gi"),
+		} {
+			Expect(rules.Labels(node)).To(Equal(DefaultCapabilityRules.Labels(node)))
+		}
+	})
+})
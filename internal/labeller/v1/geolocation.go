@@ -0,0 +1,147 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package labeller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/savaki/geoip2"
+	corev1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// GeolocationLabeller labels the nodes with the geolocation of their IP addresses, looked up from MaxMind.
+type GeolocationLabeller struct {
+	// Nil if the MaxMind configuration is not given.
+	MaxMind MaxMind
+}
+
+// The name of the GeolocationLabeller, the reconciler tells its failures apart by it.
+const GeolocationLabellerName = "geolocation"
+
+var _ Labeller = &GeolocationLabeller{}
+
+func (g *GeolocationLabeller) Name() string {
+	return GeolocationLabellerName
+}
+
+func (g *GeolocationLabeller) Keys() []string {
+	return GeolocationLabelKeys
+}
+
+// The geolocation is looked up from the external IP address of the node, if the node doesn't have one the
+// internal IP address is used instead. If MaxMind doesn't know the addresses, for example they are private,
+// the node has no location and its geolocation labels are removed.
+// The labeller fails with ErrMaxMindUnavailable while there are no credentials, so the geolocation labels of the
+// node are kept until they can be looked up again.
+func (g *GeolocationLabeller) Labels(ctx context.Context, node *corev1.Node) (map[string]string, error) {
+	if !IsMaxMindAvailable(g.MaxMind) {
+		return nil, ErrMaxMindUnavailable
+	}
+
+	internalIP, externalIP := GetNodeIPAddresses(node)
+
+	var response *geoip2.Response
+	var err error
+
+	for _, address := range []string{externalIP, internalIP} {
+		if address == "" {
+			continue
+		}
+		if response, err = g.MaxMind.MaxMindLookup(address); err == nil {
+			break
+		}
+	}
+
+	if isUnknownAddress(err) {
+		return map[string]string{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if response == nil {
+		return nil, fmt.Errorf("node %s doesn't have an ip address", node.GetName())
+	}
+
+	return GeolocationLabels(response), nil
+}
+
+// Removes the failures of the geolocation labeller caused by the missing MaxMind credentials from the error of
+// the labelling. They are not retried, the nodes are labelled again once the credentials are loaded.
+func IgnoreMaxMindUnavailable(err error) error {
+	remaining := []error{}
+	for _, labellerErr := range LabellerErrors(err) {
+		if errors.Is(labellerErr.Err, ErrMaxMindUnavailable) {
+			continue
+		}
+		if labellerErr.Labeller == "" {
+			remaining = append(remaining, labellerErr.Err)
+			continue
+		}
+		remaining = append(remaining, labellerErr)
+	}
+	return utilerrors.NewAggregate(remaining)
+}
+
+// These are the errors returned by MaxMind for the addresses it cannot locate.
+var unknownAddressCodes = []string{"IP_ADDRESS_NOT_FOUND", "IP_ADDRESS_RESERVED", "IP_ADDRESS_INVALID"}
+
+// Checks if the lookup failed because the address cannot be located, rather than a failure of MaxMind.
+func isUnknownAddress(err error) bool {
+	var lookupErr geoip2.Error
+	if !errors.As(err, &lookupErr) {
+		return false
+	}
+	for _, code := range unknownAddressCodes {
+		if lookupErr.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Converts the geolocation response to the node labels. The fields that are not known are not included.
+func GeolocationLabels(response *geoip2.Response) map[string]string {
+	labels := map[string]string{}
+
+	if city := SanitizeLabelValue(response.City.Names["en"]); city != "" {
+		labels[CityLabel] = city
+	}
+	if len(response.Subdivisions) != 0 {
+		if state := SanitizeLabelValue(response.Subdivisions[0].IsoCode); state != "" {
+			labels[StateLabel] = state
+		}
+	}
+	if country := SanitizeLabelValue(response.Country.IsoCode); country != "" {
+		labels[CountryLabel] = country
+	}
+	if continent := SanitizeLabelValue(response.Continent.Names["en"]); continent != "" {
+		labels[ContinentLabel] = continent
+	}
+
+	// MaxMind returns 0,0 when the location is unknown.
+	if response.Location.Latitude != 0 || response.Location.Longitude != 0 {
+		labels[LatitudeLabel] = FormatLatitude(response.Location.Latitude)
+		labels[LongitudeLabel] = FormatLongitude(response.Location.Longitude)
+	}
+
+	return labels
+}
@@ -18,92 +18,313 @@ package labeller
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 
+	"github.com/edgenet-project/edgenet/internal/utils"
 	corev1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+const (
+	// Set to "true" on a node to only report its label changes, or to "false" to apply them even if the node
+	// labeller runs in the dry-run mode.
+	DryRunAnnotation = "edge-net.io/labeller-dry-run"
+
+	// The label changes that are not applied because of the dry-run mode, as a list of "<key>=<value>". The
+	// removed labels are written as "<key>-".
+	PendingLabelsAnnotation = "edge-net.io/pending-labels"
+
+	// The keys of the labels set by the node labeller. Only these labels are changed or removed by the
+	// labeller, the other labels of the node are never touched.
+	ManagedLabelsAnnotation = "edge-net.io/managed-labels"
+)
+
+// Labeller computes a set of labels for a node. Each labeller is a plugin of the node labeller, such as the
+// geolocation or the capabilities of the node.
+type Labeller interface {
+	// Name of the labeller, used in the logs and the errors.
+	Name() string
+
+	// The keys of all of the labels the labeller can set. If the labeller fails, the managed labels with these
+	// keys are kept as they are.
+	Keys() []string
+
+	// Returns the labels the node should have. The labels that cannot be computed are left out, they are
+	// removed from the node if they were set before.
+	Labels(context.Context, *corev1.Node) (map[string]string, error)
+}
+
+// LabellerError is the failure of a single labeller. The labels of the other labellers are still applied.
+type LabellerError struct {
+	Labeller string
+	Err      error
+}
+
+func (e *LabellerError) Error() string {
+	return fmt.Sprintf("%s labeller: %v", e.Labeller, e.Err)
+}
+
+func (e *LabellerError) Unwrap() error {
+	return e.Err
+}
+
+// Splits the error of the labelling into the failures of the individual labellers. An error that doesn't come from
+// a labeller, such as a failed update of the node, has no labeller name.
+func LabellerErrors(err error) []*LabellerError {
+	if err == nil {
+		return nil
+	}
+
+	errs := []error{err}
+	if aggregate, ok := err.(utilerrors.Aggregate); ok {
+		errs = aggregate.Errors()
+	}
+
+	labellerErrs := make([]*LabellerError, 0, len(errs))
+	for _, err := range errs {
+		labellerErr := &LabellerError{}
+		if !errors.As(err, &labellerErr) {
+			labellerErr = &LabellerError{Err: err}
+		}
+		labellerErrs = append(labellerErrs, labellerErr)
+	}
+
+	return labellerErrs
+}
+
 // This interface contains the necessary functions to perform the operations related to
 // labelling. Most of the implementation here is retrieved from the old implementation.
 // However, some of the functions are changed.
 type LabelManager interface {
-	// This adds the labes to the node object given
-	LabelNode(context.Context, *corev1.Node) error
+	// Computes the labels of the node from all of the labellers.
+	PlanNodeLabels(context.Context, *corev1.Node) (LabelPlan, error)
+
+	// This adds the labes to the node object given. Returns the label changes applied to the node.
+	LabelNode(context.Context, *corev1.Node) ([]LabelChange, error)
+
+	// Computes the label changes of the node without applying them, they are recorded in the pending labels
+	// annotation instead. Returns the changes and whether the annotation is updated.
+	ReportNodeLabels(context.Context, *corev1.Node) ([]LabelChange, bool, error)
+}
+
+// LabelPlan is the state of the labels of a node after the labelling.
+type LabelPlan struct {
+	// All of the labels of the node, including the ones not managed by the labeller.
+	Labels map[string]string
 
-	// Gets the internal and external ip address
-	GetNodeIPAddresses(obj *corev1.Node) (string, string)
+	// The sorted keys of the managed labels.
+	Managed []string
+}
+
+// LabelChange is a label the labellers would change on a node. The old value is empty if the node doesn't have
+// the label and the new value is empty if the label is removed.
+type LabelChange struct {
+	Key string
+	Old string
+	New string
+}
+
+func (c LabelChange) String() string {
+	switch {
+	case c.Old == "":
+		return fmt.Sprintf("%s: %s", c.Key, c.New)
+	case c.New == "":
+		return fmt.Sprintf("%s: %s -> (removed)", c.Key, c.Old)
+	default:
+		return fmt.Sprintf("%s: %s -> %s", c.Key, c.Old, c.New)
+	}
 }
 
 type labelManager struct {
 	LabelManager
-	client  client.Client
-	Maxmind MaxMind
+	client    client.Client
+	labellers []Labeller
 }
 
-func NewLabelManager(ctx context.Context, client client.Client, maxmind MaxMind) (LabelManager, error) {
+func NewLabelManager(ctx context.Context, client client.Client, labellers ...Labeller) (LabelManager, error) {
 	return &labelManager{
-		client:  client,
-		Maxmind: maxmind,
+		client:    client,
+		labellers: labellers,
 	}, nil
 }
 
-// This adds the labels to the node and updates it. If any error occures it returnes the error.
-func (m *labelManager) LabelNode(ctx context.Context, node *corev1.Node) error {
-	fmt.Println("Node labeller implementation required...")
-
-	// TODO: Get this part of the code from old repo
-	// internalIp, externalIp := m.GetNodeIPAddresses(node)
-
-	// Old code...
-	// // 1. Use the VPNPeer endpoint address if available.
-	// peer, err := c.edgenetclientset.NetworkingV1alpha1().VPNPeers().Get(context.TODO(), nodeObj.Name, v1.GetOptions{})
-	// if err != nil {
-	// 	klog.V(4).Infof(
-	// 		"Failed to find a matching VPNPeer object for %s: %s. The node IP will be used instead.",
-	// 		nodeObj.Name,
-	// 		err,
-	// 	)
-	// } else {
-	// 	klog.V(4).Infof("VPNPeer endpoint IP: %s", *peer.Spec.EndpointAddress)
-	// 	result = multiproviderManager.GetGeolocationByIP(
-	// 		c.maxmindURL,
-	// 		c.maxmindAccountID,
-	// 		c.maxmindLicenseKey,
-	// 		nodeObj.Name,
-	// 		*peer.Spec.EndpointAddress,
-	// 	)
-	// }
-
-	// // 2. Otherwise use the node external IP if available.
-	// if externalIP != "" && !result {
-	// 	klog.V(4).Infof("External IP: %s", externalIP)
-	// 	result = multiproviderManager.GetGeolocationByIP(
-	// 		c.maxmindURL,
-	// 		c.maxmindAccountID,
-	// 		c.maxmindLicenseKey,
-	// 		nodeObj.Name,
-	// 		externalIP,
-	// 	)
-	// }
-
-	// // 3. Otherwise use the node internal IP if available.
-	// if internalIP != "" && !result {
-	// 	klog.V(4).Infof("Internal IP: %s", internalIP)
-	// 	multiproviderManager.GetGeolocationByIP(
-	// 		c.maxmindURL,
-	// 		c.maxmindAccountID,
-	// 		c.maxmindLicenseKey,
-	// 		nodeObj.Name,
-	// 		internalIP,
-	// 	)
-	// }
-
-	return nil
+// The labellers are independent, if one of them fails the labels of the others are still planned along with
+// the error. The managed labels that are not produced anymore are removed. A label the labeller doesn't manage
+// is only adopted if it already has the desired value, otherwise it is left as it is.
+func (m *labelManager) PlanNodeLabels(ctx context.Context, node *corev1.Node) (LabelPlan, error) {
+	current := node.GetLabels()
+	managed := ParseManagedLabels(node.GetAnnotations()[ManagedLabelsAnnotation])
+
+	desired := map[string]string{}
+	errs := []error{}
+
+	for _, plugin := range m.labellers {
+		pluginLabels, err := plugin.Labels(ctx, node)
+		if err != nil {
+			errs = append(errs, &LabellerError{Labeller: plugin.Name(), Err: err})
+
+			// Keep the labels of the failed labeller, they cannot be computed now.
+			for _, key := range plugin.Keys() {
+				if value, ok := current[key]; ok && managed[key] {
+					desired[key] = value
+				}
+			}
+			continue
+		}
+		desired = utils.MergeLabels(desired, pluginLabels)
+	}
+
+	labels := utils.MergeLabels(current, nil)
+	for key := range managed {
+		if _, ok := desired[key]; !ok {
+			delete(labels, key)
+		}
+	}
+
+	plan := LabelPlan{Labels: labels, Managed: []string{}}
+	for key, value := range desired {
+		if old, ok := current[key]; ok && !managed[key] && old != value {
+			continue
+		}
+		labels[key] = value
+		plan.Managed = append(plan.Managed, key)
+	}
+	sort.Strings(plan.Managed)
+
+	return plan, utilerrors.NewAggregate(errs)
+}
+
+// This replaces the managed labels of the node with a single patch. The patch fails if the node is changed in
+// the meantime, so the labels and the managed labels annotation are always consistent. The labels computed by
+// the labellers that succeeded are applied even if another labeller fails, the error is returned afterwards.
+func (m *labelManager) LabelNode(ctx context.Context, node *corev1.Node) ([]LabelChange, error) {
+	plan, labelErr := m.PlanNodeLabels(ctx, node)
+
+	annotations := utils.MergeLabels(node.GetAnnotations(), nil)
+
+	// The changes are applied, they are not pending anymore.
+	delete(annotations, PendingLabelsAnnotation)
+
+	if len(plan.Managed) == 0 {
+		delete(annotations, ManagedLabelsAnnotation)
+	} else {
+		annotations[ManagedLabelsAnnotation] = strings.Join(plan.Managed, ",")
+	}
+
+	changes := DiffLabels(node.GetLabels(), plan.Labels)
+
+	// Only update the node if something is changed.
+	if reflect.DeepEqual(annotations, utils.MergeLabels(node.GetAnnotations(), nil)) && len(changes) == 0 {
+		return nil, labelErr
+	}
+
+	original := node.DeepCopy()
+	node.SetLabels(plan.Labels)
+	node.SetAnnotations(annotations)
+
+	if err := m.client.Patch(ctx, node, client.MergeFromWithOptions(original, client.MergeFromWithOptimisticLock{})); err != nil {
+		return nil, err
+	}
+
+	return changes, labelErr
+}
+
+// The node is only patched if the pending changes are different than the ones in the annotation.
+func (m *labelManager) ReportNodeLabels(ctx context.Context, node *corev1.Node) ([]LabelChange, bool, error) {
+	plan, labelErr := m.PlanNodeLabels(ctx, node)
+
+	changes := DiffLabels(node.GetLabels(), plan.Labels)
+	pending := FormatLabelChanges(changes)
+
+	annotations := node.GetAnnotations()
+	if current, ok := annotations[PendingLabelsAnnotation]; (ok && current == pending) || (!ok && len(changes) == 0) {
+		return changes, false, labelErr
+	}
+
+	original := node.DeepCopy()
+	annotations = utils.MergeLabels(annotations, nil)
+	if len(changes) == 0 {
+		delete(annotations, PendingLabelsAnnotation)
+	} else {
+		annotations[PendingLabelsAnnotation] = pending
+	}
+	node.SetAnnotations(annotations)
+
+	if err := m.client.Patch(ctx, node, client.MergeFromWithOptions(original, client.MergeFromWithOptimisticLock{})); err != nil {
+		return nil, false, err
+	}
+
+	return changes, true, labelErr
+}
+
+// Returns the labels that are added, changed or removed between the current and the desired labels, sorted by
+// the key.
+func DiffLabels(current, desired map[string]string) []LabelChange {
+	changes := []LabelChange{}
+
+	for key, value := range desired {
+		if old := current[key]; old != value {
+			changes = append(changes, LabelChange{Key: key, Old: old, New: value})
+		}
+	}
+
+	for key, old := range current {
+		if _, ok := desired[key]; !ok {
+			changes = append(changes, LabelChange{Key: key, Old: old})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+
+	return changes
+}
+
+// Formats the changes as the value of the pending labels annotation.
+func FormatLabelChanges(changes []LabelChange) string {
+	entries := make([]string, 0, len(changes))
+	for _, change := range changes {
+		if change.New == "" {
+			entries = append(entries, fmt.Sprintf("%s-", change.Key))
+			continue
+		}
+		entries = append(entries, fmt.Sprintf("%s=%s", change.Key, change.New))
+	}
+	return strings.Join(entries, ",")
+}
+
+// Parses the managed labels annotation.
+func ParseManagedLabels(value string) map[string]bool {
+	managed := map[string]bool{}
+	for _, key := range strings.Split(value, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			managed[key] = true
+		}
+	}
+	return managed
+}
+
+// Checks if the label changes of the node should only be reported. The annotation of the node overrides the
+// mode of the labeller.
+func IsDryRun(node *corev1.Node, dryRun bool) bool {
+	switch strings.ToLower(node.GetAnnotations()[DryRunAnnotation]) {
+	case "true":
+		return true
+	case "false":
+		return false
+	default:
+		return dryRun
+	}
 }
 
 // GetNodeIPAddresses picks up the internal and external IP addresses of the Node
-func (m *labelManager) GetNodeIPAddresses(obj *corev1.Node) (string, string) {
+func GetNodeIPAddresses(obj *corev1.Node) (string, string) {
 	internalIP := ""
 	externalIP := ""
 	for _, addressesRow := range obj.Status.Addresses {
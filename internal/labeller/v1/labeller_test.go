@@ -0,0 +1,152 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package labeller
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// A labeller returning fixed labels, or an error if err is set.
+type staticLabeller struct {
+	keys   []string
+	labels map[string]string
+	err    error
+}
+
+func (s *staticLabeller) Name() string {
+	return "static"
+}
+
+func (s *staticLabeller) Keys() []string {
+	return s.keys
+}
+
+func (s *staticLabeller) Labels(ctx context.Context, node *corev1.Node) (map[string]string, error) {
+	return s.labels, s.err
+}
+
+var _ = Describe("LabelManager", func() {
+	ctx := context.Background()
+
+	node := func(labels map[string]string, managed string) *corev1.Node {
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+			Labels:      labels,
+			Annotations: map[string]string{ManagedLabelsAnnotation: managed},
+		}}
+	}
+
+	It("should remove the managed labels that are not produced anymore", func() {
+		manager, err := NewLabelManager(ctx, nil, &staticLabeller{
+			keys:   GeolocationLabelKeys,
+			labels: map[string]string{CountryLabel: "FR"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		plan, err := manager.PlanNodeLabels(ctx, node(map[string]string{
+			CityLabel:                "Paris",
+			CountryLabel:             "FR",
+			"team":                   "edge",
+			"kubernetes.io/hostname": "node-1",
+		}, CityLabel+","+CountryLabel))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Labels).To(Equal(map[string]string{
+			CountryLabel:             "FR",
+			"team":                   "edge",
+			"kubernetes.io/hostname": "node-1",
+		}))
+		Expect(plan.Managed).To(Equal([]string{CountryLabel}))
+	})
+
+	It("should not touch the labels it doesn't manage", func() {
+		manager, _ := NewLabelManager(ctx, nil, &staticLabeller{
+			keys:   GeolocationLabelKeys,
+			labels: map[string]string{CityLabel: "Lyon", CountryLabel: "FR"},
+		})
+
+		plan, err := manager.PlanNodeLabels(ctx, node(map[string]string{CityLabel: "Paris", CountryLabel: "FR"}, ""))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(plan.Labels).To(HaveKeyWithValue(CityLabel, "Paris"))
+		Expect(plan.Managed).To(Equal([]string{CountryLabel}))
+	})
+
+	It("should keep the labels of a failed labeller", func() {
+		manager, _ := NewLabelManager(ctx, nil,
+			&staticLabeller{keys: GeolocationLabelKeys, err: errors.New("lookup failed")},
+			&staticLabeller{keys: CapabilityLabelKeys, labels: map[string]string{ArchLabel: "arm64"}},
+		)
+
+		plan, err := manager.PlanNodeLabels(ctx, node(map[string]string{CityLabel: "Paris", ArchLabel: "amd64"}, CityLabel+","+ArchLabel))
+		Expect(err).To(HaveOccurred())
+		Expect(LabellerErrors(err)).To(ConsistOf(HaveField("Err", MatchError("lookup failed"))))
+		Expect(plan.Labels).To(Equal(map[string]string{CityLabel: "Paris", ArchLabel: "arm64"}))
+		Expect(plan.Managed).To(Equal([]string{ArchLabel, CityLabel}))
+	})
+
+	It("should keep the geolocation labels while MaxMind is not available", func() {
+		watcher := NewMaxMindWatcher("https://geoip.maxmind.com/geoip/v2.1/city/", GinkgoT().TempDir(), time.Minute)
+		manager, _ := NewLabelManager(ctx, nil,
+			&GeolocationLabeller{MaxMind: watcher},
+			&staticLabeller{keys: CapabilityLabelKeys, labels: map[string]string{ArchLabel: "arm64"}},
+		)
+
+		plan, err := manager.PlanNodeLabels(ctx, node(map[string]string{CityLabel: "Paris", CountryLabel: "FR"}, CityLabel+","+CountryLabel))
+		Expect(err).To(MatchError(ErrMaxMindUnavailable))
+		Expect(IgnoreMaxMindUnavailable(err)).To(Succeed())
+		Expect(plan.Labels).To(Equal(map[string]string{CityLabel: "Paris", CountryLabel: "FR", ArchLabel: "arm64"}))
+		Expect(plan.Managed).To(Equal([]string{ArchLabel, CityLabel, CountryLabel}))
+
+		manager, _ = NewLabelManager(ctx, nil, &GeolocationLabeller{})
+		plan, err = manager.PlanNodeLabels(ctx, node(map[string]string{CityLabel: "Paris"}, CityLabel))
+		Expect(IgnoreMaxMindUnavailable(err)).To(Succeed())
+		Expect(plan.Labels).To(HaveKeyWithValue(CityLabel, "Paris"))
+	})
+
+	It("should only report the changed labels", func() {
+		changes := DiffLabels(
+			map[string]string{CityLabel: "Paris", CountryLabel: "FR"},
+			map[string]string{CityLabel: "Lyon", CountryLabel: "FR", ArchLabel: "arm64"},
+		)
+		Expect(changes).To(Equal([]LabelChange{
+			{Key: ArchLabel, New: "arm64"},
+			{Key: CityLabel, Old: "Paris", New: "Lyon"},
+		}))
+		Expect(FormatLabelChanges(changes)).To(Equal("edge-net.io/arch=arm64,edge-net.io/city=Lyon"))
+		Expect(changes[1].String()).To(Equal("edge-net.io/city: Paris -> Lyon"))
+
+		removed := DiffLabels(map[string]string{CityLabel: "Paris"}, map[string]string{})
+		Expect(FormatLabelChanges(removed)).To(Equal("edge-net.io/city-"))
+		Expect(removed[0].String()).To(Equal("edge-net.io/city: Paris -> (removed)"))
+	})
+
+	It("should let the nodes override the dry-run mode", func() {
+		node := &corev1.Node{}
+		Expect(IsDryRun(node, true)).To(BeTrue())
+
+		node.ObjectMeta = metav1.ObjectMeta{Annotations: map[string]string{DryRunAnnotation: "false"}}
+		Expect(IsDryRun(node, true)).To(BeFalse())
+
+		node.ObjectMeta = metav1.ObjectMeta{Annotations: map[string]string{DryRunAnnotation: "true"}}
+		Expect(IsDryRun(node, false)).To(BeTrue())
+	})
+})
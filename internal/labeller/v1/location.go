@@ -0,0 +1,129 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package labeller
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// These are the labels set on the nodes from the geolocation of their IP addresses. The same labels are used by
+// the old EdgeNet so the existing selectors keep working.
+const (
+	CityLabel      = "edge-net.io/city"
+	StateLabel     = "edge-net.io/state-iso"
+	CountryLabel   = "edge-net.io/country-iso"
+	ContinentLabel = "edge-net.io/continent"
+	LatitudeLabel  = "edge-net.io/lat"
+	LongitudeLabel = "edge-net.io/lon"
+)
+
+// GeolocationLabelKeys are all of the labels set from the geolocation.
+var GeolocationLabelKeys = []string{
+	CityLabel,
+	StateLabel,
+	CountryLabel,
+	ContinentLabel,
+	LatitudeLabel,
+	LongitudeLabel,
+}
+
+// Label values only allow alphanumeric characters, '-', '_' and '.' and should start and end with an alphanumeric.
+var invalidLabelCharacters = regexp.MustCompile(`[^-A-Za-z0-9_.]`)
+
+// Makes the value usable as a label value, the spaces are replaced with underscores. For example,
+// "North America" becomes "North_America".
+func SanitizeLabelValue(value string) string {
+	value = strings.ReplaceAll(strings.TrimSpace(value), " ", "_")
+	value = invalidLabelCharacters.ReplaceAllString(value, "")
+	value = strings.Trim(value, "-_.")
+
+	if len(value) > 63 {
+		value = strings.Trim(value[:63], "-_.")
+	}
+
+	return value
+}
+
+// Label values cannot start with '-', the hemisphere is written as a prefix instead. For example, 48.85 becomes
+// "n48.850000" and -33.86 becomes "s33.860000".
+func FormatLatitude(latitude float64) string {
+	if latitude < 0 {
+		return fmt.Sprintf("s%.6f", -latitude)
+	}
+	return fmt.Sprintf("n%.6f", latitude)
+}
+
+// Same as FormatLatitude, the prefix is "e" for the east and "w" for the west.
+func FormatLongitude(longitude float64) string {
+	if longitude < 0 {
+		return fmt.Sprintf("w%.6f", -longitude)
+	}
+	return fmt.Sprintf("e%.6f", longitude)
+}
+
+// Describes the location of the node from its labels, for example "Paris, FR". Returns an empty string if the node
+// doesn't have a geolocation.
+func FormatLocation(labels map[string]string) string {
+	city, country := labels[CityLabel], labels[CountryLabel]
+	switch {
+	case city != "" && country != "":
+		return fmt.Sprintf("%s, %s", strings.ReplaceAll(city, "_", " "), country)
+	case country != "":
+		return country
+	default:
+		return strings.ReplaceAll(city, "_", " ")
+	}
+}
+
+// Reads the coordinates of the node from its labels. Returns false if the node doesn't have valid coordinates.
+func ParseCoordinates(labels map[string]string) (float64, float64, bool) {
+	latitude, err := parseCoordinate(labels[LatitudeLabel], 'n', 's')
+	if err != nil {
+		return 0, 0, false
+	}
+
+	longitude, err := parseCoordinate(labels[LongitudeLabel], 'e', 'w')
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return latitude, longitude, true
+}
+
+// Parses a coordinate formatted by FormatLatitude or FormatLongitude.
+func parseCoordinate(value string, positive, negative byte) (float64, error) {
+	if len(value) < 2 {
+		return 0, fmt.Errorf("invalid coordinate %q", value)
+	}
+
+	coordinate, err := strconv.ParseFloat(value[1:], 64)
+	if err != nil {
+		return 0, err
+	}
+
+	switch value[0] {
+	case positive:
+		return coordinate, nil
+	case negative:
+		return -coordinate, nil
+	default:
+		return 0, fmt.Errorf("invalid coordinate %q", value)
+	}
+}
@@ -4,12 +4,26 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
+	"sync"
 
 	"github.com/savaki/geoip2"
+	corev1 "k8s.io/api/core/v1"
 )
 
+// These are the keys of the MaxMind Secret, also the names of the files it is mounted as. The values are base64
+// encoded.
+const (
+	MaxMindAccountIdKey = "maxmind_accountid"
+	MaxMindTokenKey     = "maxmind_token"
+)
+
+// ErrMaxMindUnauthorized is returned by the lookups rejected by MaxMind because of invalid credentials.
+var ErrMaxMindUnauthorized = errors.New("the maxmind credentials are rejected")
+
 type MaxMind interface {
 	MaxMindLookup(address string) (*geoip2.Response, error)
 }
@@ -19,44 +33,69 @@ type maxMind struct {
 	url       string
 	accountId string
 	key       string
+
+	// Shared by the copies of the maxMind, they use the same credentials.
+	rejection *rejection
+}
+
+// rejection holds the error of the last lookup rejected by MaxMind, until a lookup succeeds.
+type rejection struct {
+	mu  sync.RWMutex
+	err error
 }
 
+func newMaxMind(url, accountId, key string) maxMind {
+	return maxMind{
+		url:       url,
+		accountId: accountId,
+		key:       key,
+		rejection: &rejection{},
+	}
+}
+
+// The directory the MaxMind Secret is mounted at.
+const MaxMindSecretDir = "/var/run/secrets/edge-net.io/maxmind-secret"
+
 // Read the content from the secret directory. URL is given as a non empty string. Others can be.
 // 1. First try to create the maxmind struct from command line arguments.
 // 2. If they are not specified, look for the environment variables.
 // 3. If they are also not specified, check the secrets mounted.
 func NewMaxMindFromSecret(url, accountId, key string) (MaxMind, error) {
+	if maxMind := NewMaxMindFromArgs(url, accountId, key); maxMind != nil {
+		return maxMind, nil
+	}
+
+	// Last resort, get them from the secret mounted by kubernetes.
+	return NewMaxMindFromDir(url, MaxMindSecretDir)
+}
+
+// Same as the NewMaxMindFromSecret except the mounted secret is not read. Returns nil if neither the command line
+// arguments nor the environment variables give the credentials.
+func NewMaxMindFromArgs(url, accountId, key string) MaxMind {
 	// Try to build from command line arguments
 	if url != "" && accountId != "" && key != "" {
-		return maxMind{
-			url:       url,
-			accountId: accountId,
-			key:       key,
-		}, nil
+		return newMaxMind(url, accountId, key)
 	}
 
 	// Get them from environment variables.
 	accountId, key = os.Getenv("MAXMIND_ACCOUNTID"), os.Getenv("MAXMIND_KEY")
 	if url != "" && accountId != "" && key != "" {
-		return maxMind{
-			url:       url,
-			accountId: accountId,
-			key:       key,
-		}, nil
+		return newMaxMind(url, accountId, key)
 	}
 
-	// Get them from secrets mounted by kubernetes.
-	accountIdPath := "/var/run/secrets/edge-net.io/maxmind-secret/maxmind_accountid"
-	keyPath := "/var/run/secrets/edge-net.io/maxmind-secret/maxmind_token"
+	return nil
+}
 
-	// Last resort, return error if there is one.
-	accountIdBytes, err := os.ReadFile(accountIdPath)
+// Reads the credentials from the files of the secret mounted in the directory.
+func NewMaxMindFromDir(url, dir string) (MaxMind, error) {
+	// Return error if there is one.
+	accountIdBytes, err := os.ReadFile(filepath.Join(dir, MaxMindAccountIdKey))
 	if err != nil {
 		return nil, err
 	}
 
-	// Last resort, return error if there is one.
-	keyBytes, err := os.ReadFile(keyPath)
+	// Return error if there is one.
+	keyBytes, err := os.ReadFile(filepath.Join(dir, MaxMindTokenKey))
 	if err != nil {
 		return nil, err
 	}
@@ -73,22 +112,38 @@ func NewMaxMindFromSecret(url, accountId, key string) (MaxMind, error) {
 		return nil, err
 	}
 
-	accountId = string(accountIdBytes)
-	key = string(keyBytes)
+	accountId := string(accountIdBytes)
+	key := string(keyBytes)
 
-	// Try to build from command line arguments
 	if url != "" && accountId != "" && key != "" {
-		return maxMind{
-			url:       url,
-			accountId: accountId,
-			key:       key,
-		}, nil
+		return newMaxMind(url, accountId, key), nil
 	}
 
 	return nil, errors.New("cannot read the maxmind secrets")
 }
 
-// This is for performing a lookup on the IP Address
+// Same as the NewMaxMindFromSecret except the account id and the token are read from the Secret object, for
+// example referenced by the EdgeNetConfig.
+func NewMaxMindFromSecretObject(url string, secret *corev1.Secret) (MaxMind, error) {
+	accountId, err := base64.StdEncoding.DecodeString(string(secret.Data[MaxMindAccountIdKey]))
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := base64.StdEncoding.DecodeString(string(secret.Data[MaxMindTokenKey]))
+	if err != nil {
+		return nil, err
+	}
+
+	if url == "" || len(accountId) == 0 || len(key) == 0 {
+		return nil, fmt.Errorf("the secret %s/%s doesn't have the %q and %q keys", secret.GetNamespace(), secret.GetName(), MaxMindAccountIdKey, MaxMindTokenKey)
+	}
+
+	return newMaxMind(url, string(accountId), string(key)), nil
+}
+
+// This is for performing a lookup on the IP Address. If MaxMind rejects the credentials the error wraps
+// ErrMaxMindUnauthorized, it is reported by CredentialsError until a lookup succeeds.
 func (mm maxMind) MaxMindLookup(address string) (*geoip2.Response, error) {
 	req, err := http.NewRequest("GET", mm.url+address, nil)
 	if err != nil {
@@ -100,6 +155,16 @@ func (mm maxMind) MaxMindLookup(address string) (*geoip2.Response, error) {
 		return nil, err
 	}
 	defer res.Body.Close()
+	if res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden {
+		err := fmt.Errorf("%w: %s", ErrMaxMindUnauthorized, res.Status)
+		v := geoip2.Error{}
+		if json.NewDecoder(res.Body).Decode(&v) == nil {
+			err = fmt.Errorf("%w: %w", ErrMaxMindUnauthorized, v)
+		}
+		mm.setRejection(err)
+		return nil, err
+	}
+	mm.setRejection(nil)
 	if res.StatusCode >= 400 {
 		v := geoip2.Error{}
 		err := json.NewDecoder(res.Body).Decode(&v)
@@ -112,3 +177,45 @@ func (mm maxMind) MaxMindLookup(address string) (*geoip2.Response, error) {
 	err = json.NewDecoder(res.Body).Decode(response)
 	return response, err
 }
+
+// Returns the error of the last lookup if MaxMind rejected the credentials.
+func (mm maxMind) CredentialsError() error {
+	if mm.rejection == nil {
+		return nil
+	}
+	mm.rejection.mu.RLock()
+	defer mm.rejection.mu.RUnlock()
+	return mm.rejection.err
+}
+
+func (mm maxMind) setRejection(err error) {
+	if mm.rejection == nil {
+		return
+	}
+	mm.rejection.mu.Lock()
+	defer mm.rejection.mu.Unlock()
+	mm.rejection.err = err
+}
+
+// Checks if both of the MaxMinds use the same credentials.
+func (mm maxMind) sameCredentials(other MaxMind) bool {
+	o, ok := other.(maxMind)
+	return ok && mm.url == o.url && mm.accountId == o.accountId && mm.key == o.key
+}
+
+// Checks if both of the MaxMinds make the lookups with the same credentials.
+func SameMaxMind(a, b MaxMind) bool {
+	if mm, ok := a.(maxMind); ok {
+		return mm.sameCredentials(b)
+	}
+	return a == b
+}
+
+// Returns the error of the last lookup if MaxMind rejected the credentials, for example they are revoked or the
+// account is expired.
+func MaxMindCredentialsError(maxMind MaxMind) error {
+	if checker, ok := maxMind.(interface{ CredentialsError() error }); ok {
+		return checker.CredentialsError()
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package labeller
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	nodeUptimeRatio = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "edgenet_node_uptime_ratio",
+			Help: "Ratio of the time the node was ready over the reliability window.",
+		},
+		[]string{"node"},
+	)
+	nodeReadyFlaps = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "edgenet_node_ready_flaps",
+			Help: "Number of times the node became not ready over the reliability window.",
+		},
+		[]string{"node"},
+	)
+	nodeReliabilityTier = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "edgenet_node_reliability_tier",
+			Help: "Reliability tier of the node, the gauge of the current tier is 1 and the others are 0.",
+		},
+		[]string{"node", "tier"},
+	)
+)
+
+var (
+	pendingLabelChanges = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "edgenet_labeller_pending_label_changes",
+			Help: "Number of label changes not applied because of the dry-run mode, over all of the nodes.",
+		},
+	)
+	nodesWithPendingLabelChanges = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "edgenet_labeller_nodes_with_pending_changes",
+			Help: "Number of nodes having label changes not applied because of the dry-run mode.",
+		},
+	)
+
+	// The pending changes of each node, the gauges are the summary of them.
+	pendingChangesLock sync.Mutex
+	pendingChanges     = map[string]int{}
+)
+
+var labelledNodesDesc = prometheus.NewDesc(
+	"edgenet_labelled_nodes",
+	"Number of nodes labelled with their location, by their country and continent.",
+	[]string{"country", "continent"}, nil,
+)
+
+// The nodes are read from the cache, the scrape shouldn't wait longer than this.
+const collectTimeout = 10 * time.Second
+
+func init() {
+	// Register the metrics with the registry of the controller-runtime, they are served on the metrics endpoint
+	// of the manager.
+	metrics.Registry.MustRegister(nodeUptimeRatio, nodeReadyFlaps, nodeReliabilityTier)
+	metrics.Registry.MustRegister(pendingLabelChanges, nodesWithPendingLabelChanges)
+}
+
+// Exports the score of the node as metrics.
+func RecordReliabilityMetrics(node string, score ReliabilityScore) {
+	nodeUptimeRatio.WithLabelValues(node).Set(score.Uptime)
+	nodeReadyFlaps.WithLabelValues(node).Set(float64(score.Flaps))
+
+	tier := score.Tier()
+	for _, t := range []string{ReliabilityHigh, ReliabilityMedium, ReliabilityLow} {
+		value := 0.0
+		if t == tier {
+			value = 1
+		}
+		nodeReliabilityTier.WithLabelValues(node, t).Set(value)
+	}
+}
+
+// Removes the metrics of a deleted node.
+func DeleteReliabilityMetrics(node string) {
+	nodeUptimeRatio.DeleteLabelValues(node)
+	nodeReadyFlaps.DeleteLabelValues(node)
+	nodeReliabilityTier.DeletePartialMatch(prometheus.Labels{"node": node})
+}
+
+// Records the number of pending label changes of the node and updates the cluster-wide summary. Zero removes
+// the node from the summary.
+func RecordPendingLabelChanges(node string, count int) {
+	pendingChangesLock.Lock()
+	defer pendingChangesLock.Unlock()
+
+	if count == 0 {
+		delete(pendingChanges, node)
+	} else {
+		pendingChanges[node] = count
+	}
+
+	total := 0
+	for _, c := range pendingChanges {
+		total += c
+	}
+
+	pendingLabelChanges.Set(float64(total))
+	nodesWithPendingLabelChanges.Set(float64(len(pendingChanges)))
+}
+
+// LocationCollector exports the number of nodes in each location from the geolocation labels. The nodes are read
+// when the metrics are scraped, the nodes without any location label are not counted.
+type LocationCollector struct {
+	reader client.Reader
+}
+
+var _ prometheus.Collector = &LocationCollector{}
+
+// Creates a collector reading from the given reader, which should be the cached client of the manager.
+func NewLocationCollector(reader client.Reader) *LocationCollector {
+	return &LocationCollector{reader: reader}
+}
+
+func (c *LocationCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- labelledNodesDesc
+}
+
+func (c *LocationCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	nodeList := &corev1.NodeList{}
+	if err := c.reader.List(ctx, nodeList); err != nil {
+		ch <- prometheus.NewInvalidMetric(labelledNodesDesc, err)
+		return
+	}
+
+	type location struct {
+		country, continent string
+	}
+	locations := map[location]int{}
+	for _, node := range nodeList.Items {
+		labels := node.GetLabels()
+		l := location{country: labels[CountryLabel], continent: labels[ContinentLabel]}
+		if l.country == "" && l.continent == "" {
+			continue
+		}
+		locations[l]++
+	}
+
+	for l, count := range locations {
+		ch <- prometheus.MustNewConstMetric(labelledNodesDesc, prometheus.GaugeValue, float64(count), l.country, l.continent)
+	}
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package labeller
+
+import (
+	"context"
+
+	infrastructurev1 "github.com/edgenet-project/edgenet/api/infrastructure/v1"
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The taint set on the nodes of the tenants that don't share their nodes. The value is the name of the tenant.
+const OwnerTaintKey = infrastructurev1.OwnerTenantLabel
+
+// This interface contains the necessary functions to keep the ownership of the nodes up to date.
+type OwnershipManager interface {
+	// Returns the tenant owning the node and the contributor of the node. Both are empty if the node
+	// doesn't belong to a tenant.
+	ResolveNodeOwner(context.Context, *corev1.Node) (string, string, error)
+
+	// Sets the ownership labels and the owner taint of the node. They are removed if the node doesn't
+	// belong to a tenant anymore.
+	LabelNodeOwner(context.Context, *corev1.Node) error
+}
+
+type ownershipManager struct {
+	OwnershipManager
+	client client.Client
+}
+
+func NewOwnershipManager(ctx context.Context, client client.Client) (OwnershipManager, error) {
+	return &ownershipManager{
+		client: client,
+	}, nil
+}
+
+// The node contribution the node joined with takes precedence over the annotations, the annotations are only
+// used for the nodes added without a contribution.
+func (m *ownershipManager) ResolveNodeOwner(ctx context.Context, node *corev1.Node) (string, string, error) {
+	if name, ok := node.GetLabels()[infrastructurev1.ContributionLabel]; ok {
+		nc := &infrastructurev1.NodeContribution{}
+		err := m.client.Get(ctx, types.NamespacedName{Name: name}, nc)
+
+		if err == nil && nc.GetDeletionTimestamp().IsZero() {
+			contributor := nc.Spec.Contributor
+			if contributor == "" {
+				contributor = nc.Spec.Tenant
+			}
+			return nc.Spec.Tenant, SanitizeLabelValue(contributor), nil
+		} else if err != nil && !errors.IsNotFound(err) {
+			return "", "", err
+		}
+	}
+
+	tenant := node.GetAnnotations()[infrastructurev1.OwnerTenantAnnotation]
+	if tenant == "" {
+		return "", "", nil
+	}
+
+	contributor := node.GetAnnotations()[infrastructurev1.ContributorAnnotation]
+	if contributor == "" {
+		contributor = tenant
+	}
+
+	return tenant, SanitizeLabelValue(contributor), nil
+}
+
+// The taint depends on the node scheduling policy of the owner tenant. The node is only updated if something
+// is changed.
+func (m *ownershipManager) LabelNodeOwner(ctx context.Context, node *corev1.Node) error {
+	tenantName, contributor, err := m.ResolveNodeOwner(ctx, node)
+
+	if err != nil {
+		return err
+	}
+
+	policy := multitenancyv1.NodeSchedulingShared
+
+	if tenantName != "" {
+		tenant := &multitenancyv1.Tenant{}
+		if err := m.client.Get(ctx, types.NamespacedName{Name: tenantName}, tenant); err == nil {
+			policy = tenant.Spec.NodeScheduling
+		} else if !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	original := node.DeepCopy()
+	changed := false
+	labels := node.GetLabels()
+
+	if tenantName == "" {
+		for _, key := range []string{infrastructurev1.OwnerTenantLabel, infrastructurev1.ContributorLabel} {
+			if _, ok := labels[key]; ok {
+				delete(labels, key)
+				changed = true
+			}
+		}
+	} else {
+		expected := map[string]string{
+			infrastructurev1.OwnerTenantLabel: tenantName,
+			infrastructurev1.ContributorLabel: contributor,
+		}
+		if !utils.ContainsLabels(labels, expected) {
+			labels = utils.MergeLabels(labels, expected)
+			changed = true
+		}
+	}
+
+	taints := []corev1.Taint{}
+	for _, taint := range node.Spec.Taints {
+		if taint.Key != OwnerTaintKey {
+			taints = append(taints, taint)
+		}
+	}
+
+	if taint := OwnerTaint(tenantName, policy); taint != nil {
+		taints = append(taints, *taint)
+	}
+
+	if !equalTaints(node.Spec.Taints, taints) {
+		node.Spec.Taints = taints
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+
+	node.SetLabels(labels)
+
+	// Only the labels and the taints are patched, so the writes of the kubelet don't conflict with them.
+	return m.client.Patch(ctx, node, client.MergeFrom(original))
+}
+
+// Returns the taint set on the nodes of the tenant, nil if the tenant shares its nodes.
+func OwnerTaint(tenant string, policy multitenancyv1.NodeSchedulingPolicy) *corev1.Taint {
+	if tenant == "" {
+		return nil
+	}
+
+	switch policy {
+	case multitenancyv1.NodeSchedulingPriority:
+		return &corev1.Taint{Key: OwnerTaintKey, Value: tenant, Effect: corev1.TaintEffectPreferNoSchedule}
+	case multitenancyv1.NodeSchedulingExclusive:
+		return &corev1.Taint{Key: OwnerTaintKey, Value: tenant, Effect: corev1.TaintEffectNoSchedule}
+	default:
+		return nil
+	}
+}
+
+// Returns the toleration added to the pods of the tenant, it tolerates the owner taint regardless of its effect.
+func OwnerToleration(tenant string) corev1.Toleration {
+	return corev1.Toleration{
+		Key:      OwnerTaintKey,
+		Operator: corev1.TolerationOpEqual,
+		Value:    tenant,
+	}
+}
+
+// Returns the node affinity term preferring the nodes of the tenant.
+func OwnerPreferredSchedulingTerm(tenant string) corev1.PreferredSchedulingTerm {
+	return corev1.PreferredSchedulingTerm{
+		Weight: 100,
+		Preference: corev1.NodeSelectorTerm{
+			MatchExpressions: []corev1.NodeSelectorRequirement{
+				{
+					Key:      infrastructurev1.OwnerTenantLabel,
+					Operator: corev1.NodeSelectorOpIn,
+					Values:   []string{tenant},
+				},
+			},
+		},
+	}
+}
+
+// Compares the taints without regarding the time they are added.
+func equalTaints(a, b []corev1.Taint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Key != b[i].Key || a[i].Value != b[i].Value || a[i].Effect != b[i].Effect {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,273 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package labeller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/edgenet-project/edgenet/internal/utils"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// The reliability tier of the node, one of high, medium or low.
+	ReliabilityLabel = "edge-net.io/reliability"
+
+	// The history of the ready condition of the node. It is stored on the node so it survives the restarts of
+	// the controller.
+	ReadyHistoryAnnotation = "edge-net.io/ready-history"
+)
+
+// These are the reliability tiers of the nodes.
+const (
+	ReliabilityHigh   = "high"
+	ReliabilityMedium = "medium"
+	ReliabilityLow    = "low"
+)
+
+// The number of transitions kept in the history, the older ones are dropped even if they are in the window.
+const maxReadyHistory = 100
+
+// The minimum uptime ratio and the maximum number of flaps in the window for each tier. The nodes that don't
+// satisfy the medium tier are in the low tier.
+const (
+	highUptime   = 0.99
+	highFlaps    = 2
+	mediumUptime = 0.9
+	mediumFlaps  = 10
+)
+
+// ReadyTransition is a change of the ready condition of a node.
+type ReadyTransition struct {
+	Time  time.Time
+	Ready bool
+}
+
+// ReliabilityScore is computed from the ready history of a node over the window.
+type ReliabilityScore struct {
+	// Ratio of the observed time the node was ready, between 0 and 1.
+	Uptime float64
+
+	// Number of times the node became not ready.
+	Flaps int
+}
+
+// Returns the tier of the score.
+func (s ReliabilityScore) Tier() string {
+	switch {
+	case s.Uptime >= highUptime && s.Flaps <= highFlaps:
+		return ReliabilityHigh
+	case s.Uptime >= mediumUptime && s.Flaps <= mediumFlaps:
+		return ReliabilityMedium
+	default:
+		return ReliabilityLow
+	}
+}
+
+// This interface contains the necessary functions to score the reliability of the nodes.
+type ReliabilityManager interface {
+	// Records the current ready state of the node in its history, computes the score over the window and
+	// labels the node with its tier.
+	UpdateNodeReliability(context.Context, *corev1.Node, time.Time) (ReliabilityScore, error)
+}
+
+type reliabilityManager struct {
+	ReliabilityManager
+	client client.Client
+	window time.Duration
+}
+
+func NewReliabilityManager(ctx context.Context, client client.Client, window time.Duration) (ReliabilityManager, error) {
+	if window <= 0 {
+		return nil, fmt.Errorf("the reliability window should be positive, got %s", window)
+	}
+	return &reliabilityManager{
+		client: client,
+		window: window,
+	}, nil
+}
+
+// The node is only patched if its history or its tier is changed, the patch only contains the history annotation
+// and the tier label.
+func (m *reliabilityManager) UpdateNodeReliability(ctx context.Context, node *corev1.Node, now time.Time) (ReliabilityScore, error) {
+	history := ParseReadyHistory(node.GetAnnotations()[ReadyHistoryAnnotation])
+
+	ready, since := readyCondition(node, now)
+	history = RecordReadyTransition(history, since, ready)
+	history = PruneReadyHistory(history, now, m.window)
+
+	score := ComputeReliability(history, now, m.window)
+
+	annotations := map[string]string{ReadyHistoryAnnotation: FormatReadyHistory(history)}
+	labels := map[string]string{ReliabilityLabel: score.Tier()}
+
+	if utils.ContainsLabels(node.GetAnnotations(), annotations) && utils.ContainsLabels(node.GetLabels(), labels) {
+		return score, nil
+	}
+
+	original := node.DeepCopy()
+	node.SetAnnotations(utils.MergeLabels(node.GetAnnotations(), annotations))
+	node.SetLabels(utils.MergeLabels(node.GetLabels(), labels))
+
+	return score, m.client.Patch(ctx, node, client.MergeFrom(original))
+}
+
+// Returns the ready state of the node and since when it is in this state. A node without the ready condition
+// is considered not ready.
+func readyCondition(node *corev1.Node, now time.Time) (bool, time.Time) {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			if condition.LastTransitionTime.IsZero() {
+				return condition.Status == corev1.ConditionTrue, now
+			}
+			return condition.Status == corev1.ConditionTrue, condition.LastTransitionTime.Time
+		}
+	}
+	return false, now
+}
+
+// Parses the history written by FormatReadyHistory. The invalid entries are skipped.
+func ParseReadyHistory(value string) []ReadyTransition {
+	history := []ReadyTransition{}
+
+	for _, entry := range strings.Split(value, ",") {
+		timestamp, state, found := strings.Cut(entry, ":")
+		if !found {
+			continue
+		}
+
+		seconds, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		history = append(history, ReadyTransition{Time: time.Unix(seconds, 0).UTC(), Ready: state == "1"})
+	}
+
+	return history
+}
+
+// Formats the history as a list of "<unix time>:<1 if ready, 0 otherwise>".
+func FormatReadyHistory(history []ReadyTransition) string {
+	entries := make([]string, 0, len(history))
+
+	for _, transition := range history {
+		state := "0"
+		if transition.Ready {
+			state = "1"
+		}
+		entries = append(entries, fmt.Sprintf("%d:%s", transition.Time.Unix(), state))
+	}
+
+	return strings.Join(entries, ",")
+}
+
+// Appends the state to the history if it is different than the last recorded state. The transitions are kept
+// in order, a transition older than the last one is recorded at the time of the last one.
+func RecordReadyTransition(history []ReadyTransition, at time.Time, ready bool) []ReadyTransition {
+	at = at.UTC().Truncate(time.Second)
+
+	if len(history) == 0 {
+		return []ReadyTransition{{Time: at, Ready: ready}}
+	}
+
+	last := history[len(history)-1]
+	if last.Ready == ready {
+		return history
+	}
+
+	if at.Before(last.Time) {
+		at = last.Time
+	}
+
+	return append(history, ReadyTransition{Time: at, Ready: ready})
+}
+
+// Removes the transitions before the window. The last transition before the window is kept since it gives the
+// state of the node at the beginning of the window.
+func PruneReadyHistory(history []ReadyTransition, now time.Time, window time.Duration) []ReadyTransition {
+	start := now.Add(-window)
+
+	first := 0
+	for i, transition := range history {
+		if transition.Time.After(start) {
+			break
+		}
+		first = i
+	}
+
+	history = history[first:]
+
+	if len(history) > maxReadyHistory {
+		history = history[len(history)-maxReadyHistory:]
+	}
+
+	return history
+}
+
+// Computes the uptime and the flaps over the window. Only the observed part of the window is taken into account,
+// so a node that has just joined isn't penalized for the time before it.
+func ComputeReliability(history []ReadyTransition, now time.Time, window time.Duration) ReliabilityScore {
+	score := ReliabilityScore{}
+
+	if len(history) == 0 {
+		return score
+	}
+
+	start := now.Add(-window)
+
+	var observed, ready time.Duration
+
+	for i, transition := range history {
+		from := transition.Time
+		if from.Before(start) {
+			from = start
+		}
+
+		to := now
+		if i+1 < len(history) {
+			to = history[i+1].Time
+		}
+
+		if to.After(from) {
+			observed += to.Sub(from)
+			if transition.Ready {
+				ready += to.Sub(from)
+			}
+		}
+
+		if i > 0 && !transition.Ready && transition.Time.After(start) {
+			score.Flaps++
+		}
+	}
+
+	if observed == 0 {
+		if history[len(history)-1].Ready {
+			score.Uptime = 1
+		}
+		return score
+	}
+
+	score.Uptime = float64(ready) / float64(observed)
+
+	return score
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package labeller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Reliability", func() {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	window := 24 * time.Hour
+
+	It("should round trip the history", func() {
+		history := []ReadyTransition{
+			{Time: now.Add(-2 * time.Hour), Ready: true},
+			{Time: now.Add(-time.Hour), Ready: false},
+		}
+		Expect(ParseReadyHistory(FormatReadyHistory(history))).To(Equal(history))
+		Expect(ParseReadyHistory("")).To(BeEmpty())
+		Expect(ParseReadyHistory("invalid,10:1")).To(HaveLen(1))
+	})
+
+	It("should only record the changes", func() {
+		history := RecordReadyTransition(nil, now.Add(-time.Hour), true)
+		history = RecordReadyTransition(history, now.Add(-30*time.Minute), true)
+		Expect(history).To(HaveLen(1))
+
+		history = RecordReadyTransition(history, now.Add(-2*time.Hour), false)
+		Expect(history).To(HaveLen(2))
+		Expect(history[1].Time).To(Equal(history[0].Time))
+	})
+
+	It("should keep the state at the beginning of the window", func() {
+		history := []ReadyTransition{
+			{Time: now.Add(-72 * time.Hour), Ready: false},
+			{Time: now.Add(-48 * time.Hour), Ready: true},
+			{Time: now.Add(-time.Hour), Ready: false},
+		}
+		history = PruneReadyHistory(history, now, window)
+		Expect(history).To(HaveLen(2))
+		Expect(history[0].Ready).To(BeTrue())
+	})
+
+	It("should score a stable node high", func() {
+		history := []ReadyTransition{{Time: now.Add(-48 * time.Hour), Ready: true}}
+		score := ComputeReliability(history, now, window)
+		Expect(score.Uptime).To(BeNumerically("==", 1))
+		Expect(score.Flaps).To(BeZero())
+		Expect(score.Tier()).To(Equal(ReliabilityHigh))
+	})
+
+	It("should score a flapping node low", func() {
+		history := []ReadyTransition{{Time: now.Add(-48 * time.Hour), Ready: true}}
+		for i := 20; i > 0; i-- {
+			history = append(history,
+				ReadyTransition{Time: now.Add(-time.Duration(i) * time.Hour), Ready: false},
+				ReadyTransition{Time: now.Add(-time.Duration(i)*time.Hour + time.Minute), Ready: true},
+			)
+		}
+		score := ComputeReliability(history, now, window)
+		Expect(score.Flaps).To(Equal(20))
+		Expect(score.Tier()).To(Equal(ReliabilityLow))
+	})
+
+	It("should only take the observed time into account", func() {
+		history := []ReadyTransition{
+			{Time: now.Add(-10 * time.Hour), Ready: true},
+			{Time: now.Add(-time.Hour), Ready: false},
+		}
+		score := ComputeReliability(history, now, window)
+		Expect(score.Uptime).To(BeNumerically("~", 0.9, 0.001))
+		Expect(score.Flaps).To(Equal(1))
+		Expect(score.Tier()).To(Equal(ReliabilityMedium))
+	})
+})
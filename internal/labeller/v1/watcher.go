@@ -0,0 +1,248 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package labeller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/savaki/geoip2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// The interval the directory of the mounted secret is watched again if it doesn't exist yet. The credentials are
+// also reloaded this often in case an event is missed.
+const DefaultMaxMindReloadInterval = time.Minute
+
+// ErrMaxMindUnavailable is returned by the lookups while there are no valid credentials.
+var ErrMaxMindUnavailable = errors.New("the maxmind credentials are not available")
+
+// MaxMindWatcher watches the files of the mounted MaxMind secret and reloads the credentials whenever they change,
+// so they can be given or rotated without restarting the controller. It is a MaxMind itself, the lookups are
+// made with the latest valid credentials.
+type MaxMindWatcher struct {
+	url      string
+	dir      string
+	interval time.Duration
+
+	// Fails the readiness check while there are no valid credentials, otherwise the check only reports them.
+	Required bool
+
+	mu      sync.RWMutex
+	maxMind MaxMind
+	err     error
+
+	updates chan event.GenericEvent
+}
+
+var _ MaxMind = &MaxMindWatcher{}
+
+// Creates the watcher and loads the credentials once, the watching starts with the manager.
+func NewMaxMindWatcher(url, dir string, interval time.Duration) *MaxMindWatcher {
+	if interval <= 0 {
+		interval = DefaultMaxMindReloadInterval
+	}
+
+	w := &MaxMindWatcher{
+		url:      url,
+		dir:      filepath.Clean(dir),
+		interval: interval,
+		updates:  make(chan event.GenericEvent, 1),
+	}
+	w.reload()
+	return w
+}
+
+// Reads the credentials again. The previous credentials are kept until the new ones are valid, the error is
+// reported by the readiness check meanwhile. Returns whether the credentials changed.
+func (w *MaxMindWatcher) reload() bool {
+	loaded, err := NewMaxMindFromDir(w.url, w.dir)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.err = err
+	if err != nil {
+		return false
+	}
+	if mm, ok := loaded.(maxMind); ok && mm.sameCredentials(w.maxMind) {
+		return false
+	}
+	w.maxMind = loaded
+	return true
+}
+
+// Checks if the lookups can be made, which is the case once the credentials are loaded.
+func (w *MaxMindWatcher) Available() bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.maxMind != nil
+}
+
+func (w *MaxMindWatcher) MaxMindLookup(address string) (*geoip2.Response, error) {
+	w.mu.RLock()
+	maxMind := w.maxMind
+	w.mu.RUnlock()
+
+	if maxMind == nil {
+		return nil, ErrMaxMindUnavailable
+	}
+	return maxMind.MaxMindLookup(address)
+}
+
+// Receives an event whenever the credentials are loaded or changed, the nodes can be labelled again. The events
+// are coalesced if they are not consumed.
+func (w *MaxMindWatcher) Updates() <-chan event.GenericEvent {
+	return w.updates
+}
+
+// Returns the error of the last lookup if MaxMind rejected the credentials in use.
+func (w *MaxMindWatcher) CredentialsError() error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return MaxMindCredentialsError(w.maxMind)
+}
+
+// Reports the status of the credentials, it can be added as a readiness check of the manager. The credentials
+// rejected by MaxMind are reported as well, until they are replaced or a lookup succeeds.
+func (w *MaxMindWatcher) Check(_ *http.Request) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if !w.Required {
+		return nil
+	}
+	if w.err != nil && w.maxMind != nil {
+		return fmt.Errorf("the maxmind credentials cannot be reloaded, the previous ones are in use: %w", w.err)
+	}
+	if w.err != nil {
+		return fmt.Errorf("%w: %w", ErrMaxMindUnavailable, w.err)
+	}
+	return MaxMindCredentialsError(w.maxMind)
+}
+
+// Start watches the directory of the secret until the context is done. The kubelet updates the mounted secret by
+// swapping a symbolic link, so the directory is watched rather than the files.
+func (w *MaxMindWatcher) Start(ctx context.Context) error {
+	l := log.FromContext(ctx).WithName("maxmind")
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	watching := false
+	watch := func() {
+		if watching {
+			return
+		}
+		if err := watcher.Add(w.dir); err != nil {
+			l.V(1).Info("cannot watch the maxmind secret yet", "dir", w.dir, "error", err.Error())
+			return
+		}
+		watching = true
+	}
+
+	// The failures are logged once until the error changes, the directory is read again on every tick.
+	lastErr := ""
+	refresh := func() {
+		if w.reload() {
+			l.Info("maxmind credentials loaded", "dir", w.dir)
+			w.notify()
+		}
+
+		w.mu.RLock()
+		err := w.err
+		w.mu.RUnlock()
+
+		if err == nil {
+			lastErr = ""
+		} else if err.Error() != lastErr {
+			lastErr = err.Error()
+			l.Info("maxmind credentials not available", "dir", w.dir, "error", lastErr)
+		}
+	}
+
+	watch()
+
+	// The credentials loaded by the constructor are announced once the manager is running.
+	if w.Available() {
+		w.notify()
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			watch()
+			refresh()
+		case e, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			// The directory itself is removed when the secret is unmounted, it is watched again once it is back.
+			if e.Name == w.dir && e.Has(fsnotify.Remove) {
+				watching = false
+			}
+			refresh()
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			l.Error(err, "cannot watch the maxmind secret", "dir", w.dir)
+		}
+	}
+}
+
+// The watcher runs on all of the replicas so each of them reports its readiness.
+func (w *MaxMindWatcher) NeedLeaderElection() bool {
+	return false
+}
+
+func (w *MaxMindWatcher) notify() {
+	// The object is only a trigger, the receivers decide what to reconcile.
+	e := event.GenericEvent{Object: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "maxmind-secret"}}}
+	select {
+	case w.updates <- e:
+	default:
+	}
+}
+
+// Checks if the lookups can be made with the MaxMind, the ones that can be reloaded may not have credentials yet.
+func IsMaxMindAvailable(maxMind MaxMind) bool {
+	if maxMind == nil {
+		return false
+	}
+	if reloadable, ok := maxMind.(interface{ Available() bool }); ok {
+		return reloadable.Available()
+	}
+	return true
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package labeller
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("MaxMindWatcher", func() {
+	const url = "https://geoip.maxmind.com/geoip/v2.1/city/"
+
+	var dir string
+
+	write := func(accountId, token string) {
+		Expect(os.WriteFile(filepath.Join(dir, MaxMindAccountIdKey), []byte(base64.StdEncoding.EncodeToString([]byte(accountId))), 0o600)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(dir, MaxMindTokenKey), []byte(base64.StdEncoding.EncodeToString([]byte(token))), 0o600)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		dir = GinkgoT().TempDir()
+	})
+
+	It("reports the missing credentials only when they are required", func() {
+		watcher := NewMaxMindWatcher(url, dir, time.Minute)
+		Expect(watcher.Available()).To(BeFalse())
+		Expect(IsMaxMindAvailable(watcher)).To(BeFalse())
+		Expect(watcher.Check(nil)).To(Succeed())
+
+		_, err := watcher.MaxMindLookup("192.0.2.1")
+		Expect(err).To(MatchError(ErrMaxMindUnavailable))
+
+		watcher.Required = true
+		Expect(watcher.Check(nil)).To(MatchError(ErrMaxMindUnavailable))
+	})
+
+	It("loads the credentials once they are mounted", func() {
+		write("", "")
+		watcher := NewMaxMindWatcher(url, dir, time.Minute)
+		watcher.Required = true
+		Expect(watcher.Available()).To(BeFalse())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			defer GinkgoRecover()
+			Expect(watcher.Start(ctx)).To(Succeed())
+		}()
+
+		// Give the watcher the time to watch the directory before the files change.
+		Eventually(func() error { return watcher.Check(nil) }).Should(HaveOccurred())
+		time.Sleep(100 * time.Millisecond)
+
+		write("account", "token")
+		Eventually(watcher.Available).Should(BeTrue())
+		Eventually(watcher.Updates()).Should(Receive())
+		Expect(watcher.Check(nil)).To(Succeed())
+
+		By("keeping the previous credentials while the new ones are invalid")
+		Expect(os.Remove(filepath.Join(dir, MaxMindTokenKey))).To(Succeed())
+		Eventually(func() error { return watcher.Check(nil) }).Should(HaveOccurred())
+		Expect(watcher.Available()).To(BeTrue())
+	})
+
+	It("reports the credentials rejected by MaxMind until a lookup succeeds", func() {
+		valid := false
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !valid {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"code":"AUTHORIZATION_INVALID","error":"invalid license key"}`))
+				return
+			}
+			w.Write([]byte(`{"country":{"iso_code":"FR"}}`))
+		}))
+		defer server.Close()
+
+		write("account", "token")
+		watcher := NewMaxMindWatcher(server.URL+"/", dir, time.Minute)
+		watcher.Required = true
+		Expect(watcher.Check(nil)).To(Succeed())
+
+		_, err := watcher.MaxMindLookup("192.0.2.1")
+		Expect(err).To(MatchError(ErrMaxMindUnauthorized))
+		Expect(MaxMindCredentialsError(watcher)).To(MatchError(ErrMaxMindUnauthorized))
+		Expect(watcher.Check(nil)).To(MatchError(ErrMaxMindUnauthorized))
+		Expect(watcher.Available()).To(BeTrue())
+
+		valid = true
+		_, err = watcher.MaxMindLookup("192.0.2.1")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(MaxMindCredentialsError(watcher)).To(Succeed())
+		Expect(watcher.Check(nil)).To(Succeed())
+	})
+
+	It("considers the static credentials available", func() {
+		Expect(IsMaxMindAvailable(nil)).To(BeFalse())
+		Expect(IsMaxMindAvailable(NewMaxMindFromArgs(url, "account", "token"))).To(BeTrue())
+	})
+})
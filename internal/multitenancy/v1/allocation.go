@@ -0,0 +1,207 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multitenancy
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/utils"
+)
+
+// Maps a resource of the tenant quotas to the resource of the nodes it is allocated from. The initial requests
+// can use the quota names, "requests.cpu" is allocated from the "cpu" of the nodes.
+func AllocationResource(name corev1.ResourceName) corev1.ResourceName {
+	return corev1.ResourceName(strings.TrimPrefix(string(name), "requests."))
+}
+
+// Returns the resources with their allocation names. A quota limits "cpu" and "requests.cpu" the same way, if
+// both are given the larger quantity is the one allocated.
+func allocationResources(resources corev1.ResourceList) corev1.ResourceList {
+	normalized := corev1.ResourceList{}
+	for name, quantity := range resources {
+		name = AllocationResource(name)
+		if current, ok := normalized[name]; ok && current.Cmp(quantity) >= 0 {
+			continue
+		}
+		normalized[name] = quantity
+	}
+	return normalized
+}
+
+// Sums the allocatable resources of the schedulable nodes. Returns the capacity and the number of nodes counted.
+func ClusterCapacity(nodes []corev1.Node) (corev1.ResourceList, int) {
+	capacity := corev1.ResourceList{}
+	count := 0
+	for i := range nodes {
+		if !utils.IsNodeSchedulable(&nodes[i]) {
+			continue
+		}
+		count++
+		for name, quantity := range nodes[i].Status.Allocatable {
+			total := capacity[name]
+			total.Add(quantity)
+			capacity[name] = total
+		}
+	}
+	return capacity, count
+}
+
+// Returns the capacity multiplied by the overcommit ratio of each resource. The resources without a ratio are not
+// limited and left out.
+func AllocatableResources(policy *multitenancyv1.AllocationPolicy, capacity corev1.ResourceList) corev1.ResourceList {
+	allocatable := corev1.ResourceList{}
+	for name, ratio := range policy.Spec.OvercommitRatios {
+		total := capacity[name]
+		allocatable[name] = scaleQuantity(total, ratio.AsApproximateFloat64())
+	}
+	return allocatable
+}
+
+// Multiplies the quantity, the result is rounded to a thousandth.
+func scaleQuantity(quantity resource.Quantity, factor float64) resource.Quantity {
+	return *resource.NewMilliQuantity(int64(math.Round(quantity.AsApproximateFloat64()*factor*1000)), quantity.Format)
+}
+
+// Sums the initial requests of the tenants.
+func AllocatedResources(tenants []multitenancyv1.Tenant) corev1.ResourceList {
+	allocated := corev1.ResourceList{}
+	for _, tenant := range tenants {
+		for name, quantity := range allocationResources(tenant.Spec.InitialRequest) {
+			total := allocated[name]
+			total.Add(quantity)
+			allocated[name] = total
+		}
+	}
+	return allocated
+}
+
+// Computes the capacity, the allocation and the headroom of the cluster from its nodes and tenants. The
+// Overcommitted condition lists the resources allocated beyond their allocatable amount.
+func ComputeAllocationStatus(policy *multitenancyv1.AllocationPolicy, nodes []corev1.Node, tenants []multitenancyv1.Tenant) multitenancyv1.AllocationPolicyStatus {
+	capacity, nodeCount := ClusterCapacity(nodes)
+	allocatable := AllocatableResources(policy, capacity)
+	allocated := AllocatedResources(tenants)
+
+	status := multitenancyv1.AllocationPolicyStatus{
+		Capacity:    capacity,
+		Allocatable: allocatable,
+		Allocated:   allocated,
+		Headroom:    corev1.ResourceList{},
+		Nodes:       int32(nodeCount),
+		Tenants:     int32(len(tenants)),
+		Conditions:  append([]metav1.Condition(nil), policy.Status.Conditions...),
+	}
+
+	overcommitted := []string{}
+	for name, limit := range allocatable {
+		headroom := limit.DeepCopy()
+		headroom.Sub(allocated[name])
+		status.Headroom[name] = headroom
+		if headroom.Sign() < 0 {
+			overcommitted = append(overcommitted, string(name))
+		}
+	}
+	sort.Strings(overcommitted)
+
+	condition := metav1.Condition{
+		Type:               multitenancyv1.AllocationPolicyConditionOvercommitted,
+		Status:             metav1.ConditionFalse,
+		Reason:             "WithinCapacity",
+		Message:            "The allocations of the tenants are within the allocatable resources",
+		ObservedGeneration: policy.GetGeneration(),
+	}
+	if len(overcommitted) != 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "Overcommitted"
+		condition.Message = fmt.Sprintf("The allocations of the tenants exceed the allocatable %s", strings.Join(overcommitted, ", "))
+	}
+	meta.SetStatusCondition(&status.Conditions, condition)
+
+	return status
+}
+
+// Checks the initial request of the tenant against the caps of the tenant, the overcommit ratios and the fair
+// share. Only the resources whose request grows from the previous one are checked, a tenant can always lower its
+// request even if the cluster is overcommitted. The others are all of the other tenants.
+func CheckTenantAllocation(policy *multitenancyv1.AllocationPolicy, tenant *multitenancyv1.Tenant, previous corev1.ResourceList,
+	others []multitenancyv1.Tenant, capacity corev1.ResourceList) error {
+	request := allocationResources(tenant.Spec.InitialRequest)
+	previous = allocationResources(previous)
+	allocation := policy.TenantAllocation(tenant.GetName())
+	caps := allocationResources(allocation.Cap)
+	allocatable := AllocatableResources(policy, capacity)
+	allocated := AllocatedResources(others)
+
+	totalWeight := int64(allocation.Weight)
+	for _, other := range others {
+		totalWeight += int64(policy.TenantAllocation(other.GetName()).Weight)
+	}
+
+	names := make([]string, 0, len(request))
+	for name := range request {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+
+	errs := []error{}
+	for _, n := range names {
+		name := corev1.ResourceName(n)
+		quantity := request[name]
+		if old, ok := previous[name]; ok && quantity.Cmp(old) <= 0 {
+			continue
+		}
+
+		if limit, ok := caps[name]; ok && quantity.Cmp(limit) > 0 {
+			errs = append(errs, fmt.Errorf("the %s request %s exceeds the cap %s of the tenant", name, quantity.String(), limit.String()))
+		}
+
+		limit, ok := allocatable[name]
+		if ok {
+			total := allocated[name].DeepCopy()
+			total.Add(quantity)
+			if total.Cmp(limit) > 0 {
+				headroom := limit.DeepCopy()
+				headroom.Sub(allocated[name])
+				errs = append(errs, fmt.Errorf("the %s request %s exceeds the headroom %s of the cluster", name, quantity.String(), headroom.String()))
+			}
+		}
+
+		if policy.Spec.FairShare {
+			if !ok {
+				limit, ok = capacity[name]
+			}
+			if ok {
+				share := scaleQuantity(limit, float64(allocation.Weight)/float64(totalWeight))
+				if quantity.Cmp(share) > 0 {
+					errs = append(errs, fmt.Errorf("the %s request %s exceeds the fair share %s of the tenant", name, quantity.String(), share.String()))
+				}
+			}
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
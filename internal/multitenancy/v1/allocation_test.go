@@ -0,0 +1,125 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multitenancy
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+)
+
+var _ = Describe("Allocation", func() {
+	node := func(name, cpu string, ready bool) corev1.Node {
+		status := corev1.ConditionFalse
+		if ready {
+			status = corev1.ConditionTrue
+		}
+		return corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)},
+				Conditions:  []corev1.NodeCondition{{Type: corev1.NodeReady, Status: status}},
+			},
+		}
+	}
+
+	tenant := func(name, cpu string) multitenancyv1.Tenant {
+		return multitenancyv1.Tenant{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: multitenancyv1.TenantSpec{
+				InitialRequest: map[corev1.ResourceName]resource.Quantity{"requests.cpu": resource.MustParse(cpu)},
+			},
+		}
+	}
+
+	policy := func() *multitenancyv1.AllocationPolicy {
+		return &multitenancyv1.AllocationPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: multitenancyv1.AllocationPolicyName},
+			Spec: multitenancyv1.AllocationPolicySpec{
+				OvercommitRatios: map[corev1.ResourceName]resource.Quantity{corev1.ResourceCPU: resource.MustParse("1.5")},
+				DefaultTenant:    multitenancyv1.TenantAllocation{Weight: 1},
+			},
+		}
+	}
+
+	nodes := []corev1.Node{node("a", "4", true), node("b", "4", true), node("c", "8", false)}
+
+	It("should report the capacity, the allocation and the headroom", func() {
+		status := ComputeAllocationStatus(policy(), nodes, []multitenancyv1.Tenant{tenant("alpha", "6"), tenant("beta", "8")})
+
+		Expect(status.Nodes).To(Equal(int32(2)))
+		Expect(status.Tenants).To(Equal(int32(2)))
+		Expect(status.Capacity.Cpu().String()).To(Equal("8"))
+		Expect(status.Allocatable.Cpu().String()).To(Equal("12"))
+		Expect(status.Allocated.Cpu().String()).To(Equal("14"))
+		Expect(status.Headroom.Cpu().String()).To(Equal("-2"))
+		Expect(meta.IsStatusConditionTrue(status.Conditions, multitenancyv1.AllocationPolicyConditionOvercommitted)).To(BeTrue())
+	})
+
+	It("should count the same resource once if it is given with and without the requests prefix", func() {
+		t := tenant("alpha", "2")
+		t.Spec.InitialRequest[corev1.ResourceCPU] = resource.MustParse("3")
+
+		status := ComputeAllocationStatus(policy(), nodes, []multitenancyv1.Tenant{t})
+		Expect(status.Allocated.Cpu().String()).To(Equal("3"))
+	})
+
+	It("should reject the requests exceeding the headroom", func() {
+		capacity, _ := ClusterCapacity(nodes)
+		others := []multitenancyv1.Tenant{tenant("alpha", "6")}
+
+		t := tenant("beta", "6")
+		Expect(CheckTenantAllocation(policy(), &t, nil, others, capacity)).To(Succeed())
+
+		t = tenant("beta", "7")
+		Expect(CheckTenantAllocation(policy(), &t, nil, others, capacity)).To(MatchError(ContainSubstring("exceeds the headroom 6")))
+	})
+
+	It("should always admit a lower request", func() {
+		capacity, _ := ClusterCapacity(nodes)
+		others := []multitenancyv1.Tenant{tenant("alpha", "12")}
+
+		t := tenant("beta", "2")
+		previous := corev1.ResourceList{"requests.cpu": resource.MustParse("4")}
+		Expect(CheckTenantAllocation(policy(), &t, previous, others, capacity)).To(Succeed())
+	})
+
+	It("should enforce the caps and the fair share of the tenants", func() {
+		capacity, _ := ClusterCapacity(nodes)
+		others := []multitenancyv1.Tenant{tenant("alpha", "1")}
+		p := policy()
+		p.Spec.FairShare = true
+		p.Spec.Tenants = map[string]multitenancyv1.TenantAllocation{
+			"beta":  {Weight: 2},
+			"gamma": {Cap: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")}},
+		}
+
+		// Beta has 2 of the 3 weights of the 12 allocatable cores.
+		t := tenant("beta", "8")
+		Expect(CheckTenantAllocation(p, &t, nil, others, capacity)).To(Succeed())
+		t = tenant("beta", "9")
+		Expect(CheckTenantAllocation(p, &t, nil, others, capacity)).To(MatchError(ContainSubstring("fair share 8")))
+
+		t = tenant("gamma", "3")
+		Expect(CheckTenantAllocation(p, &t, nil, others, capacity)).To(MatchError(ContainSubstring("cap 2")))
+	})
+})
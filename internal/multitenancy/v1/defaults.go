@@ -0,0 +1,144 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multitenancy
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+)
+
+const (
+	// Name of the LimitRange holding the default requests and limits in the namespaces of the tenants.
+	NamespaceDefaultsName = "edgenet-defaults"
+
+	// The label of the Pod Security Admission enforcing the level in the namespace.
+	PodSecurityEnforceLabel = "pod-security.kubernetes.io/enforce"
+)
+
+// Returns the namespace defaults of the tenant, the fields given by the tenant override the ones of the cluster.
+func ResolveNamespaceDefaults(cluster multitenancyv1.NamespaceDefaults, t *multitenancyv1.Tenant) multitenancyv1.NamespaceDefaults {
+	defaults := *cluster.DeepCopy()
+
+	if override := t.Spec.NamespaceDefaults; override != nil {
+		if override.PodSecurityLevel != "" {
+			defaults.PodSecurityLevel = override.PodSecurityLevel
+		}
+		if override.DefaultRequests != nil {
+			defaults.DefaultRequests = override.DefaultRequests
+		}
+		if override.DefaultLimits != nil {
+			defaults.DefaultLimits = override.DefaultLimits
+		}
+	}
+
+	return defaults
+}
+
+// Applies the namespace defaults to all of the namespaces of the tenant. The namespaces are kept in sync when the
+// defaults of the cluster or the tenant change.
+func (m *multiTenancyManager) ApplyTenantNamespaceDefaults(ctx context.Context, t *multitenancyv1.Tenant) error {
+	namespaces, err := m.getTenantNamespaces(ctx, t)
+	if err != nil {
+		return err
+	}
+
+	for _, namespace := range namespaces {
+		if err := m.applyNamespaceDefaults(ctx, t, namespace.GetName()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Sets the Pod Security Admission level of the namespace and creates or updates its LimitRange. The LimitRange is
+// removed if there are no default requests and limits. Without a level, the label of the namespace is left as
+// it is.
+func (m *multiTenancyManager) applyNamespaceDefaults(ctx context.Context, t *multitenancyv1.Tenant, namespaceName string) error {
+	defaults := ResolveNamespaceDefaults(m.config.NamespaceDefaults, t)
+
+	if defaults.PodSecurityLevel != "" {
+		namespace := &corev1.Namespace{}
+		if err := m.client.Get(ctx, types.NamespacedName{Name: namespaceName}, namespace); err != nil {
+			return err
+		}
+
+		if namespace.GetLabels()[PodSecurityEnforceLabel] != defaults.PodSecurityLevel {
+			labels := namespace.GetLabels()
+			if labels == nil {
+				labels = map[string]string{}
+			}
+			labels[PodSecurityEnforceLabel] = defaults.PodSecurityLevel
+			namespace.SetLabels(labels)
+
+			if err := m.client.Update(ctx, namespace); err != nil {
+				return err
+			}
+		}
+	}
+
+	limitRange := &corev1.LimitRange{}
+	err := m.client.Get(ctx, types.NamespacedName{Name: NamespaceDefaultsName, Namespace: namespaceName}, limitRange)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	found := err == nil
+
+	if len(defaults.DefaultRequests) == 0 && len(defaults.DefaultLimits) == 0 {
+		if found {
+			return client.IgnoreNotFound(m.client.Delete(ctx, limitRange))
+		}
+		return nil
+	}
+
+	spec := corev1.LimitRangeSpec{
+		Limits: []corev1.LimitRangeItem{{
+			Type:           corev1.LimitTypeContainer,
+			Default:        defaults.DefaultLimits,
+			DefaultRequest: defaults.DefaultRequests,
+		}},
+	}
+
+	if !found {
+		limitRange = &corev1.LimitRange{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      NamespaceDefaultsName,
+				Namespace: namespaceName,
+				Labels: map[string]string{
+					"edge-net.io/generated": "true",
+					"edge-net.io/tenant":    t.GetName(),
+				},
+			},
+			Spec: spec,
+		}
+		return m.client.Create(ctx, limitRange)
+	}
+
+	if equality.Semantic.DeepEqual(limitRange.Spec, spec) {
+		return nil
+	}
+	limitRange.Spec = spec
+	return m.client.Update(ctx, limitRange)
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multitenancy
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+)
+
+var _ = Describe("NamespaceDefaults", func() {
+	cluster := multitenancyv1.NamespaceDefaults{
+		PodSecurityLevel: multitenancyv1.PodSecurityLevelBaseline,
+		DefaultRequests:  corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+	}
+
+	It("uses the cluster defaults without tenant overrides", func() {
+		defaults := ResolveNamespaceDefaults(cluster, &multitenancyv1.Tenant{})
+		Expect(defaults).To(Equal(cluster))
+	})
+
+	It("overrides only the fields given by the tenant", func() {
+		tenant := &multitenancyv1.Tenant{Spec: multitenancyv1.TenantSpec{
+			NamespaceDefaults: &multitenancyv1.NamespaceDefaults{
+				PodSecurityLevel: multitenancyv1.PodSecurityLevelRestricted,
+				DefaultLimits:    corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+			},
+		}}
+
+		defaults := ResolveNamespaceDefaults(cluster, tenant)
+		Expect(defaults.PodSecurityLevel).To(Equal(multitenancyv1.PodSecurityLevelRestricted))
+		Expect(defaults.DefaultRequests).To(Equal(cluster.DefaultRequests))
+		Expect(defaults.DefaultLimits).To(HaveKey(corev1.ResourceMemory))
+	})
+
+	Context("applying to a namespace", func() {
+		var c client.Client
+		var m *multiTenancyManager
+		tenant := &multitenancyv1.Tenant{ObjectMeta: metav1.ObjectMeta{Name: "edgenet"}}
+
+		BeforeEach(func() {
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+			Expect(multitenancyv1.AddToScheme(scheme)).To(Succeed())
+
+			c = fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "edgenet"}},
+			).Build()
+			m = &multiTenancyManager{client: c, config: Config{NamespaceDefaults: cluster}}
+		})
+
+		It("labels the namespace and creates the LimitRange", func() {
+			Expect(m.applyNamespaceDefaults(context.Background(), tenant, "edgenet")).To(Succeed())
+
+			namespace := &corev1.Namespace{}
+			Expect(c.Get(context.Background(), types.NamespacedName{Name: "edgenet"}, namespace)).To(Succeed())
+			Expect(namespace.GetLabels()).To(HaveKeyWithValue(PodSecurityEnforceLabel, multitenancyv1.PodSecurityLevelBaseline))
+
+			limitRange := &corev1.LimitRange{}
+			Expect(c.Get(context.Background(), types.NamespacedName{Name: NamespaceDefaultsName, Namespace: "edgenet"}, limitRange)).To(Succeed())
+			Expect(limitRange.GetLabels()).To(HaveKeyWithValue("edge-net.io/tenant", "edgenet"))
+			Expect(limitRange.Spec.Limits).To(HaveLen(1))
+			Expect(limitRange.Spec.Limits[0].DefaultRequest.Cpu().String()).To(Equal("100m"))
+		})
+
+		It("removes the LimitRange when the defaults are cleared", func() {
+			Expect(m.applyNamespaceDefaults(context.Background(), tenant, "edgenet")).To(Succeed())
+
+			m.config.NamespaceDefaults = multitenancyv1.NamespaceDefaults{}
+			Expect(m.applyNamespaceDefaults(context.Background(), tenant, "edgenet")).To(Succeed())
+
+			limitRange := &corev1.LimitRange{}
+			err := c.Get(context.Background(), types.NamespacedName{Name: NamespaceDefaultsName, Namespace: "edgenet"}, limitRange)
+			Expect(errors.IsNotFound(err)).To(BeTrue())
+		})
+	})
+})
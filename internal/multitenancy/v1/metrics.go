@@ -0,0 +1,322 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multitenancy
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/utils"
+)
+
+var (
+	managerDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "edgenet_multitenancy_manager_duration_seconds",
+			Help:    "Duration of the reconciliation steps performed by the multitenancy manager.",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"method"},
+	)
+	managerErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "edgenet_multitenancy_manager_errors_total",
+			Help: "Number of the reconciliation steps of the multitenancy manager that failed.",
+		},
+		[]string{"method"},
+	)
+)
+
+var (
+	tenantsDesc = prometheus.NewDesc(
+		"edgenet_tenants",
+		"Number of tenants by their state.",
+		[]string{"state"}, nil,
+	)
+	subNamespacesDesc = prometheus.NewDesc(
+		"edgenet_tenant_subnamespaces",
+		"Number of SubNamespaces of the tenant.",
+		[]string{"tenant"}, nil,
+	)
+	subNamespaceDepthDesc = prometheus.NewDesc(
+		"edgenet_tenant_subnamespace_depth",
+		"Depth of the deepest SubNamespace of the tenant, the core namespace is at depth zero.",
+		[]string{"tenant"}, nil,
+	)
+	quotaAllocatedDesc = prometheus.NewDesc(
+		"edgenet_tenant_quota_allocated",
+		"Resources allocated to the tenant by the quota of its core namespace.",
+		[]string{"tenant", "resource"}, nil,
+	)
+	quotaUsedDesc = prometheus.NewDesc(
+		"edgenet_tenant_quota_used",
+		"Resources used by the tenant from the quota of its core namespace.",
+		[]string{"tenant", "resource"}, nil,
+	)
+)
+
+// The state reported for the tenants that are not reconciled yet.
+const tenantStatePending = "Pending"
+
+// The objects are read from the cache, the scrape shouldn't wait longer than this.
+const collectTimeout = 10 * time.Second
+
+func init() {
+	// Register the metrics with the registry of the controller-runtime, they are served on the metrics endpoint
+	// of the manager.
+	metrics.Registry.MustRegister(managerDuration, managerErrors)
+}
+
+// Collector exports the state of the tenants, their namespace trees and their quotas. The objects are read when the
+// metrics are scraped, the metrics of the deleted tenants disappear without any cleanup.
+type Collector struct {
+	reader client.Reader
+}
+
+var _ prometheus.Collector = &Collector{}
+
+// Creates a collector reading from the given reader, which should be the cached client of the manager.
+func NewCollector(reader client.Reader) *Collector {
+	return &Collector{reader: reader}
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- tenantsDesc
+	ch <- subNamespacesDesc
+	ch <- subNamespaceDepthDesc
+	ch <- quotaAllocatedDesc
+	ch <- quotaUsedDesc
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	tenantList := &multitenancyv1.TenantList{}
+	if err := c.reader.List(ctx, tenantList); err != nil {
+		ch <- prometheus.NewInvalidMetric(tenantsDesc, err)
+		return
+	}
+
+	states := map[string]int{
+		multitenancyv1.TenantStateEstablished: 0,
+		multitenancyv1.TenantStateFailed:      0,
+		multitenancyv1.TenantStateTerminating: 0,
+		multitenancyv1.TenantStateSuspended:   0,
+		tenantStatePending:                    0,
+	}
+	for _, tenant := range tenantList.Items {
+		state := tenant.Status.State
+		if state == "" {
+			state = tenantStatePending
+		}
+		states[state]++
+	}
+	for state, count := range states {
+		ch <- prometheus.MustNewConstMetric(tenantsDesc, prometheus.GaugeValue, float64(count), state)
+	}
+
+	namespaceList := &corev1.NamespaceList{}
+	if err := c.reader.List(ctx, namespaceList, client.HasLabels{"edge-net.io/tenant"}); err != nil {
+		ch <- prometheus.NewInvalidMetric(subNamespacesDesc, err)
+		return
+	}
+	trees := NamespaceTrees(namespaceList.Items)
+
+	for _, tenant := range tenantList.Items {
+		tree := trees[tenant.GetName()]
+		ch <- prometheus.MustNewConstMetric(subNamespacesDesc, prometheus.GaugeValue, float64(tree.SubNamespaces), tenant.GetName())
+		ch <- prometheus.MustNewConstMetric(subNamespaceDepthDesc, prometheus.GaugeValue, float64(tree.Depth), tenant.GetName())
+
+		coreNamespaceName := utils.ResolveCoreNamespaceName(tenant.GetName())
+		quota := &corev1.ResourceQuota{}
+		if err := c.reader.Get(ctx, types.NamespacedName{Name: coreNamespaceName, Namespace: coreNamespaceName}, quota); err != nil {
+			if client.IgnoreNotFound(err) != nil {
+				ch <- prometheus.NewInvalidMetric(quotaAllocatedDesc, err)
+			}
+			continue
+		}
+
+		for resource, hard := range quota.Status.Hard {
+			ch <- prometheus.MustNewConstMetric(quotaAllocatedDesc, prometheus.GaugeValue, hard.AsApproximateFloat64(), tenant.GetName(), string(resource))
+		}
+		for resource, used := range quota.Status.Used {
+			ch <- prometheus.MustNewConstMetric(quotaUsedDesc, prometheus.GaugeValue, used.AsApproximateFloat64(), tenant.GetName(), string(resource))
+		}
+	}
+}
+
+// NamespaceTree is the summary of the namespaces of a tenant.
+type NamespaceTree struct {
+	// Number of the SubNamespaces below the core namespace.
+	SubNamespaces int
+
+	// Depth of the deepest SubNamespace, zero if the tenant only has its core namespace.
+	Depth int
+}
+
+// Summarizes the namespace trees of the tenants from their namespaces. The depth of a SubNamespace is found by
+// following its parent label up to the core namespace.
+func NamespaceTrees(namespaces []corev1.Namespace) map[string]NamespaceTree {
+	parents := map[string]string{}
+	for _, namespace := range namespaces {
+		if namespace.GetLabels()["edge-net.io/kind"] == "sub" {
+			parents[namespace.GetName()] = namespace.GetLabels()["edge-net.io/parent"]
+		}
+	}
+
+	trees := map[string]NamespaceTree{}
+	for _, namespace := range namespaces {
+		tenant := namespace.GetLabels()["edge-net.io/tenant"]
+		tree := trees[tenant]
+
+		if _, ok := parents[namespace.GetName()]; ok {
+			tree.SubNamespaces++
+
+			// A broken chain of parents cannot be longer than the number of SubNamespaces.
+			depth := 0
+			for name := namespace.GetName(); depth <= len(parents); depth++ {
+				parent, ok := parents[name]
+				if !ok {
+					break
+				}
+				name = parent
+			}
+			if depth > tree.Depth {
+				tree.Depth = depth
+			}
+		}
+
+		trees[tenant] = tree
+	}
+
+	return trees
+}
+
+// Measures the reconciliation steps of the multitenancy manager. ErrCleanupPending is not counted as an error.
+func observeManager(method string, start time.Time, err *error) {
+	managerDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if *err != nil && !errors.Is(*err, ErrCleanupPending) {
+		managerErrors.WithLabelValues(method).Inc()
+	}
+}
+
+// Measures each method of the manager. The manager calls its own methods directly, only the steps called by the
+// reconcilers are measured.
+type instrumentedManager struct {
+	manager MultiTenancyManager
+}
+
+var _ MultiTenancyManager = &instrumentedManager{}
+
+func (m *instrumentedManager) TenantCleanup(ctx context.Context, t *multitenancyv1.Tenant) (err error) {
+	defer observeManager("TenantCleanup", time.Now(), &err)
+	return m.manager.TenantCleanup(ctx, t)
+}
+
+func (m *instrumentedManager) CreateCoreNamespace(ctx context.Context, t *multitenancyv1.Tenant, clusterUID types.UID) (err error) {
+	defer observeManager("CreateCoreNamespace", time.Now(), &err)
+	return m.manager.CreateCoreNamespace(ctx, t, clusterUID)
+}
+
+func (m *instrumentedManager) CreateCoreNamespaceLocal(ctx context.Context, t *multitenancyv1.Tenant) (err error) {
+	defer observeManager("CreateCoreNamespaceLocal", time.Now(), &err)
+	return m.manager.CreateCoreNamespaceLocal(ctx, t)
+}
+
+func (m *instrumentedManager) CreateTenantRoleBindings(ctx context.Context, t *multitenancyv1.Tenant) (err error) {
+	defer observeManager("CreateTenantRoleBindings", time.Now(), &err)
+	return m.manager.CreateTenantRoleBindings(ctx, t)
+}
+
+func (m *instrumentedManager) SuspendTenant(ctx context.Context, t *multitenancyv1.Tenant) (err error) {
+	defer observeManager("SuspendTenant", time.Now(), &err)
+	return m.manager.SuspendTenant(ctx, t)
+}
+
+func (m *instrumentedManager) ResumeTenant(ctx context.Context, t *multitenancyv1.Tenant) (err error) {
+	defer observeManager("ResumeTenant", time.Now(), &err)
+	return m.manager.ResumeTenant(ctx, t)
+}
+
+func (m *instrumentedManager) GetTenantActivity(ctx context.Context, t *multitenancyv1.Tenant) (result time.Time, err error) {
+	defer observeManager("GetTenantActivity", time.Now(), &err)
+	return m.manager.GetTenantActivity(ctx, t)
+}
+
+func (m *instrumentedManager) CreateTenantNetworkPolicy(ctx context.Context, t *multitenancyv1.Tenant) (err error) {
+	defer observeManager("CreateTenantNetworkPolicy", time.Now(), &err)
+	return m.manager.CreateTenantNetworkPolicy(ctx, t)
+}
+
+func (m *instrumentedManager) ApplyTenantNamespaceDefaults(ctx context.Context, t *multitenancyv1.Tenant) (err error) {
+	defer observeManager("ApplyTenantNamespaceDefaults", time.Now(), &err)
+	return m.manager.ApplyTenantNamespaceDefaults(ctx, t)
+}
+
+func (m *instrumentedManager) SubNamespaceCleanup(ctx context.Context, s *multitenancyv1.SubNamespace) (err error) {
+	defer observeManager("SubNamespaceCleanup", time.Now(), &err)
+	return m.manager.SubNamespaceCleanup(ctx, s)
+}
+
+func (m *instrumentedManager) SetupSubNamespace(ctx context.Context, s *multitenancyv1.SubNamespace) (err error) {
+	defer observeManager("SetupSubNamespace", time.Now(), &err)
+	return m.manager.SetupSubNamespace(ctx, s)
+}
+
+func (m *instrumentedManager) CreateRoleRequestRoleBindings(ctx context.Context, rr *multitenancyv1.RoleRequest) (err error) {
+	defer observeManager("CreateRoleRequestRoleBindings", time.Now(), &err)
+	return m.manager.CreateRoleRequestRoleBindings(ctx, rr)
+}
+
+func (m *instrumentedManager) RoleRequestCleanup(ctx context.Context, rr *multitenancyv1.RoleRequest) (err error) {
+	defer observeManager("RoleRequestCleanup", time.Now(), &err)
+	return m.manager.RoleRequestCleanup(ctx, rr)
+}
+
+func (m *instrumentedManager) PropagateObjects(ctx context.Context, namespace *corev1.Namespace) (result []PropagationConflict, err error) {
+	defer observeManager("PropagateObjects", time.Now(), &err)
+	return m.manager.PropagateObjects(ctx, namespace)
+}
+
+func (m *instrumentedManager) CreateClaimSlice(ctx context.Context, sc *multitenancyv1.SliceClaim) (result *multitenancyv1.Slice, err error) {
+	defer observeManager("CreateClaimSlice", time.Now(), &err)
+	return m.manager.CreateClaimSlice(ctx, sc)
+}
+
+func (m *instrumentedManager) SliceClaimCleanup(ctx context.Context, sc *multitenancyv1.SliceClaim) (err error) {
+	defer observeManager("SliceClaimCleanup", time.Now(), &err)
+	return m.manager.SliceClaimCleanup(ctx, sc)
+}
+
+func (m *instrumentedManager) ReserveSliceNodes(ctx context.Context, s *multitenancyv1.Slice) (result []string, err error) {
+	defer observeManager("ReserveSliceNodes", time.Now(), &err)
+	return m.manager.ReserveSliceNodes(ctx, s)
+}
+
+func (m *instrumentedManager) ReleaseSliceNodes(ctx context.Context, s *multitenancyv1.Slice) (err error) {
+	defer observeManager("ReleaseSliceNodes", time.Now(), &err)
+	return m.manager.ReleaseSliceNodes(ctx, s)
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multitenancy
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+)
+
+var _ = Describe("Metrics", func() {
+	namespace := func(name, tenant, parent string) corev1.Namespace {
+		labels := map[string]string{"edge-net.io/tenant": tenant, "edge-net.io/kind": "core"}
+		if parent != "" {
+			labels["edge-net.io/kind"] = "sub"
+			labels["edge-net.io/parent"] = parent
+		}
+		return corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+
+	// Returns the value of each metric of the family by its label values.
+	gather := func(collector prometheus.Collector, name string) map[string]float64 {
+		registry := prometheus.NewPedanticRegistry()
+		registry.MustRegister(collector)
+		families, err := registry.Gather()
+		Expect(err).NotTo(HaveOccurred())
+
+		values := map[string]float64{}
+		for _, family := range families {
+			if family.GetName() != name {
+				continue
+			}
+			for _, metric := range family.GetMetric() {
+				key := ""
+				for _, label := range metric.GetLabel() {
+					key += label.GetValue() + "/"
+				}
+				values[key] = metric.GetGauge().GetValue()
+			}
+		}
+		return values
+	}
+
+	It("should summarize the namespace trees", func() {
+		trees := NamespaceTrees([]corev1.Namespace{
+			namespace("alpha", "alpha", ""),
+			namespace("alpha-lab", "alpha", "alpha"),
+			namespace("alpha-lab-a", "alpha", "alpha-lab"),
+			namespace("alpha-lab-b", "alpha", "alpha-lab"),
+			namespace("beta", "beta", ""),
+		})
+
+		Expect(trees).To(Equal(map[string]NamespaceTree{
+			"alpha": {SubNamespaces: 3, Depth: 2},
+			"beta":  {},
+		}))
+	})
+
+	It("should not loop on a cycle of parents", func() {
+		trees := NamespaceTrees([]corev1.Namespace{
+			namespace("a", "alpha", "b"),
+			namespace("b", "alpha", "a"),
+		})
+
+		Expect(trees["alpha"].SubNamespaces).To(Equal(2))
+	})
+
+	It("should collect the tenants and their quotas", func() {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(multitenancyv1.AddToScheme(scheme)).To(Succeed())
+
+		alpha := namespace("alpha", "alpha", "")
+		lab := namespace("alpha-lab", "alpha", "alpha")
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&multitenancyv1.Tenant{
+				ObjectMeta: metav1.ObjectMeta{Name: "alpha"},
+				Status:     multitenancyv1.TenantStatus{State: multitenancyv1.TenantStateEstablished},
+			},
+			&multitenancyv1.Tenant{ObjectMeta: metav1.ObjectMeta{Name: "beta"}},
+			&alpha, &lab,
+			&corev1.ResourceQuota{
+				ObjectMeta: metav1.ObjectMeta{Name: "alpha", Namespace: "alpha"},
+				Status: corev1.ResourceQuotaStatus{
+					Hard: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")},
+					Used: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+				},
+			},
+		).Build()
+		collector := NewCollector(c)
+
+		Expect(gather(collector, "edgenet_tenants")).To(Equal(map[string]float64{
+			"Established/": 1, "Failed/": 0, "Pending/": 1, "Suspended/": 0, "Terminating/": 0,
+		}))
+		Expect(gather(collector, "edgenet_tenant_subnamespaces")).To(Equal(map[string]float64{"alpha/": 1, "beta/": 0}))
+		Expect(gather(collector, "edgenet_tenant_subnamespace_depth")).To(Equal(map[string]float64{"alpha/": 1, "beta/": 0}))
+		Expect(gather(collector, "edgenet_tenant_quota_allocated")).To(Equal(map[string]float64{"cpu/alpha/": 4}))
+		Expect(gather(collector, "edgenet_tenant_quota_used")).To(Equal(map[string]float64{"cpu/alpha/": 0.5}))
+	})
+})
@@ -19,6 +19,10 @@ package multitenancy
 import (
 	"context"
 	errors2 "errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
 
 	antreav1alpha1 "antrea.io/antrea/pkg/apis/crd/v1alpha1"
 	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
@@ -38,7 +42,7 @@ import (
 // However, some of the functions are changed.
 type MultiTenancyManager interface {
 	// Remove the tenant and all of the other artifacts created with it including,
-	// subtenants, subnamespaces etc.
+	// subtenants, subnamespaces etc. Returns ErrCleanupPending until the whole tree is removed.
 	TenantCleanup(context.Context, *multitenancyv1.Tenant) error
 
 	// Creates a core namespace (same name with the tenant) and sets the resource allocation.
@@ -48,47 +52,107 @@ type MultiTenancyManager interface {
 	// Same as the CreateCoreNamespace except gets the UID from local cluster.
 	CreateCoreNamespaceLocal(context.Context, *multitenancyv1.Tenant) error
 
-	// Creates a new tenant role binding with admin priviliages. Requires "edgenet:tenant-admin" role
-	// to work.
-	CreateTenantAdminRoleBinding(context.Context, *multitenancyv1.Tenant) error
+	// Creates a role binding for each of the tenant roles in the core namespace and in all of the SubNamespaces
+	// of the tenant. Role bindings of the roles without any members are removed. Requires the "edgenet:tenant-*"
+	// cluster roles to work.
+	CreateTenantRoleBindings(context.Context, *multitenancyv1.Tenant) error
+
+	// Suspends the tenant in the core namespace and in all of the SubNamespaces. No pods can be created and the
+	// role bindings of the tenant are removed. If requested the workloads are scaled down to zero.
+	SuspendTenant(context.Context, *multitenancyv1.Tenant) error
+
+	// Reverts the suspension of the tenant, the scaled down workloads get their replicas back and the role
+	// bindings of the approved role requests are recreated.
+	ResumeTenant(context.Context, *multitenancyv1.Tenant) error
+
+	// Returns the last activity observed in the namespaces of the tenant, used by the inactivity policy.
+	GetTenantActivity(context.Context, *multitenancyv1.Tenant) (time.Time, error)
 
 	// Create the network policy. If specified creates the cluster network policy as well.
 	CreateTenantNetworkPolicy(context.Context, *multitenancyv1.Tenant) error
 
-	// Cleanups the SubNamespace
+	// Applies the default LimitRange and the Pod Security Admission level to the core namespace and all of the
+	// SubNamespaces of the tenant.
+	ApplyTenantNamespaceDefaults(context.Context, *multitenancyv1.Tenant) error
+
+	// Cleanups the SubNamespace. The SubNamespaces inside the child namespace are deleted first, returns
+	// ErrCleanupPending until the whole subtree is removed.
 	SubNamespaceCleanup(context.Context, *multitenancyv1.SubNamespace) error
 
 	// Creates and setups studd for the SubNamespace
 	SetupSubNamespace(context.Context, *multitenancyv1.SubNamespace) error
+
+	// Creates the role binding of an approved role request in the core namespace and in all of the
+	// SubNamespaces of the tenant.
+	CreateRoleRequestRoleBindings(context.Context, *multitenancyv1.RoleRequest) error
+
+	// Removes the role bindings created for the role request.
+	RoleRequestCleanup(context.Context, *multitenancyv1.RoleRequest) error
+
+	// Copies the Roles, RoleBindings and the other configured objects of the parent namespace into the given
+	// SubNamespace namespace. Returns the objects that cannot be propagated because of a conflict.
+	PropagateObjects(context.Context, *corev1.Namespace) ([]PropagationConflict, error)
+
+	// Creates the slice of the claim if it doesn't exist and returns it.
+	CreateClaimSlice(context.Context, *multitenancyv1.SliceClaim) (*multitenancyv1.Slice, error)
+
+	// Deletes the slice of the claim.
+	SliceClaimCleanup(context.Context, *multitenancyv1.SliceClaim) error
+
+	// Reserves the nodes of the slice for the claiming namespace and returns their names. Returns
+	// ErrInsufficientNodes if there are not enough free nodes.
+	ReserveSliceNodes(context.Context, *multitenancyv1.Slice) ([]string, error)
+
+	// Releases the nodes reserved for the slice.
+	ReleaseSliceNodes(context.Context, *multitenancyv1.Slice) error
 }
 
+// Config holds the cluster-wide settings of the multitenancy manager. These are given by the command line
+// arguments of the controller.
+type Config struct {
+	// Maps the tenant admins and the members to the RBAC subjects.
+	Subjects SubjectMapper
+
+	// Kinds of the objects propagated from the parent namespaces to the SubNamespaces. If empty,
+	// DefaultPropagatedKinds are used.
+	PropagatedKinds []string
+
+	// The LimitRange and the Pod Security Admission level applied to the namespaces of the tenants. The tenants can
+	// override them.
+	NamespaceDefaults multitenancyv1.NamespaceDefaults
+
+	// Applies the cluster network policy to all of the tenants, including the ones not requesting it.
+	ClusterNetworkPolicy bool
+
+	// Limits of the nodes the tenants can reserve with the slice claims.
+	SliceLimits SliceLimits
+}
+
+// ErrCleanupPending is returned by the cleanup functions while the namespaces below the object are still being
+// deleted. This is not a failure, the object should be reconciled again later.
+var ErrCleanupPending = errors2.New("cleanup pending")
+
 type multiTenancyManager struct {
 	MultiTenancyManager
 	client client.Client
+	config Config
 }
 
-func NewMultiTenancyManager(ctx context.Context, client client.Client) (MultiTenancyManager, error) {
-	return &multiTenancyManager{
-		client: client,
+func NewMultiTenancyManager(ctx context.Context, client client.Client, config Config) (MultiTenancyManager, error) {
+	return &instrumentedManager{
+		manager: &multiTenancyManager{
+			client: client,
+			config: config,
+		},
 	}, nil
 }
 
+// Removes the tenant tree from the top. First the SubNamespaces in the core namespace are deleted, each of them
+// removes its own subtree before its namespace. The core namespace is deleted once all of them are gone. The
+// core namespace has an owner reference to the tenant, however the garbage collector removes it only after the
+// tenant, which would leave the SubNamespaces in an arbitrary order.
 func (m *multiTenancyManager) TenantCleanup(ctx context.Context, t *multitenancyv1.Tenant) error {
-	// The core namespace should automatically deleted because of the owner references.
-	// // Get the corenamespace name
-	// coreNamespaceName := ResolveCoreNamespaceName(t.Name)
-	// coreNamespaceObjectKey := client.ObjectKey{Name: coreNamespaceName}
-
-	// // Create the namespace
-	// coreNamespace := corev1.Namespace{}
-	// err := m.client.Get(ctx, coreNamespaceObjectKey, &coreNamespace)
-
-	// if err != nil {
-	// 	return err
-	// }
-
-	// return m.client.Delete(ctx, &coreNamespace)
-	return nil
+	return m.deleteNamespaceTree(ctx, utils.ResolveCoreNamespaceName(t.GetName()))
 }
 
 // Same as the CreateCoreNamespace except automaticaly populates the cluster UID from the local
@@ -181,54 +245,137 @@ func (m *multiTenancyManager) CreateCoreNamespace(ctx context.Context, t *multit
 
 	}
 
+	return m.applyNamespaceDefaults(ctx, t, coreNamespaceName)
+}
+
+// Returns the sorted names of the resources whose usage reached the hard limit of the quota. The resources limited
+// to zero are not considered exhausted.
+func ExhaustedResources(quota *corev1.ResourceQuota) []string {
+	exhausted := []string{}
+	for name, hard := range quota.Spec.Hard {
+		used, ok := quota.Status.Used[name]
+		if ok && hard.Sign() > 0 && used.Cmp(hard) >= 0 {
+			exhausted = append(exhausted, string(name))
+		}
+	}
+	sort.Strings(exhausted)
+	return exhausted
+}
+
+// This creates the role bindings of the tenant members. The role bindings are created inside the core namespace
+// and in every SubNamespace of the tenant. By this way the tenant's permissions will be contained inside the
+// namespaces of the tenant.
+func (m *multiTenancyManager) CreateTenantRoleBindings(ctx context.Context, t *multitenancyv1.Tenant) error {
+	namespaces, err := m.getTenantNamespaces(ctx, t)
+
+	if err != nil {
+		return err
+	}
+
+	for _, namespace := range namespaces {
+		if err := m.createTenantRoleBindingsInNamespace(ctx, t, namespace.GetName()); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-// This creates a role binding for the tenant. The role binding will be created inside the core namespace of the
-// tenant. By this way the tenant's permissions will be contained inside the core namespace.
-func (m *multiTenancyManager) CreateTenantAdminRoleBinding(ctx context.Context, t *multitenancyv1.Tenant) error {
-	// Retrieve the role bingind, if already exists, do nothing.
-	roleBinding := &rbacv1.RoleBinding{}
-	err := m.client.Get(ctx,
-		types.NamespacedName{
-			// The name of the rolebinding should be TenantAdminRoleName and Namespace should be core namespace
-			Name:      multitenancyv1.TenantAdminRoleName,
-			Namespace: utils.ResolveCoreNamespaceName(t.GetName()),
-		}, roleBinding)
+// Converges the role bindings of the tenant in the given namespace. There is one role binding per tenant role,
+// named after the cluster role, which contains all of the members having that role.
+func (m *multiTenancyManager) createTenantRoleBindingsInNamespace(ctx context.Context, t *multitenancyv1.Tenant, namespace string) error {
+	subjects, err := m.getTenantRoleSubjects(t)
 
-	// If it doesn't exsist then create it.
 	if err != nil {
-		// Create the role binding
-		if errors.IsNotFound(err) {
+		return err
+	}
+
+	for _, role := range multitenancyv1.TenantRoles {
+		roleBindingName := role.ClusterRoleName()
+		roleBinding := &rbacv1.RoleBinding{}
+		err := m.client.Get(ctx, types.NamespacedName{Name: roleBindingName, Namespace: namespace}, roleBinding)
+
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+
+		exists := err == nil
+
+		// If there are no members with this role, remove the role binding.
+		if len(subjects[role]) == 0 {
+			if exists {
+				if err := m.client.Delete(ctx, roleBinding); err != nil && !errors.IsNotFound(err) {
+					return err
+				}
+			}
+			continue
+		}
+
+		roleRef := rbacv1.RoleRef{
+			APIGroup: "rbac.authorization.k8s.io",
+			Kind:     "ClusterRole",
+			Name:     roleBindingName,
+		}
+
+		// The role reference of a role binding cannot be changed, delete it first if it is different.
+		if exists && !reflect.DeepEqual(roleBinding.RoleRef, roleRef) {
+			if err := m.client.Delete(ctx, roleBinding); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+			exists = false
+		}
+
+		if !exists {
 			roleBinding = &rbacv1.RoleBinding{
 				ObjectMeta: metav1.ObjectMeta{
-					Name:      multitenancyv1.TenantAdminRoleName,
-					Namespace: utils.ResolveCoreNamespaceName(t.GetName()),
+					Name:      roleBindingName,
+					Namespace: namespace,
 					Labels: map[string]string{
 						"edge-net.io/generated":    "true",
 						"edge-net.io/notification": "true",
+						"edge-net.io/tenant":       t.GetName(),
+						"edge-net.io/tenant-role":  string(role),
 					},
 				},
-				Subjects: []rbacv1.Subject{
-					{
-						Kind:     "User",
-						APIGroup: "rbac.authorization.k8s.io",
-						Name:     t.Spec.Admin,
-					},
-				},
-				RoleRef: rbacv1.RoleRef{
-					Kind: "ClusterRole",
-					Name: multitenancyv1.TenantAdminRoleName,
-				},
+				Subjects: subjects[role],
+				RoleRef:  roleRef,
 			}
 
-			return m.client.Create(ctx, roleBinding)
+			if err := m.client.Create(ctx, roleBinding); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Only update the role binding if the members are changed.
+		if !reflect.DeepEqual(roleBinding.Subjects, subjects[role]) {
+			roleBinding.Subjects = subjects[role]
+			if err := m.client.Update(ctx, roleBinding); err != nil {
+				return err
+			}
 		}
-		return err
 	}
+
 	return nil
 }
 
+// Groups the subjects of the tenant by their roles, see SubjectMapper.TenantRoleSubjects.
+func (m *multiTenancyManager) getTenantRoleSubjects(t *multitenancyv1.Tenant) (map[multitenancyv1.TenantRole][]rbacv1.Subject, error) {
+	return m.config.Subjects.TenantRoleSubjects(t)
+}
+
+// Lists the core namespace and all of the SubNamespaces of the tenant. These namespaces are labelled with the
+// tenant name when they are created.
+func (m *multiTenancyManager) getTenantNamespaces(ctx context.Context, t *multitenancyv1.Tenant) ([]corev1.Namespace, error) {
+	namespaceList := &corev1.NamespaceList{}
+
+	if err := m.client.List(ctx, namespaceList, client.MatchingLabels{"edge-net.io/tenant": t.GetName()}); err != nil {
+		return nil, err
+	}
+
+	return namespaceList.Items, nil
+}
+
 // Create the network policy, if specified in the tenant create the cluster network policy as well.
 func (m *multiTenancyManager) CreateTenantNetworkPolicy(ctx context.Context, t *multitenancyv1.Tenant) error {
 	clusterUID, err := utils.GetClusterUID(ctx, m.client)
@@ -366,8 +513,9 @@ func (m *multiTenancyManager) CreateTenantNetworkPolicy(ctx context.Context, t *
 		},
 	}
 
-	// Check if in the tenant spec the cluster network policy is requested. If this is false, try to delete the policy if it exist.
-	if t.Spec.ClusterNetworkPolicy {
+	// Check if in the tenant spec or the configuration the cluster network policy is requested. If this is false,
+	// try to delete the policy if it exist.
+	if t.Spec.ClusterNetworkPolicy || m.config.ClusterNetworkPolicy {
 		if err = m.client.Create(ctx, &clusterNetworkPolicy); err != nil && !errors.IsAlreadyExists(err) {
 			return err
 		}
@@ -380,100 +528,122 @@ func (m *multiTenancyManager) CreateTenantNetworkPolicy(ctx context.Context, t *
 	return nil
 }
 
-// Deletes the created child namespace.
+// Deletes the created child namespace. The SubNamespaces inside the child namespace are deleted first and their
+// cleanup removes their own children, so the tree is torn down depth-first. The finalizer of the SubNamespace
+// should only be removed once this returns nil, which means the child namespace is completely gone.
 func (m *multiTenancyManager) SubNamespaceCleanup(ctx context.Context, s *multitenancyv1.SubNamespace) error {
-	subNamespaceName := utils.ResolveSubNamespaceName(s)
+	return m.deleteNamespaceTree(ctx, utils.ResolveSubNamespaceName(s))
+}
 
-	ns := &corev1.Namespace{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: subNamespaceName,
-		},
+// Deletes the SubNamespaces inside the namespace, then the namespace itself. Returns ErrCleanupPending until the
+// namespace doesn't exist anymore.
+func (m *multiTenancyManager) deleteNamespaceTree(ctx context.Context, namespaceName string) error {
+	subNamespaceList := &multitenancyv1.SubNamespaceList{}
+
+	if err := m.client.List(ctx, subNamespaceList, client.InNamespace(namespaceName)); err != nil {
+		return err
 	}
 
-	// Then finally try to delete the namespace
-	if err := m.client.Delete(ctx, ns); err != nil && !errors.IsNotFound(err) {
+	// Deleting the namespace while there are SubNamespaces inside would remove them in an arbitrary order.
+	// Mark each of them for deletion and wait until their finalizers are removed by their own cleanup.
+	if len(subNamespaceList.Items) != 0 {
+		for i := range subNamespaceList.Items {
+			sns := &subNamespaceList.Items[i]
+			if !sns.GetDeletionTimestamp().IsZero() {
+				continue
+			}
+			if err := m.client.Delete(ctx, sns); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+		return fmt.Errorf("%w: waiting for %d SubNamespaces in %s to be deleted", ErrCleanupPending, len(subNamespaceList.Items), namespaceName)
+	}
+
+	ns := &corev1.Namespace{}
+	if err := m.client.Get(ctx, types.NamespacedName{Name: namespaceName}, ns); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
 		return err
 	}
 
-	return nil
+	// Then finally try to delete the namespace
+	if ns.GetDeletionTimestamp().IsZero() {
+		if err := m.client.Delete(ctx, ns); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return fmt.Errorf("%w: waiting for namespace %s to be deleted", ErrCleanupPending, namespaceName)
 }
 
 // This creates a new namespace using the generated name. Then populates the namespace with the initial allocation.
-// Then gives the current tenant admin the permissions.
+// Then gives the tenant members the permissions.
 func (m *multiTenancyManager) SetupSubNamespace(ctx context.Context, s *multitenancyv1.SubNamespace) error {
 	subNamespaceName := utils.ResolveSubNamespaceName(s)
 
+	// The parent namespace is the namespace of the SubNamespace object, the tenant is resolved from its labels.
+	t, err := m.getRootTenant(ctx, s.GetNamespace())
+
+	if err != nil {
+		return err
+	}
+
+	labels := map[string]string{
+		"edge-net.io/generated": "true",
+		"edge-net.io/kind":      "sub",
+		"edge-net.io/parent":    s.GetNamespace(),
+		"edge-net.io/tenant":    t.GetName(),
+	}
+
 	ns := &corev1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: subNamespaceName,
-			Labels: map[string]string{
-				"edge-net.io/generated": "true",
-				"edge-net.io/kind":      "sub",
-				"edge-net.io/parent":    s.GetNamespace(),
-			},
+			Name:   subNamespaceName,
+			Labels: labels,
 			// We will use admission controller to prevent namespaces that are managed by the subnamespace controller from deleting.
 			// So we will not have finalizers and owners in the newly created object.
 		},
 	}
 
-	// Try to create the namespace, continue even if it already exist
-	if err := m.client.Create(ctx, ns); err != nil && !errors.IsAlreadyExists(err) {
-		return err
-	}
+	// Try to create the namespace, if it already exists make sure that the labels are set.
+	if err := m.client.Create(ctx, ns); err != nil {
+		if !errors.IsAlreadyExists(err) {
+			return err
+		}
 
-	// TODO: Create the role bingind etc.
-	t, err := m.getRootTenant(ctx, s)
+		if err := m.client.Get(ctx, types.NamespacedName{Name: subNamespaceName}, ns); err != nil {
+			return err
+		}
 
-	if err != nil {
-		return err
+		if !utils.ContainsLabels(ns.GetLabels(), labels) {
+			ns.SetLabels(utils.MergeLabels(ns.GetLabels(), labels))
+			if err := m.client.Update(ctx, ns); err != nil {
+				return err
+			}
+		}
 	}
 
-	// Retrieve the role bingind, if already exists, do nothing.
-	roleBinding := &rbacv1.RoleBinding{}
-	err = m.client.Get(ctx,
-		types.NamespacedName{
-			// The name of the rolebinding should be TenantAdminRoleName and Namespace should be core namespace
-			Name:      multitenancyv1.TenantAdminRoleName,
-			Namespace: subNamespaceName,
-		}, roleBinding)
+	if err := m.applyNamespaceDefaults(ctx, t, subNamespaceName); err != nil {
+		return err
+	}
 
-	// If it doesn't exsist then create it.
-	if err != nil {
-		// Create the role binding
-		if errors.IsNotFound(err) {
-			roleBinding = &rbacv1.RoleBinding{
-				ObjectMeta: metav1.ObjectMeta{
-					Name:      multitenancyv1.TenantAdminRoleName,
-					Namespace: subNamespaceName,
-					Labels: map[string]string{
-						"edge-net.io/generated":    "true",
-						"edge-net.io/notification": "true",
-					},
-				},
-				Subjects: []rbacv1.Subject{
-					{
-						Kind:     "User",
-						APIGroup: "rbac.authorization.k8s.io",
-						Name:     t.Spec.Admin,
-					},
-				},
-				RoleRef: rbacv1.RoleRef{
-					Kind: "ClusterRole",
-					Name: multitenancyv1.TenantAdminRoleName,
-				},
-			}
+	// The SubNamespaces created while the tenant is suspended are suspended as well.
+	if t.Spec.Suspended {
+		return m.suspendNamespace(ctx, t, subNamespaceName)
+	}
 
-			return m.client.Create(ctx, roleBinding)
-		}
+	if err := m.createTenantRoleBindingsInNamespace(ctx, t, subNamespaceName); err != nil {
 		return err
 	}
-	return nil
+
+	// The approved role requests are inherited by the SubNamespaces as well.
+	return m.createRoleRequestRoleBindingsInNamespace(ctx, t, subNamespaceName)
 }
 
-// Gets the tenant of the topmost namespace in the subnamespace hierarchy.
-func (m *multiTenancyManager) getRootTenant(ctx context.Context, s *multitenancyv1.SubNamespace) (*multitenancyv1.Tenant, error) {
+// Gets the tenant of the topmost namespace in the subnamespace hierarchy starting from the given namespace.
+func (m *multiTenancyManager) getRootTenant(ctx context.Context, namespace string) (*multitenancyv1.Tenant, error) {
 	// Start with the current namespace then go up.
-	currentNamespaceName := utils.ResolveSubNamespaceName(s)
+	currentNamespaceName := namespace
 
 	// This should be limited in case there happens to be a loop. for now limit this to 255. A Map or a Set can be used here
 	// to check if the current namespace is traversed before.
@@ -0,0 +1,284 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multitenancy
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// Annotation on the source object, if set to "false" the object is not propagated to the SubNamespaces.
+	// For the kinds that are not propagated by default, the same key is used as a label set to "true".
+	PropagateKey = "edge-net.io/propagate"
+
+	// Label on the propagated copies, the value is the namespace the object is copied from.
+	PropagatedFromLabel = "edge-net.io/propagated-from"
+)
+
+// PropagatableKind describes a kind of object that can be copied from a parent namespace to its SubNamespaces.
+type PropagatableKind struct {
+	GroupVersionKind schema.GroupVersionKind
+
+	// If true, only the objects labelled with "edge-net.io/propagate: true" are propagated.
+	OptIn bool
+}
+
+// These are the kinds that can be propagated. Roles and RoleBindings are propagated by default, the others
+// should be enabled in the controller and labelled on the source object.
+var PropagatableKinds = map[string]PropagatableKind{
+	"Role":        {GroupVersionKind: rbacv1.SchemeGroupVersion.WithKind("Role")},
+	"RoleBinding": {GroupVersionKind: rbacv1.SchemeGroupVersion.WithKind("RoleBinding")},
+	"Secret":      {GroupVersionKind: corev1.SchemeGroupVersion.WithKind("Secret"), OptIn: true},
+	"ConfigMap":   {GroupVersionKind: corev1.SchemeGroupVersion.WithKind("ConfigMap"), OptIn: true},
+	"LimitRange":  {GroupVersionKind: corev1.SchemeGroupVersion.WithKind("LimitRange"), OptIn: true},
+}
+
+// DefaultPropagatedKinds are propagated if no kinds are configured.
+var DefaultPropagatedKinds = []string{"Role", "RoleBinding"}
+
+// PropagationConflict is reported when an object with the same name already exists in the SubNamespace and
+// it is not created by the propagation.
+type PropagationConflict struct {
+	Kind      string
+	Name      string
+	Namespace string
+}
+
+func (c PropagationConflict) String() string {
+	return fmt.Sprintf("%s %s/%s", c.Kind, c.Namespace, c.Name)
+}
+
+// Copies the propagatable objects of the parent namespace into the given SubNamespace namespace and keeps them in
+// sync. Copies whose source is deleted or opted-out are removed. Since the copies are propagatable as well, the
+// objects are carried down the whole hierarchy one level at a time. Objects that exist in the SubNamespace but
+// are not created by the propagation are never overwritten, they are returned as conflicts.
+func (m *multiTenancyManager) PropagateObjects(ctx context.Context, namespace *corev1.Namespace) ([]PropagationConflict, error) {
+	parent, ok := namespace.GetLabels()["edge-net.io/parent"]
+
+	// Only the SubNamespaces have a parent, nothing to do for the other namespaces.
+	if !ok || parent == "" || !namespace.GetDeletionTimestamp().IsZero() {
+		return nil, nil
+	}
+
+	kinds := m.config.PropagatedKinds
+	if len(kinds) == 0 {
+		kinds = DefaultPropagatedKinds
+	}
+
+	conflicts := []PropagationConflict{}
+
+	for _, kindName := range kinds {
+		kind, ok := PropagatableKinds[kindName]
+		if !ok {
+			return nil, fmt.Errorf("kind %q cannot be propagated", kindName)
+		}
+
+		kindConflicts, err := m.propagateKind(ctx, kind, parent, namespace.GetName())
+		if err != nil {
+			return nil, err
+		}
+		conflicts = append(conflicts, kindConflicts...)
+	}
+
+	return conflicts, nil
+}
+
+// Propagates the objects of a single kind from the parent namespace to the child namespace.
+func (m *multiTenancyManager) propagateKind(ctx context.Context, kind PropagatableKind, parent, child string) ([]PropagationConflict, error) {
+	sources, err := m.listUnstructured(ctx, kind.GroupVersionKind, parent)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := m.listUnstructured(ctx, kind.GroupVersionKind, child)
+	if err != nil {
+		return nil, err
+	}
+
+	existingByName := map[string]*unstructured.Unstructured{}
+	for i := range existing {
+		existingByName[existing[i].GetName()] = &existing[i]
+	}
+
+	conflicts := []PropagationConflict{}
+	desired := map[string]bool{}
+
+	for i := range sources {
+		source := &sources[i]
+		if !isPropagatable(kind, source) {
+			continue
+		}
+		desired[source.GetName()] = true
+
+		propagated := newPropagatedCopy(source, parent, child)
+		current, ok := existingByName[source.GetName()]
+
+		if !ok {
+			if err := m.client.Create(ctx, propagated); err != nil && !errors.IsAlreadyExists(err) {
+				return nil, err
+			}
+			continue
+		}
+
+		// Never touch the objects that are not created by the propagation.
+		if current.GetLabels()[PropagatedFromLabel] != parent {
+			conflicts = append(conflicts, PropagationConflict{
+				Kind:      kind.GroupVersionKind.Kind,
+				Name:      source.GetName(),
+				Namespace: child,
+			})
+			continue
+		}
+
+		if isPropagatedCopyInSync(current, propagated) {
+			continue
+		}
+
+		propagated.SetResourceVersion(current.GetResourceVersion())
+		if err := m.client.Update(ctx, propagated); err != nil {
+			// Some fields are immutable (e.g. the role reference of the role bindings), recreate the object.
+			if !errors.IsInvalid(err) {
+				return nil, err
+			}
+			if err := m.client.Delete(ctx, current); err != nil && !errors.IsNotFound(err) {
+				return nil, err
+			}
+			propagated.SetResourceVersion("")
+			if err := m.client.Create(ctx, propagated); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Remove the copies whose source does not exist anymore.
+	for i := range existing {
+		current := &existing[i]
+		if current.GetLabels()[PropagatedFromLabel] != parent || desired[current.GetName()] {
+			continue
+		}
+		if err := m.client.Delete(ctx, current); err != nil && !errors.IsNotFound(err) {
+			return nil, err
+		}
+	}
+
+	return conflicts, nil
+}
+
+// Lists the objects of the given kind in the namespace.
+func (m *multiTenancyManager) listUnstructured(ctx context.Context, gvk schema.GroupVersionKind, namespace string) ([]unstructured.Unstructured, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+
+	if err := m.client.List(ctx, list, client.InNamespace(namespace)); err != nil {
+		return nil, err
+	}
+
+	return list.Items, nil
+}
+
+// Checks if the object should be copied to the SubNamespaces. The objects generated by the controller are
+// rendered in each namespace separately so they are not propagated.
+func isPropagatable(kind PropagatableKind, obj *unstructured.Unstructured) bool {
+	if !obj.GetDeletionTimestamp().IsZero() || obj.GetLabels()["edge-net.io/generated"] == "true" {
+		return false
+	}
+
+	if obj.GetAnnotations()[PropagateKey] == "false" {
+		return false
+	}
+
+	// The service account tokens are bound to the service accounts of the namespace.
+	if kind.GroupVersionKind.Kind == "Secret" {
+		if secretType, _, _ := unstructured.NestedString(obj.Object, "type"); secretType == string(corev1.SecretTypeServiceAccountToken) {
+			return false
+		}
+	}
+
+	if kind.OptIn {
+		return obj.GetLabels()[PropagateKey] == "true"
+	}
+
+	return true
+}
+
+// Creates the copy of the source object for the child namespace. Everything except the metadata and the status
+// is copied. The labels and the annotations are kept so the copy can be propagated further.
+func newPropagatedCopy(source *unstructured.Unstructured, parent, child string) *unstructured.Unstructured {
+	propagated := &unstructured.Unstructured{Object: map[string]interface{}{}}
+
+	for key, value := range source.Object {
+		if key == "metadata" || key == "status" {
+			continue
+		}
+		propagated.Object[key] = runtime.DeepCopyJSONValue(value)
+	}
+
+	labels := map[string]string{}
+	for key, value := range source.GetLabels() {
+		labels[key] = value
+	}
+	labels[PropagatedFromLabel] = parent
+
+	annotations := map[string]string{}
+	for key, value := range source.GetAnnotations() {
+		if key == corev1.LastAppliedConfigAnnotation {
+			continue
+		}
+		annotations[key] = value
+	}
+
+	propagated.SetName(source.GetName())
+	propagated.SetNamespace(child)
+	propagated.SetLabels(labels)
+	propagated.SetAnnotations(annotations)
+
+	return propagated
+}
+
+// Checks if the current copy has the same content, labels and annotations as the desired copy.
+func isPropagatedCopyInSync(current, desired *unstructured.Unstructured) bool {
+	if !reflect.DeepEqual(current.GetLabels(), desired.GetLabels()) {
+		return false
+	}
+
+	// Empty annotations are not stored, compare them by their length as well.
+	if len(current.GetAnnotations()) != len(desired.GetAnnotations()) ||
+		(len(desired.GetAnnotations()) != 0 && !reflect.DeepEqual(current.GetAnnotations(), desired.GetAnnotations())) {
+		return false
+	}
+
+	for key, value := range desired.Object {
+		if key == "metadata" || key == "apiVersion" || key == "kind" {
+			continue
+		}
+		if !reflect.DeepEqual(current.Object[key], value) {
+			return false
+		}
+	}
+
+	return true
+}
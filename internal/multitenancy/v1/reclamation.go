@@ -0,0 +1,224 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multitenancy
+
+import (
+	"context"
+	"time"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ReclamationPolicy configures how the expiring and the inactive tenants are reclaimed. It is given by the command
+// line arguments of the controller.
+type ReclamationPolicy struct {
+	// Warnings are sent when the deadline of the tenant is closer than each of these durations.
+	WarningLeadTimes []time.Duration
+
+	// How long a tenant suspended by the reclamation is kept before it is deleted. Zero disables the deletion.
+	GracePeriod time.Duration
+}
+
+// ReclamationAction is the step of the reclamation to be taken on a tenant.
+type ReclamationAction string
+
+const (
+	ReclamationActionNone    ReclamationAction = "None"
+	ReclamationActionWarn    ReclamationAction = "Warn"
+	ReclamationActionSuspend ReclamationAction = "Suspend"
+	ReclamationActionDelete  ReclamationAction = "Delete"
+)
+
+// ReclamationDecision is the result of the evaluation of a tenant against the reclamation policy.
+type ReclamationDecision struct {
+	Action ReclamationAction
+
+	// Expired or Inactive, the reason of the closest deadline.
+	Reason string
+
+	// The time the tenant is suspended at.
+	Deadline time.Time
+
+	// The lead time of the warning, only set for the Warn action.
+	LeadTime time.Duration
+
+	// When the tenant should be evaluated again. Zero if nothing is scheduled.
+	RequeueAfter time.Duration
+}
+
+// Returns the time the tenant should be suspended at, which is the earliest of the expiration date and the end of
+// the inactivity period. The inactivity is counted from the last activity, or from the creation of the tenant if
+// no activity is observed yet. Returns false if the tenant has neither of them.
+func ReclamationDeadline(t *multitenancyv1.Tenant) (time.Time, string, bool) {
+	var deadline time.Time
+	var reason string
+
+	if t.Spec.ExpirationDate != nil {
+		deadline = t.Spec.ExpirationDate.Time
+		reason = multitenancyv1.TenantReasonExpired
+	}
+
+	if t.Spec.InactivityDays > 0 {
+		lastActivity := t.GetCreationTimestamp().Time
+		if t.Status.LastActivity != nil && t.Status.LastActivity.After(lastActivity) {
+			lastActivity = t.Status.LastActivity.Time
+		}
+
+		inactivityDeadline := lastActivity.Add(time.Duration(t.Spec.InactivityDays) * 24 * time.Hour)
+		if reason == "" || inactivityDeadline.Before(deadline) {
+			deadline = inactivityDeadline
+			reason = multitenancyv1.TenantReasonInactive
+		}
+	}
+
+	// The deadline is kept in the status, which only has a precision of seconds.
+	return deadline.Truncate(time.Second), reason, reason != ""
+}
+
+// Decides the next step of the reclamation of the tenant at the given time. The tenant is warned once per lead
+// time as its deadline approaches, suspended at the deadline and deleted when the grace period after the
+// suspension ends.
+func EvaluateReclamation(t *multitenancyv1.Tenant, policy ReclamationPolicy, now time.Time) ReclamationDecision {
+	deadline, reason, ok := ReclamationDeadline(t)
+	if !ok {
+		return ReclamationDecision{Action: ReclamationActionNone}
+	}
+
+	decision := ReclamationDecision{Action: ReclamationActionNone, Reason: reason, Deadline: deadline}
+	reclamation := t.Status.Reclamation
+
+	// Already suspended by the reclamation, only the deletion is left.
+	if reclamation != nil && reclamation.SuspendedAt != nil {
+		if policy.GracePeriod <= 0 {
+			return decision
+		}
+
+		deletion := reclamation.SuspendedAt.Add(policy.GracePeriod)
+		if !now.Before(deletion) {
+			decision.Action = ReclamationActionDelete
+			return decision
+		}
+
+		decision.RequeueAfter = deletion.Sub(now)
+		return decision
+	}
+
+	remaining := deadline.Sub(now)
+	if remaining <= 0 {
+		decision.Action = ReclamationActionSuspend
+		decision.RequeueAfter = policy.GracePeriod
+		return decision
+	}
+
+	// The most urgent lead time that has been reached is warned, the next one schedules the evaluation.
+	var reached time.Duration
+	next := remaining
+	for _, lead := range policy.WarningLeadTimes {
+		if lead <= 0 {
+			continue
+		}
+		if lead >= remaining {
+			if reached == 0 || lead < reached {
+				reached = lead
+			}
+		} else if remaining-lead < next {
+			next = remaining - lead
+		}
+	}
+
+	decision.RequeueAfter = next
+
+	if reached != 0 && !isReclamationWarned(reclamation, deadline, reached) {
+		decision.Action = ReclamationActionWarn
+		decision.LeadTime = reached
+	}
+
+	return decision
+}
+
+// Checks if a warning with the same or a shorter lead time has been sent for the deadline. A new deadline, for
+// example after the expiration date is extended, is warned again.
+func isReclamationWarned(reclamation *multitenancyv1.TenantReclamation, deadline time.Time, lead time.Duration) bool {
+	return reclamation != nil &&
+		reclamation.Deadline.Time.Equal(deadline) &&
+		reclamation.WarnedLeadTime != nil &&
+		reclamation.WarnedLeadTime.Duration <= lead
+}
+
+// Returns the last activity observed in the namespaces of the tenant. A running or pending pod means the tenant
+// is active now, otherwise the latest creation of a pod, a workload, a SubNamespace or a role request is taken.
+// Returns the zero time if there is no activity at all.
+func (m *multiTenancyManager) GetTenantActivity(ctx context.Context, t *multitenancyv1.Tenant) (time.Time, error) {
+	namespaces, err := m.getTenantNamespaces(ctx, t)
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var activity time.Time
+	observe := func(obj client.Object) {
+		if created := obj.GetCreationTimestamp().Time; created.After(activity) {
+			activity = created
+		}
+	}
+
+	for _, namespace := range namespaces {
+		podList := &corev1.PodList{}
+
+		if err := m.client.List(ctx, podList, client.InNamespace(namespace.GetName())); err != nil {
+			return time.Time{}, err
+		}
+
+		for i := range podList.Items {
+			pod := &podList.Items[i]
+			if pod.Status.Phase == corev1.PodRunning || pod.Status.Phase == corev1.PodPending {
+				return time.Now(), nil
+			}
+			observe(pod)
+		}
+
+		lists := []client.ObjectList{
+			&appsv1.DeploymentList{},
+			&appsv1.StatefulSetList{},
+			&multitenancyv1.SubNamespaceList{},
+			&multitenancyv1.RoleRequestList{},
+		}
+
+		for _, list := range lists {
+			if err := m.client.List(ctx, list, client.InNamespace(namespace.GetName())); err != nil {
+				return time.Time{}, err
+			}
+
+			items, err := meta.ExtractList(list)
+			if err != nil {
+				return time.Time{}, err
+			}
+
+			for _, item := range items {
+				if obj, ok := item.(client.Object); ok {
+					observe(obj)
+				}
+			}
+		}
+	}
+
+	return activity, nil
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multitenancy
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+)
+
+var _ = Describe("Reclamation", func() {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	policy := ReclamationPolicy{WarningLeadTimes: []time.Duration{7 * day, day}, GracePeriod: 30 * day}
+
+	newTenant := func(expiration time.Time) *multitenancyv1.Tenant {
+		date := metav1.NewTime(expiration)
+		return &multitenancyv1.Tenant{
+			ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(now.Add(-100 * day))},
+			Spec:       multitenancyv1.TenantSpec{ExpirationDate: &date},
+		}
+	}
+
+	It("should ignore the tenants without a deadline", func() {
+		decision := EvaluateReclamation(&multitenancyv1.Tenant{}, policy, now)
+		Expect(decision.Action).To(Equal(ReclamationActionNone))
+		Expect(decision.RequeueAfter).To(BeZero())
+	})
+
+	It("should schedule the first warning", func() {
+		decision := EvaluateReclamation(newTenant(now.Add(10*day)), policy, now)
+		Expect(decision.Action).To(Equal(ReclamationActionNone))
+		Expect(decision.Reason).To(Equal(multitenancyv1.TenantReasonExpired))
+		Expect(decision.RequeueAfter).To(Equal(3 * day))
+	})
+
+	It("should warn once per lead time", func() {
+		tenant := newTenant(now.Add(5 * day))
+		decision := EvaluateReclamation(tenant, policy, now)
+		Expect(decision.Action).To(Equal(ReclamationActionWarn))
+		Expect(decision.LeadTime).To(Equal(7 * day))
+		Expect(decision.RequeueAfter).To(Equal(4 * day))
+
+		tenant.Status.Reclamation = &multitenancyv1.TenantReclamation{
+			Deadline:       metav1.NewTime(decision.Deadline),
+			WarnedLeadTime: &metav1.Duration{Duration: decision.LeadTime},
+		}
+		Expect(EvaluateReclamation(tenant, policy, now).Action).To(Equal(ReclamationActionNone))
+		Expect(EvaluateReclamation(tenant, policy, now.Add(4*day)).LeadTime).To(Equal(day))
+
+		By("extending the expiration date")
+		date := metav1.NewTime(now.Add(6 * day))
+		tenant.Spec.ExpirationDate = &date
+		Expect(EvaluateReclamation(tenant, policy, now).Action).To(Equal(ReclamationActionWarn))
+	})
+
+	It("should suspend at the deadline and delete after the grace period", func() {
+		tenant := newTenant(now)
+		decision := EvaluateReclamation(tenant, policy, now)
+		Expect(decision.Action).To(Equal(ReclamationActionSuspend))
+		Expect(decision.RequeueAfter).To(Equal(30 * day))
+
+		suspendedAt := metav1.NewTime(now)
+		tenant.Status.Reclamation = &multitenancyv1.TenantReclamation{SuspendedAt: &suspendedAt}
+		Expect(EvaluateReclamation(tenant, policy, now.Add(day)).Action).To(Equal(ReclamationActionNone))
+		Expect(EvaluateReclamation(tenant, policy, now.Add(30*day)).Action).To(Equal(ReclamationActionDelete))
+		Expect(EvaluateReclamation(tenant, ReclamationPolicy{}, now.Add(30*day)).Action).To(Equal(ReclamationActionNone))
+	})
+
+	It("should count the inactivity from the last activity", func() {
+		tenant := &multitenancyv1.Tenant{
+			ObjectMeta: metav1.ObjectMeta{CreationTimestamp: metav1.NewTime(now.Add(-100 * day))},
+			Spec:       multitenancyv1.TenantSpec{InactivityDays: 30},
+		}
+		Expect(EvaluateReclamation(tenant, policy, now).Action).To(Equal(ReclamationActionSuspend))
+
+		lastActivity := metav1.NewTime(now.Add(-25 * day))
+		tenant.Status.LastActivity = &lastActivity
+		decision := EvaluateReclamation(tenant, policy, now)
+		Expect(decision.Action).To(Equal(ReclamationActionWarn))
+		Expect(decision.Reason).To(Equal(multitenancyv1.TenantReasonInactive))
+		Expect(decision.Deadline).To(Equal(now.Add(5 * day)))
+
+		By("taking the earliest deadline")
+		date := metav1.NewTime(now.Add(2 * day))
+		tenant.Spec.ExpirationDate = &date
+		Expect(EvaluateReclamation(tenant, policy, now).Reason).To(Equal(multitenancyv1.TenantReasonExpired))
+	})
+})
@@ -0,0 +1,194 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multitenancy
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Resolves the name of the role binding created for a role request.
+func ResolveRoleRequestRoleBindingName(r *multitenancyv1.RoleRequest) string {
+	return fmt.Sprintf("edgenet:rolerequest-%s", r.GetName())
+}
+
+// Creates the role bindings for the approved role request. Role requests can only be created in the core
+// namespace of a tenant, the role binding is then created in every namespace of the tenant. Nothing is created
+// while the tenant is suspended, the role bindings are created when the tenant is resumed.
+func (m *multiTenancyManager) CreateRoleRequestRoleBindings(ctx context.Context, r *multitenancyv1.RoleRequest) error {
+	t, err := m.getRoleRequestTenant(ctx, r)
+
+	if err != nil {
+		return err
+	}
+
+	if t.Spec.Suspended {
+		return nil
+	}
+
+	namespaces, err := m.getTenantNamespaces(ctx, t)
+
+	if err != nil {
+		return err
+	}
+
+	for _, namespace := range namespaces {
+		if err := m.createRoleRequestRoleBinding(ctx, t, r, namespace.GetName()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Removes the role bindings of the role request from all of the namespaces of the tenant. If the tenant
+// doesn't exist anymore there is nothing to clean.
+func (m *multiTenancyManager) RoleRequestCleanup(ctx context.Context, r *multitenancyv1.RoleRequest) error {
+	t, err := m.getRoleRequestTenant(ctx, r)
+
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	namespaces, err := m.getTenantNamespaces(ctx, t)
+
+	if err != nil {
+		return err
+	}
+
+	for _, namespace := range namespaces {
+		err := m.client.DeleteAllOf(ctx, &rbacv1.RoleBinding{},
+			client.InNamespace(namespace.GetName()),
+			client.MatchingLabels{"edge-net.io/role-request": r.GetName()},
+		)
+
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Creates the role bindings of all of the approved role requests of the tenant in the given namespace.
+func (m *multiTenancyManager) createRoleRequestRoleBindingsInNamespace(ctx context.Context, t *multitenancyv1.Tenant, namespace string) error {
+	roleRequestList := &multitenancyv1.RoleRequestList{}
+
+	if err := m.client.List(ctx, roleRequestList, client.InNamespace(t.GetName())); err != nil {
+		return err
+	}
+
+	for i := range roleRequestList.Items {
+		r := &roleRequestList.Items[i]
+
+		if r.Status.State != multitenancyv1.RoleRequestStateApproved || !r.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+
+		if err := m.createRoleRequestRoleBinding(ctx, t, r, namespace); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Creates or updates the role binding of a single role request in the given namespace.
+func (m *multiTenancyManager) createRoleRequestRoleBinding(ctx context.Context, t *multitenancyv1.Tenant, r *multitenancyv1.RoleRequest, namespace string) error {
+	kind := r.Spec.Kind
+	if kind == "" {
+		kind = rbacv1.UserKind
+	}
+
+	subject, err := m.config.Subjects.MapSubject(kind, r.Spec.Subject, "")
+
+	if err != nil {
+		return err
+	}
+
+	subjects := []rbacv1.Subject{subject}
+	roleRef := rbacv1.RoleRef{
+		APIGroup: "rbac.authorization.k8s.io",
+		Kind:     "ClusterRole",
+		Name:     r.Spec.Role.ClusterRoleName(),
+	}
+
+	roleBinding := &rbacv1.RoleBinding{}
+	err = m.client.Get(ctx, types.NamespacedName{Name: ResolveRoleRequestRoleBindingName(r), Namespace: namespace}, roleBinding)
+
+	if err == nil {
+		if reflect.DeepEqual(roleBinding.RoleRef, roleRef) {
+			if reflect.DeepEqual(roleBinding.Subjects, subjects) {
+				return nil
+			}
+			roleBinding.Subjects = subjects
+			return m.client.Update(ctx, roleBinding)
+		}
+
+		// The role reference cannot be changed, recreate the role binding.
+		if err := m.client.Delete(ctx, roleBinding); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+
+	roleBinding = &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ResolveRoleRequestRoleBindingName(r),
+			Namespace: namespace,
+			Labels: map[string]string{
+				"edge-net.io/generated":    "true",
+				"edge-net.io/notification": "true",
+				"edge-net.io/tenant":       t.GetName(),
+				"edge-net.io/tenant-role":  string(r.Spec.Role),
+				"edge-net.io/role-request": r.GetName(),
+			},
+		},
+		Subjects: subjects,
+		RoleRef:  roleRef,
+	}
+
+	return m.client.Create(ctx, roleBinding)
+}
+
+// Gets the tenant of the role request. The role request should be in the core namespace of the tenant.
+func (m *multiTenancyManager) getRoleRequestTenant(ctx context.Context, r *multitenancyv1.RoleRequest) (*multitenancyv1.Tenant, error) {
+	namespace := &corev1.Namespace{}
+
+	if err := m.client.Get(ctx, types.NamespacedName{Name: r.GetNamespace()}, namespace); err != nil {
+		return nil, err
+	}
+
+	if namespace.GetLabels()["edge-net.io/kind"] != "core" {
+		return nil, fmt.Errorf("role request %q is not in the core namespace of a tenant", r.GetName())
+	}
+
+	return m.getRootTenant(ctx, namespace.GetName())
+}
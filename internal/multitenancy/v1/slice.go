@@ -0,0 +1,404 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multitenancy
+
+import (
+	"context"
+	"encoding/json"
+	errors2 "errors"
+	"fmt"
+	"sort"
+	"time"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The tolerations in this annotation are added to the pods of the namespace by the PodTolerationRestriction
+// admission plugin of the API server. The plugin is disabled by default, the pod webhook adds the tolerations of
+// the slices to the pods as well.
+const DefaultTolerationsAnnotation = "scheduler.alpha.kubernetes.io/defaultTolerations"
+
+// ErrInsufficientNodes is returned when there are not enough free nodes matching the slice. No nodes are
+// reserved in this case, the reservation should be retried later.
+var ErrInsufficientNodes = errors2.New("not enough nodes are available")
+
+// ErrSliceLimitExceeded is returned when the slice can never be reserved within the SliceLimits, for example when
+// its duration is too long. The nodes of the slice should be released.
+var ErrSliceLimitExceeded = errors2.New("the slice exceeds the limits")
+
+// SliceLimits bounds the reservations of the tenants, the zero values disable the limits.
+type SliceLimits struct {
+	// Maximum number of nodes reserved by the slices of a tenant at the same time.
+	MaxNodesPerTenant int
+
+	// Maximum duration of a reservation.
+	MaxDuration time.Duration
+}
+
+// Resolves the name of the slice created for a claim. The UID is used since the slices are cluster-scoped and
+// the name is used as a label value on the nodes.
+func ResolveSliceName(c *multitenancyv1.SliceClaim) string {
+	return string(c.GetUID())
+}
+
+// Creates the slice of the claim if it doesn't exist yet. The profile of the slice is fixed once created.
+func (m *multiTenancyManager) CreateClaimSlice(ctx context.Context, c *multitenancyv1.SliceClaim) (*multitenancyv1.Slice, error) {
+	slice := &multitenancyv1.Slice{}
+
+	err := m.client.Get(ctx, types.NamespacedName{Name: ResolveSliceName(c)}, slice)
+
+	if err == nil || !errors.IsNotFound(err) {
+		return slice, err
+	}
+
+	slice = &multitenancyv1.Slice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: ResolveSliceName(c),
+			Labels: map[string]string{
+				"edge-net.io/generated": "true",
+			},
+		},
+		Spec: multitenancyv1.SliceSpec{
+			ClaimRef: multitenancyv1.SliceClaimReference{
+				Namespace: c.GetNamespace(),
+				Name:      c.GetName(),
+			},
+			SliceProfile: *c.Spec.SliceProfile.DeepCopy(),
+		},
+	}
+
+	if err := m.client.Create(ctx, slice); err != nil {
+		return nil, err
+	}
+
+	return slice, nil
+}
+
+// Deletes the slice of the claim. The nodes are released by the cleanup of the slice.
+func (m *multiTenancyManager) SliceClaimCleanup(ctx context.Context, c *multitenancyv1.SliceClaim) error {
+	slice := &multitenancyv1.Slice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: ResolveSliceName(c),
+		},
+	}
+
+	return client.IgnoreNotFound(m.client.Delete(ctx, slice))
+}
+
+// Reserves the nodes of the slice. The nodes are labelled and tainted with the name of the slice and the
+// toleration of the taint is added to the claiming namespace. Nodes are reserved all at once, if there are not
+// enough free nodes, or the tenant of the claiming namespace already reserves too many nodes, ErrInsufficientNodes
+// is returned and nothing is changed. If a node or the namespace cannot be updated, the nodes reserved by this call
+// are released again before the error is returned.
+func (m *multiTenancyManager) ReserveSliceNodes(ctx context.Context, s *multitenancyv1.Slice) ([]string, error) {
+	limits := m.config.SliceLimits
+	if limits.MaxDuration > 0 && s.Spec.Duration.Duration > limits.MaxDuration {
+		return nil, fmt.Errorf("%w: the duration %s is longer than %s", ErrSliceLimitExceeded, s.Spec.Duration.Duration, limits.MaxDuration)
+	}
+
+	nodeList := &corev1.NodeList{}
+
+	if err := m.client.List(ctx, nodeList); err != nil {
+		return nil, err
+	}
+
+	selector := labels.Everything()
+	if s.Spec.NodeSelector != nil {
+		var err error
+		if selector, err = metav1.LabelSelectorAsSelector(s.Spec.NodeSelector); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(nodeList.Items, func(i, j int) bool {
+		return nodeList.Items[i].GetName() < nodeList.Items[j].GetName()
+	})
+
+	// Keep the nodes reserved before, then pick the free nodes in the order of their names.
+	reserved := []*corev1.Node{}
+	free := []*corev1.Node{}
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+		owner, ok := node.GetLabels()[multitenancyv1.SliceLabel]
+		switch {
+		case ok && owner == s.GetName():
+			reserved = append(reserved, node)
+		case !ok && utils.IsNodeSchedulable(node) && selector.Matches(labels.Set(node.GetLabels())) && hasAllocatable(node, s.Spec.Resources):
+			free = append(free, node)
+		}
+	}
+
+	if limits.MaxNodesPerTenant > 0 {
+		tenant, others, err := m.countTenantSliceNodes(ctx, s, nodeList.Items)
+		if err != nil {
+			return nil, err
+		}
+		if tenant != "" && s.Spec.Quantity > limits.MaxNodesPerTenant {
+			return nil, fmt.Errorf("%w: %d nodes are more than the %d nodes a tenant can reserve", ErrSliceLimitExceeded, s.Spec.Quantity, limits.MaxNodesPerTenant)
+		}
+		if tenant != "" && others+s.Spec.Quantity > limits.MaxNodesPerTenant {
+			return nil, fmt.Errorf("%w: tenant %s already reserves %d of %d nodes", ErrInsufficientNodes, tenant, others, limits.MaxNodesPerTenant)
+		}
+	}
+
+	if len(reserved)+len(free) < s.Spec.Quantity {
+		return nil, fmt.Errorf("%w: %d of %d nodes", ErrInsufficientNodes, len(reserved)+len(free), s.Spec.Quantity)
+	}
+
+	if missing := s.Spec.Quantity - len(reserved); missing > 0 {
+		reserved = append(reserved, free[:missing]...)
+	}
+
+	// The nodes reserved by this call, they are released if the reservation fails.
+	added := []*corev1.Node{}
+	names := []string{}
+	for _, node := range reserved {
+		isNew := node.GetLabels()[multitenancyv1.SliceLabel] != s.GetName()
+		if err := m.reserveNode(ctx, s, node); err != nil {
+			return nil, m.rollbackSliceNodes(ctx, added, err)
+		}
+		if isNew {
+			added = append(added, node)
+		}
+		names = append(names, node.GetName())
+	}
+
+	if err := m.updateNamespaceToleration(ctx, s, true); err != nil {
+		return nil, m.rollbackSliceNodes(ctx, added, err)
+	}
+
+	return names, nil
+}
+
+// Releases the nodes reserved by a failed reservation. Returns the error of the reservation, joined with the errors
+// of the release.
+func (m *multiTenancyManager) rollbackSliceNodes(ctx context.Context, nodes []*corev1.Node, err error) error {
+	errs := []error{err}
+	for _, node := range nodes {
+		if err := m.releaseNode(ctx, node); err != nil {
+			errs = append(errs, fmt.Errorf("cannot release node %s: %w", node.GetName(), err))
+		}
+	}
+	return errors2.Join(errs...)
+}
+
+// Counts the nodes reserved by the other slices of the tenant of the claiming namespace. The tenant is empty if the
+// namespace doesn't belong to a tenant.
+func (m *multiTenancyManager) countTenantSliceNodes(ctx context.Context, s *multitenancyv1.Slice, nodes []corev1.Node) (string, int, error) {
+	namespace := &corev1.Namespace{}
+	if err := m.client.Get(ctx, types.NamespacedName{Name: s.Spec.ClaimRef.Namespace}, namespace); err != nil {
+		return "", 0, err
+	}
+
+	tenant := namespace.GetLabels()["edge-net.io/tenant"]
+	if tenant == "" {
+		return "", 0, nil
+	}
+
+	namespaceList := &corev1.NamespaceList{}
+	if err := m.client.List(ctx, namespaceList, client.MatchingLabels{"edge-net.io/tenant": tenant}); err != nil {
+		return "", 0, err
+	}
+	namespaces := map[string]bool{}
+	for _, ns := range namespaceList.Items {
+		namespaces[ns.GetName()] = true
+	}
+
+	sliceList := &multitenancyv1.SliceList{}
+	if err := m.client.List(ctx, sliceList); err != nil {
+		return "", 0, err
+	}
+	slices := map[string]bool{}
+	for _, other := range sliceList.Items {
+		if other.GetName() != s.GetName() && namespaces[other.Spec.ClaimRef.Namespace] {
+			slices[other.GetName()] = true
+		}
+	}
+
+	count := 0
+	for _, node := range nodes {
+		if slices[node.GetLabels()[multitenancyv1.SliceLabel]] {
+			count++
+		}
+	}
+
+	return tenant, count, nil
+}
+
+// Removes the labels and the taints of the slice from the nodes and the toleration from the claiming namespace.
+func (m *multiTenancyManager) ReleaseSliceNodes(ctx context.Context, s *multitenancyv1.Slice) error {
+	nodeList := &corev1.NodeList{}
+
+	if err := m.client.List(ctx, nodeList, client.MatchingLabels{multitenancyv1.SliceLabel: s.GetName()}); err != nil {
+		return err
+	}
+
+	// The other nodes are released even if one of them fails, the failed ones are released on the next attempt.
+	errs := []error{}
+	for i := range nodeList.Items {
+		if err := m.releaseNode(ctx, &nodeList.Items[i]); err != nil {
+			errs = append(errs, fmt.Errorf("cannot release node %s: %w", nodeList.Items[i].GetName(), err))
+		}
+	}
+
+	if err := m.updateNamespaceToleration(ctx, s, false); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors2.Join(errs...)
+}
+
+// Removes the label and the taint of the slice from the node. The node is patched, so the writes of the kubelet
+// don't conflict with it.
+func (m *multiTenancyManager) releaseNode(ctx context.Context, node *corev1.Node) error {
+	original := node.DeepCopy()
+
+	nodeLabels := node.GetLabels()
+	delete(nodeLabels, multitenancyv1.SliceLabel)
+	node.SetLabels(nodeLabels)
+
+	taints := []corev1.Taint{}
+	for _, taint := range node.Spec.Taints {
+		if taint.Key != multitenancyv1.SliceLabel {
+			taints = append(taints, taint)
+		}
+	}
+	node.Spec.Taints = taints
+
+	return client.IgnoreNotFound(m.client.Patch(ctx, node, client.MergeFrom(original)))
+}
+
+// Labels and taints the node for the slice. The node is patched like in releaseNode.
+func (m *multiTenancyManager) reserveNode(ctx context.Context, s *multitenancyv1.Slice, node *corev1.Node) error {
+	taint := sliceTaint(s)
+
+	hasTaint := false
+	for _, t := range node.Spec.Taints {
+		if t.MatchTaint(&taint) && t.Value == taint.Value {
+			hasTaint = true
+			break
+		}
+	}
+
+	if hasTaint && node.GetLabels()[multitenancyv1.SliceLabel] == s.GetName() {
+		return nil
+	}
+
+	original := node.DeepCopy()
+	node.SetLabels(utils.MergeLabels(node.GetLabels(), map[string]string{multitenancyv1.SliceLabel: s.GetName()}))
+	if !hasTaint {
+		node.Spec.Taints = append(node.Spec.Taints, taint)
+	}
+
+	return m.client.Patch(ctx, node, client.MergeFrom(original))
+}
+
+// Adds or removes the toleration of the slice in the default tolerations of the claiming namespace.
+func (m *multiTenancyManager) updateNamespaceToleration(ctx context.Context, s *multitenancyv1.Slice, add bool) error {
+	namespace := &corev1.Namespace{}
+
+	if err := m.client.Get(ctx, types.NamespacedName{Name: s.Spec.ClaimRef.Namespace}, namespace); err != nil {
+		// The namespace might be deleted before the slice is released.
+		if errors.IsNotFound(err) && !add {
+			return nil
+		}
+		return err
+	}
+
+	tolerations := []corev1.Toleration{}
+	if value, ok := namespace.GetAnnotations()[DefaultTolerationsAnnotation]; ok && value != "" {
+		if err := json.Unmarshal([]byte(value), &tolerations); err != nil {
+			return fmt.Errorf("invalid %s annotation on namespace %s: %w", DefaultTolerationsAnnotation, namespace.GetName(), err)
+		}
+	}
+
+	toleration := SliceToleration(s)
+	updated := []corev1.Toleration{}
+	exists := false
+	for _, t := range tolerations {
+		if t.MatchToleration(&toleration) {
+			exists = true
+			if !add {
+				continue
+			}
+		}
+		updated = append(updated, t)
+	}
+
+	if exists == add {
+		return nil
+	}
+
+	if add {
+		updated = append(updated, toleration)
+	}
+
+	annotations := namespace.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	if len(updated) == 0 {
+		delete(annotations, DefaultTolerationsAnnotation)
+	} else {
+		data, err := json.Marshal(updated)
+		if err != nil {
+			return err
+		}
+		annotations[DefaultTolerationsAnnotation] = string(data)
+	}
+	namespace.SetAnnotations(annotations)
+
+	return m.client.Update(ctx, namespace)
+}
+
+// The taint keeps the pods of the other namespaces away from the nodes of the slice.
+func sliceTaint(s *multitenancyv1.Slice) corev1.Taint {
+	return corev1.Taint{
+		Key:    multitenancyv1.SliceLabel,
+		Value:  s.GetName(),
+		Effect: corev1.TaintEffectNoSchedule,
+	}
+}
+
+// SliceToleration is the toleration of the slice taint, the pods of the claiming namespace are given it.
+func SliceToleration(s *multitenancyv1.Slice) corev1.Toleration {
+	return corev1.Toleration{
+		Key:      multitenancyv1.SliceLabel,
+		Operator: corev1.TolerationOpEqual,
+		Value:    s.GetName(),
+		Effect:   corev1.TaintEffectNoSchedule,
+	}
+}
+
+// Checks if the allocatable resources of the node are at least the requested resources.
+func hasAllocatable(node *corev1.Node, resources corev1.ResourceList) bool {
+	for name, quantity := range resources {
+		allocatable, ok := node.Status.Allocatable[name]
+		if !ok || allocatable.Cmp(quantity) < 0 {
+			return false
+		}
+	}
+	return true
+}
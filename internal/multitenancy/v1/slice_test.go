@@ -0,0 +1,150 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multitenancy
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+)
+
+var _ = Describe("Slice", func() {
+	ctx := context.Background()
+
+	node := func(name string, sliceName string) *corev1.Node {
+		n := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{}},
+			Status:     corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}},
+		}
+		if sliceName != "" {
+			n.Labels[multitenancyv1.SliceLabel] = sliceName
+		}
+		return n
+	}
+
+	slice := func(name, namespace string, quantity int) *multitenancyv1.Slice {
+		return &multitenancyv1.Slice{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: multitenancyv1.SliceSpec{
+				ClaimRef:     multitenancyv1.SliceClaimReference{Namespace: namespace, Name: name},
+				SliceProfile: multitenancyv1.SliceProfile{Quantity: quantity, Duration: metav1.Duration{Duration: time.Hour}},
+			},
+		}
+	}
+
+	newManager := func(limits SliceLimits, funcs interceptor.Funcs, objects ...client.Object) (*multiTenancyManager, client.Client) {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(multitenancyv1.AddToScheme(scheme)).To(Succeed())
+
+		objects = append(objects,
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "lab", Labels: map[string]string{"edge-net.io/tenant": "lab"}}},
+			&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "lab-team", Labels: map[string]string{"edge-net.io/tenant": "lab"}}},
+		)
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).WithInterceptorFuncs(funcs).Build()
+		return &multiTenancyManager{client: c, config: Config{SliceLimits: limits}}, c
+	}
+
+	It("should reserve the free nodes", func() {
+		m, c := newManager(SliceLimits{}, interceptor.Funcs{}, node("node-1", ""), node("node-2", ""))
+
+		names, err := m.ReserveSliceNodes(ctx, slice("alpha", "lab", 2))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names).To(ConsistOf("node-1", "node-2"))
+
+		reserved := &corev1.Node{}
+		Expect(c.Get(ctx, client.ObjectKey{Name: "node-1"}, reserved)).To(Succeed())
+		Expect(reserved.Labels).To(HaveKeyWithValue(multitenancyv1.SliceLabel, "alpha"))
+		Expect(reserved.Spec.Taints).To(HaveLen(1))
+	})
+
+	It("should release the nodes reserved by a failed reservation", func() {
+		failing := interceptor.Funcs{
+			Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+				if _, ok := obj.(*corev1.Namespace); ok {
+					return apierrors.NewConflict(schema.GroupResource{Resource: "namespaces"}, obj.GetName(), errors.New("conflict"))
+				}
+				return c.Update(ctx, obj, opts...)
+			},
+		}
+		m, c := newManager(SliceLimits{}, failing, node("node-1", "alpha"), node("node-2", ""))
+
+		_, err := m.ReserveSliceNodes(ctx, slice("alpha", "lab", 2))
+		Expect(apierrors.IsConflict(err)).To(BeTrue())
+
+		// The node reserved before is kept, the one reserved by the failed call is released.
+		nodeList := &corev1.NodeList{}
+		Expect(c.List(ctx, nodeList, client.MatchingLabels{multitenancyv1.SliceLabel: "alpha"})).To(Succeed())
+		Expect(nodeList.Items).To(HaveLen(1))
+		Expect(nodeList.Items[0].GetName()).To(Equal("node-1"))
+	})
+
+	It("should release the other nodes if one of them fails", func() {
+		failing := interceptor.Funcs{
+			Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+				if obj.GetName() == "node-1" {
+					return apierrors.NewServiceUnavailable("unavailable")
+				}
+				return c.Patch(ctx, obj, patch, opts...)
+			},
+		}
+		m, c := newManager(SliceLimits{}, failing, node("node-1", "alpha"), node("node-2", "alpha"))
+
+		Expect(m.ReleaseSliceNodes(ctx, slice("alpha", "lab", 2))).To(MatchError(ContainSubstring("node-1")))
+
+		released := &corev1.Node{}
+		Expect(c.Get(ctx, types.NamespacedName{Name: "node-2"}, released)).To(Succeed())
+		Expect(released.Labels).NotTo(HaveKey(multitenancyv1.SliceLabel))
+		Expect(released.Spec.Taints).To(BeEmpty())
+	})
+
+	It("should enforce the limits of the tenants", func() {
+		limits := SliceLimits{MaxNodesPerTenant: 2, MaxDuration: 24 * time.Hour}
+		m, _ := newManager(limits, interceptor.Funcs{}, slice("beta", "lab-team", 1), node("node-1", "beta"), node("node-2", ""), node("node-3", ""))
+
+		long := slice("alpha", "lab", 1)
+		long.Spec.Duration.Duration = 48 * time.Hour
+		_, err := m.ReserveSliceNodes(ctx, long)
+		Expect(errors.Is(err, ErrSliceLimitExceeded)).To(BeTrue())
+
+		_, err = m.ReserveSliceNodes(ctx, slice("alpha", "lab", 3))
+		Expect(errors.Is(err, ErrSliceLimitExceeded)).To(BeTrue())
+
+		// The other slice of the tenant already reserves a node.
+		_, err = m.ReserveSliceNodes(ctx, slice("alpha", "lab", 2))
+		Expect(errors.Is(err, ErrInsufficientNodes)).To(BeTrue())
+
+		names, err := m.ReserveSliceNodes(ctx, slice("alpha", "lab", 1))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(names).To(ConsistOf("node-2"))
+	})
+})
@@ -0,0 +1,196 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multitenancy
+
+import (
+	"fmt"
+	"strings"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// These are the prefixes that can be used in the admin field of the tenant to select the kind of the subject.
+const (
+	groupIdentityPrefix          = "group:"
+	serviceAccountIdentityPrefix = "serviceaccount:"
+	systemServiceAccountPrefix   = "system:serviceaccount:"
+)
+
+// SubjectMapper maps the identities written in the tenant objects to the RBAC subjects. The prefixes should
+// be the same as the --oidc-username-prefix and --oidc-groups-prefix flags of the API server, so the role
+// bindings match the users the API server actually authenticates.
+type SubjectMapper struct {
+	// Prefix added to the user names, for example "oidc:".
+	UsernamePrefix string
+
+	// Prefix added to the group names.
+	GroupPrefix string
+}
+
+// Maps an identity string to a subject. The identity can be one of the following;
+//
+//	"alice"                              -> User "<prefix>alice"
+//	"group:researchers"                  -> Group "<prefix>researchers"
+//	"serviceaccount:<namespace>:<name>"  -> ServiceAccount
+//	"system:serviceaccount:<ns>:<name>"  -> ServiceAccount
+func (m SubjectMapper) MapIdentity(identity string) (rbacv1.Subject, error) {
+	switch {
+	case strings.HasPrefix(identity, groupIdentityPrefix):
+		return m.MapSubject(rbacv1.GroupKind, strings.TrimPrefix(identity, groupIdentityPrefix), "")
+	case strings.HasPrefix(identity, serviceAccountIdentityPrefix), strings.HasPrefix(identity, systemServiceAccountPrefix):
+		parts := strings.Split(strings.TrimPrefix(strings.TrimPrefix(identity, "system:"), serviceAccountIdentityPrefix), ":")
+		if len(parts) != 2 {
+			return rbacv1.Subject{}, fmt.Errorf("service account %q should be in the form serviceaccount:<namespace>:<name>", identity)
+		}
+		return m.MapSubject(rbacv1.ServiceAccountKind, parts[1], parts[0])
+	default:
+		return m.MapSubject(rbacv1.UserKind, identity, "")
+	}
+}
+
+// Maps a member of the tenant to a subject.
+func (m SubjectMapper) MapMember(member multitenancyv1.TenantMember) (rbacv1.Subject, error) {
+	kind := member.Kind
+	if kind == "" {
+		kind = rbacv1.UserKind
+	}
+	return m.MapSubject(kind, member.Name, member.Namespace)
+}
+
+// Creates a subject of the given kind. The configured prefixes are added to the users and the groups if the
+// name doesn't already have it. The namespace is only used for the service accounts.
+func (m SubjectMapper) MapSubject(kind, name, namespace string) (rbacv1.Subject, error) {
+	if name == "" {
+		return rbacv1.Subject{}, fmt.Errorf("the name of the %s subject is empty", kind)
+	}
+
+	switch kind {
+	case rbacv1.UserKind:
+		if strings.HasPrefix(name, systemServiceAccountPrefix) {
+			return m.MapIdentity(name)
+		}
+		return rbacv1.Subject{
+			Kind:     rbacv1.UserKind,
+			APIGroup: rbacv1.GroupName,
+			Name:     addPrefix(m.UsernamePrefix, name),
+		}, nil
+	case rbacv1.GroupKind:
+		return rbacv1.Subject{
+			Kind:     rbacv1.GroupKind,
+			APIGroup: rbacv1.GroupName,
+			Name:     addPrefix(m.GroupPrefix, name),
+		}, nil
+	case rbacv1.ServiceAccountKind:
+		if errs := validation.IsDNS1123Label(namespace); len(errs) != 0 {
+			return rbacv1.Subject{}, fmt.Errorf("invalid namespace %q for service account %q: %s", namespace, name, strings.Join(errs, ", "))
+		}
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) != 0 {
+			return rbacv1.Subject{}, fmt.Errorf("invalid service account name %q: %s", name, strings.Join(errs, ", "))
+		}
+		return rbacv1.Subject{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      name,
+			Namespace: namespace,
+		}, nil
+	default:
+		return rbacv1.Subject{}, fmt.Errorf("unknown subject kind %q", kind)
+	}
+}
+
+// Groups the subjects of the tenant by their roles. The admin specified in the spec always has the admin role.
+// Each subject appears at most once per role. Returns an error if an identity cannot be mapped to a subject, the
+// Tenant webhook uses it to reject the invalid subjects before they reach the controller.
+func (m SubjectMapper) TenantRoleSubjects(t *multitenancyv1.Tenant) (map[multitenancyv1.TenantRole][]rbacv1.Subject, error) {
+	subjects := map[multitenancyv1.TenantRole][]rbacv1.Subject{}
+	seen := map[multitenancyv1.TenantRole]map[rbacv1.Subject]bool{}
+
+	add := func(role multitenancyv1.TenantRole, subject rbacv1.Subject) {
+		if seen[role] == nil {
+			seen[role] = map[rbacv1.Subject]bool{}
+		}
+		if seen[role][subject] {
+			return
+		}
+		seen[role][subject] = true
+		subjects[role] = append(subjects[role], subject)
+	}
+
+	if t.Spec.Admin != "" {
+		subject, err := m.MapIdentity(t.Spec.Admin)
+		if err != nil {
+			return nil, fmt.Errorf("invalid admin: %w", err)
+		}
+		add(multitenancyv1.TenantRoleAdmin, subject)
+	}
+
+	for _, member := range t.Spec.Members {
+		subject, err := m.MapMember(member)
+		if err != nil {
+			return nil, fmt.Errorf("invalid member %q: %w", member.Name, err)
+		}
+		add(member.Role, subject)
+	}
+
+	return subjects, nil
+}
+
+// Returns the most privileged role of the user in the tenant, false if the user is neither the admin nor a member
+// of the tenant. The user is the one authenticated by the API server, so the prefixes are already added.
+func (m SubjectMapper) TenantRoleOf(t *multitenancyv1.Tenant, user authenticationv1.UserInfo) (multitenancyv1.TenantRole, bool, error) {
+	subjects, err := m.TenantRoleSubjects(t)
+	if err != nil {
+		return "", false, err
+	}
+
+	for _, role := range multitenancyv1.TenantRoles {
+		for _, subject := range subjects[role] {
+			if IsUserSubject(user, subject) {
+				return role, true, nil
+			}
+		}
+	}
+
+	return "", false, nil
+}
+
+// Returns true if the user is the subject, or is a member of the group subject.
+func IsUserSubject(user authenticationv1.UserInfo, subject rbacv1.Subject) bool {
+	switch subject.Kind {
+	case rbacv1.UserKind:
+		return user.Username == subject.Name
+	case rbacv1.GroupKind:
+		for _, group := range user.Groups {
+			if group == subject.Name {
+				return true
+			}
+		}
+	case rbacv1.ServiceAccountKind:
+		return user.Username == systemServiceAccountPrefix+subject.Namespace+":"+subject.Name
+	}
+	return false
+}
+
+// Adds the prefix to the name unless it is already prefixed.
+func addPrefix(prefix, name string) string {
+	if strings.HasPrefix(name, prefix) {
+		return name
+	}
+	return prefix + name
+}
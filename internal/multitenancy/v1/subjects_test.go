@@ -0,0 +1,111 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multitenancy
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+)
+
+var _ = Describe("SubjectMapper", func() {
+	mapper := SubjectMapper{UsernamePrefix: "oidc:", GroupPrefix: "oidc:"}
+
+	It("should prefix the users", func() {
+		subject, err := mapper.MapIdentity("alice@example.com")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subject.Kind).To(Equal(rbacv1.UserKind))
+		Expect(subject.Name).To(Equal("oidc:alice@example.com"))
+	})
+
+	It("should not prefix twice", func() {
+		subject, err := mapper.MapIdentity("oidc:alice")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subject.Name).To(Equal("oidc:alice"))
+	})
+
+	It("should map the groups", func() {
+		subject, err := mapper.MapIdentity("group:researchers")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subject.Kind).To(Equal(rbacv1.GroupKind))
+		Expect(subject.Name).To(Equal("oidc:researchers"))
+	})
+
+	It("should map the service accounts", func() {
+		for _, identity := range []string{"serviceaccount:lab:ci", "system:serviceaccount:lab:ci"} {
+			subject, err := mapper.MapIdentity(identity)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(subject).To(Equal(rbacv1.Subject{Kind: rbacv1.ServiceAccountKind, Name: "ci", Namespace: "lab"}))
+		}
+	})
+
+	It("should reject invalid subjects", func() {
+		_, err := mapper.MapIdentity("serviceaccount:ci")
+		Expect(err).To(HaveOccurred())
+		_, err = mapper.MapMember(multitenancyv1.TenantMember{Name: "ci", Kind: rbacv1.ServiceAccountKind})
+		Expect(err).To(HaveOccurred())
+		_, err = mapper.MapSubject("Robot", "r2d2", "")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should group the subjects of the tenant by their roles", func() {
+		tenant := &multitenancyv1.Tenant{Spec: multitenancyv1.TenantSpec{
+			Admin: "alice",
+			Members: []multitenancyv1.TenantMember{
+				{Name: "alice", Kind: rbacv1.UserKind, Role: multitenancyv1.TenantRoleAdmin},
+				{Name: "researchers", Kind: rbacv1.GroupKind, Role: multitenancyv1.TenantRoleMember},
+			},
+		}}
+		subjects, err := mapper.TenantRoleSubjects(tenant)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(subjects[multitenancyv1.TenantRoleAdmin]).To(Equal([]rbacv1.Subject{{Kind: rbacv1.UserKind, APIGroup: rbacv1.GroupName, Name: "oidc:alice"}}))
+		Expect(subjects[multitenancyv1.TenantRoleMember]).To(HaveLen(1))
+
+		tenant.Spec.Members = append(tenant.Spec.Members, multitenancyv1.TenantMember{Name: "ci", Kind: rbacv1.ServiceAccountKind, Role: multitenancyv1.TenantRoleManager})
+		_, err = mapper.TenantRoleSubjects(tenant)
+		Expect(err).To(MatchError(ContainSubstring(`invalid member "ci"`)))
+	})
+
+	It("should find the most privileged role of the user", func() {
+		tenant := &multitenancyv1.Tenant{Spec: multitenancyv1.TenantSpec{
+			Admin: "alice",
+			Members: []multitenancyv1.TenantMember{
+				{Name: "researchers", Kind: rbacv1.GroupKind, Role: multitenancyv1.TenantRoleMember},
+				{Name: "ci", Kind: rbacv1.ServiceAccountKind, Namespace: "lab", Role: multitenancyv1.TenantRoleManager},
+				{Name: "alice", Kind: rbacv1.UserKind, Role: multitenancyv1.TenantRoleOwner},
+			},
+		}}
+
+		for user, expected := range map[string]multitenancyv1.TenantRole{
+			"oidc:alice":                   multitenancyv1.TenantRoleOwner,
+			"oidc:bob":                     multitenancyv1.TenantRoleMember,
+			"system:serviceaccount:lab:ci": multitenancyv1.TenantRoleManager,
+		} {
+			role, ok, err := mapper.TenantRoleOf(tenant, authenticationv1.UserInfo{Username: user, Groups: []string{"oidc:researchers"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ok).To(BeTrue())
+			Expect(role).To(Equal(expected))
+		}
+
+		_, ok, err := mapper.TenantRoleOf(tenant, authenticationv1.UserInfo{Username: "oidc:mallory"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ok).To(BeFalse())
+	})
+})
@@ -0,0 +1,240 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package multitenancy
+
+import (
+	"context"
+	"strconv"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SuspensionQuotaName is the name of the ResourceQuota created in every namespace of a suspended tenant. It is
+// separate from the quota of the initial request, Kubernetes enforces the most restrictive one.
+const SuspensionQuotaName = "edgenet-suspension"
+
+// SuspendedReplicasAnnotation keeps the replica count of a workload scaled down by the suspension.
+const SuspendedReplicasAnnotation = "edge-net.io/suspended-replicas"
+
+// Suspends the tenant in all of its namespaces. A ResourceQuota allowing zero pods is created and the role bindings
+// of the members and the role requests are removed. The running pods are kept unless the workloads are scaled down.
+func (m *multiTenancyManager) SuspendTenant(ctx context.Context, t *multitenancyv1.Tenant) error {
+	namespaces, err := m.getTenantNamespaces(ctx, t)
+
+	if err != nil {
+		return err
+	}
+
+	for _, namespace := range namespaces {
+		if err := m.suspendNamespace(ctx, t, namespace.GetName()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Resumes the tenant, the suspension quotas are removed and the scaled down workloads get their replicas back. The
+// role bindings are not created here, they are recreated by CreateTenantRoleBindings and
+// CreateRoleRequestRoleBindings.
+func (m *multiTenancyManager) ResumeTenant(ctx context.Context, t *multitenancyv1.Tenant) error {
+	namespaces, err := m.getTenantNamespaces(ctx, t)
+
+	if err != nil {
+		return err
+	}
+
+	for _, namespace := range namespaces {
+		quota := &corev1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: SuspensionQuotaName, Namespace: namespace.GetName()},
+		}
+		if err := m.client.Delete(ctx, quota); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+
+		if err := m.restoreWorkloads(ctx, namespace.GetName()); err != nil {
+			return err
+		}
+
+		if err := m.createRoleRequestRoleBindingsInNamespace(ctx, t, namespace.GetName()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Applies the suspension to a single namespace of the tenant. Used for the existing namespaces when the tenant is
+// suspended and for the SubNamespaces created afterwards.
+func (m *multiTenancyManager) suspendNamespace(ctx context.Context, t *multitenancyv1.Tenant, namespace string) error {
+	quota := &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      SuspensionQuotaName,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"edge-net.io/generated": "true",
+				"edge-net.io/tenant":    t.GetName(),
+			},
+		},
+		Spec: corev1.ResourceQuotaSpec{
+			Hard: corev1.ResourceList{corev1.ResourcePods: resource.MustParse("0")},
+		},
+	}
+
+	if err := m.client.Create(ctx, quota); err != nil && !errors.IsAlreadyExists(err) {
+		return err
+	}
+
+	// Both the role bindings of the members and the role requests carry the tenant role label.
+	err := m.client.DeleteAllOf(ctx, &rbacv1.RoleBinding{},
+		client.InNamespace(namespace),
+		client.MatchingLabels{"edge-net.io/tenant": t.GetName()},
+		client.HasLabels{"edge-net.io/tenant-role"},
+	)
+
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	if t.Spec.ScaleDownOnSuspension {
+		return m.scaleDownWorkloads(ctx, namespace)
+	}
+
+	return nil
+}
+
+// Scales the Deployments and the StatefulSets of the namespace to zero. The replica count is kept in an annotation,
+// the workloads already scaled down by the suspension are skipped.
+func (m *multiTenancyManager) scaleDownWorkloads(ctx context.Context, namespace string) error {
+	deploymentList := &appsv1.DeploymentList{}
+
+	if err := m.client.List(ctx, deploymentList, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+
+	for i := range deploymentList.Items {
+		deployment := &deploymentList.Items[i]
+		if err := m.scaleDownWorkload(ctx, deployment, &deployment.Spec.Replicas); err != nil {
+			return err
+		}
+	}
+
+	statefulSetList := &appsv1.StatefulSetList{}
+
+	if err := m.client.List(ctx, statefulSetList, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+
+	for i := range statefulSetList.Items {
+		statefulSet := &statefulSetList.Items[i]
+		if err := m.scaleDownWorkload(ctx, statefulSet, &statefulSet.Spec.Replicas); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Sets the replicas of the workload to zero and remembers the previous count. A nil replica count defaults to one.
+func (m *multiTenancyManager) scaleDownWorkload(ctx context.Context, obj client.Object, replicas **int32) error {
+	if _, ok := obj.GetAnnotations()[SuspendedReplicasAnnotation]; ok {
+		return nil
+	}
+
+	count := int32(1)
+	if *replicas != nil {
+		count = **replicas
+	}
+
+	if count == 0 {
+		return nil
+	}
+
+	original := obj.DeepCopyObject().(client.Object)
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[SuspendedReplicasAnnotation] = strconv.Itoa(int(count))
+	obj.SetAnnotations(annotations)
+
+	zero := int32(0)
+	*replicas = &zero
+
+	return m.client.Patch(ctx, obj, client.MergeFrom(original))
+}
+
+// Gives the Deployments and the StatefulSets of the namespace the replica counts they had before the suspension.
+func (m *multiTenancyManager) restoreWorkloads(ctx context.Context, namespace string) error {
+	deploymentList := &appsv1.DeploymentList{}
+
+	if err := m.client.List(ctx, deploymentList, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+
+	for i := range deploymentList.Items {
+		deployment := &deploymentList.Items[i]
+		if err := m.restoreWorkload(ctx, deployment, &deployment.Spec.Replicas); err != nil {
+			return err
+		}
+	}
+
+	statefulSetList := &appsv1.StatefulSetList{}
+
+	if err := m.client.List(ctx, statefulSetList, client.InNamespace(namespace)); err != nil {
+		return err
+	}
+
+	for i := range statefulSetList.Items {
+		statefulSet := &statefulSetList.Items[i]
+		if err := m.restoreWorkload(ctx, statefulSet, &statefulSet.Spec.Replicas); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Restores the replica count kept in the annotation and removes the annotation. If the workload was scaled again
+// by its owner during the suspension, the new count is kept.
+func (m *multiTenancyManager) restoreWorkload(ctx context.Context, obj client.Object, replicas **int32) error {
+	value, ok := obj.GetAnnotations()[SuspendedReplicasAnnotation]
+	if !ok {
+		return nil
+	}
+
+	original := obj.DeepCopyObject().(client.Object)
+
+	annotations := obj.GetAnnotations()
+	delete(annotations, SuspendedReplicasAnnotation)
+	obj.SetAnnotations(annotations)
+
+	if count, err := strconv.ParseInt(value, 10, 32); err == nil && (*replicas == nil || **replicas == 0) {
+		restored := int32(count)
+		*replicas = &restored
+	}
+
+	return m.client.Patch(ctx, obj, client.MergeFrom(original))
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"sort"
+	"strings"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// Event is the kind of the notification, each event has its own message template.
+type Event string
+
+const (
+	// The core namespace of the tenant is created.
+	EventTenantCreated Event = "TenantCreated"
+
+	// The tenant is suspended, either by an administrator or by the reclamation.
+	EventTenantSuspended Event = "TenantSuspended"
+
+	// The resource quota of the tenant is changed.
+	EventTenantQuotaChanged Event = "TenantQuotaChanged"
+
+	// The tenant approaches its expiration date or the end of its inactivity period.
+	EventTenantExpiring Event = "TenantExpiring"
+
+	// A role request in the tenant is approved.
+	EventRoleRequestApproved Event = "RoleRequestApproved"
+
+	// The reconciliation of the tenant started failing.
+	EventTenantReconciliationFailed Event = "TenantReconciliationFailed"
+
+	// The tenant used all of its quota for some of the resources.
+	EventTenantQuotaExhausted Event = "TenantQuotaExhausted"
+
+	// The labels of a node are changed by the node labeller.
+	EventNodeLabelled Event = "NodeLabelled"
+
+	// The geolocation of a node is changed, for example its IP address moved to another country.
+	EventNodeRelocated Event = "NodeRelocated"
+)
+
+// Notification is a tenant lifecycle event to be sent to the people concerned.
+type Notification struct {
+	Event Event
+
+	// The tenant the notification is about. The owners and the admins of the tenant receive the notification
+	// unless the tenant disabled the notifications. Not set for the events of the nodes.
+	Tenant *multitenancyv1.Tenant
+
+	// Additional recipients, for example the subject of an approved role request.
+	Recipients []string
+
+	// The values used by the message template of the event.
+	Data map[string]string
+}
+
+// Message is a rendered notification ready to be sent.
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Notifier delivers the notifications.
+type Notifier interface {
+	Notify(context.Context, Notification) error
+}
+
+// Sender delivers the rendered messages, for example by email.
+type Sender interface {
+	Send(context.Context, Message) error
+}
+
+// EmailNotifier renders the notifications with the message templates and sends them to the email addresses of
+// the recipients.
+type EmailNotifier struct {
+	Sender Sender
+}
+
+var _ Notifier = &EmailNotifier{}
+
+func NewEmailNotifier(sender Sender) Notifier {
+	return &EmailNotifier{Sender: sender}
+}
+
+// Sends the notification to the recipients having an email address. Nothing is sent if the tenant disabled the
+// notifications, if there is no recipient or if the event is not meant for the tenants, such as the events of
+// the nodes.
+func (n *EmailNotifier) Notify(ctx context.Context, notification Notification) error {
+	if _, ok := parsedTemplates[notification.Event]; !ok || notification.Tenant == nil {
+		return nil
+	}
+
+	if notification.Tenant.Spec.DisableNotifications {
+		return nil
+	}
+
+	recipients := Recipients(notification)
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	message, err := Render(notification)
+	if err != nil {
+		return err
+	}
+	message.To = recipients
+
+	return n.Sender.Send(ctx, message)
+}
+
+// Notifiers sends the notifications with each of the notifiers, for example by email and to the webhooks.
+type Notifiers []Notifier
+
+var _ Notifier = Notifiers{}
+
+// All of the notifiers are tried even if some of them fail, the errors are joined.
+func (n Notifiers) Notify(ctx context.Context, notification Notification) error {
+	var errs []error
+	for _, notifier := range n {
+		if notifier == nil {
+			continue
+		}
+		if err := notifier.Notify(ctx, notification); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Sends the notification if a notifier is configured. This allows the reconcilers to run without notifications.
+func Notify(ctx context.Context, notifier Notifier, notification Notification) error {
+	if notifier == nil {
+		return nil
+	}
+	return notifier.Notify(ctx, notification)
+}
+
+// Returns the email addresses the notification is sent to. These are the admin of the tenant, the members with
+// the owner or the admin role and the additional recipients. The identities which are not email addresses, such
+// as the groups and the service accounts, are skipped.
+func Recipients(notification Notification) []string {
+	var identities []string
+
+	if t := notification.Tenant; t != nil {
+		identities = append(identities, t.Spec.Admin)
+		for _, member := range t.Spec.Members {
+			if member.Kind != "" && member.Kind != rbacv1.UserKind {
+				continue
+			}
+			if member.Role == multitenancyv1.TenantRoleOwner || member.Role == multitenancyv1.TenantRoleAdmin {
+				identities = append(identities, member.Name)
+			}
+		}
+	}
+
+	identities = append(identities, notification.Recipients...)
+
+	recipients := []string{}
+	seen := map[string]bool{}
+	for _, identity := range identities {
+		if !IsEmailAddress(identity) || seen[identity] {
+			continue
+		}
+		seen[identity] = true
+		recipients = append(recipients, identity)
+	}
+
+	return recipients
+}
+
+// Checks if the identity is a plain email address, without a display name.
+func IsEmailAddress(identity string) bool {
+	address, err := mail.ParseAddress(identity)
+	return err == nil && address.Address == identity
+}
+
+// Formats the resources as a sorted list, for example "cpu=2, memory=4Gi".
+func FormatResourceList(resources corev1.ResourceList) string {
+	if len(resources) == 0 {
+		return "none"
+	}
+
+	values := make([]string, 0, len(resources))
+	for name, quantity := range resources {
+		values = append(values, fmt.Sprintf("%s=%s", name, quantity.String()))
+	}
+	sort.Strings(values)
+
+	return strings.Join(values, ", ")
+}
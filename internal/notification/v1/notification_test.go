@@ -0,0 +1,96 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+)
+
+// Keeps the messages instead of sending them.
+type recordingSender struct {
+	messages []Message
+}
+
+func (s *recordingSender) Send(ctx context.Context, message Message) error {
+	s.messages = append(s.messages, message)
+	return nil
+}
+
+var _ = Describe("Notifier", func() {
+	ctx := context.Background()
+
+	newTenant := func() *multitenancyv1.Tenant {
+		return &multitenancyv1.Tenant{
+			ObjectMeta: metav1.ObjectMeta{Name: "lab"},
+			Spec: multitenancyv1.TenantSpec{
+				FullName: "Laboratory",
+				Admin:    "admin@example.com",
+				Members: []multitenancyv1.TenantMember{
+					{Name: "owner@example.com", Kind: "User", Role: multitenancyv1.TenantRoleOwner},
+					{Name: "member@example.com", Kind: "User", Role: multitenancyv1.TenantRoleMember},
+					{Name: "group:admins", Kind: "Group", Role: multitenancyv1.TenantRoleAdmin},
+				},
+			},
+		}
+	}
+
+	It("should send to the admins and the owners", func() {
+		notification := Notification{
+			Event:      EventRoleRequestApproved,
+			Tenant:     newTenant(),
+			Recipients: []string{"student@example.com", "admin@example.com", "oidc:student"},
+		}
+
+		Expect(Recipients(notification)).To(Equal([]string{"admin@example.com", "owner@example.com", "student@example.com"}))
+	})
+
+	It("should render every event", func() {
+		for event := range templates {
+			message, err := Render(Notification{Event: event, Tenant: newTenant(), Data: map[string]string{"reason": "expired"}})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(message.Subject).To(ContainSubstring("lab"))
+			Expect(message.Body).To(ContainSubstring("Laboratory"))
+		}
+
+		_, err := Render(Notification{Event: "Unknown", Tenant: newTenant()})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should respect the opt-out of the tenant", func() {
+		sender := &recordingSender{}
+		notifier := NewEmailNotifier(sender)
+
+		tenant := newTenant()
+		Expect(notifier.Notify(ctx, Notification{Event: EventTenantCreated, Tenant: tenant})).To(Succeed())
+		Expect(sender.messages).To(HaveLen(1))
+		Expect(sender.messages[0].To).To(ConsistOf("admin@example.com", "owner@example.com"))
+
+		tenant.Spec.DisableNotifications = true
+		Expect(notifier.Notify(ctx, Notification{Event: EventTenantCreated, Tenant: tenant})).To(Succeed())
+		Expect(sender.messages).To(HaveLen(1))
+	})
+
+	It("should do nothing without a notifier", func() {
+		Expect(Notify(ctx, nil, Notification{Event: EventTenantCreated})).To(Succeed())
+	})
+})
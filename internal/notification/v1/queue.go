@@ -0,0 +1,113 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// ErrQueueFull is returned when a notification cannot be queued since the workers are behind. The notification is
+// dropped.
+var ErrQueueFull = errors.New("the notification queue is full")
+
+// The defaults of the notification queue.
+const (
+	DefaultQueueSize    = 1000
+	DefaultQueueWorkers = 4
+)
+
+// Queue delivers the notifications in the background, so the reconcilers are not blocked by the retries of a slow
+// endpoint or mail server. It should be added to the manager, which starts the workers.
+type Queue struct {
+	// Delivers the queued notifications.
+	Notifier Notifier
+
+	// Number of notifications delivered at the same time.
+	Workers int
+
+	notifications chan Notification
+}
+
+var _ Notifier = &Queue{}
+var _ manager.Runnable = &Queue{}
+var _ manager.LeaderElectionRunnable = &Queue{}
+
+func NewQueue(notifier Notifier, size, workers int) *Queue {
+	return &Queue{
+		Notifier:      notifier,
+		Workers:       workers,
+		notifications: make(chan Notification, size),
+	}
+}
+
+// Queues the notification without waiting for its delivery. The tenant is copied since the reconcilers keep
+// changing it.
+func (q *Queue) Notify(ctx context.Context, notification Notification) error {
+	if notification.Tenant != nil {
+		notification.Tenant = notification.Tenant.DeepCopy()
+	}
+
+	select {
+	case q.notifications <- notification:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Start implements manager.Runnable, the workers deliver the queued notifications until the manager stops. The
+// failures are only logged, the webhook notifier records its dead letters on the policies.
+func (q *Queue) Start(ctx context.Context) error {
+	l := log.FromContext(ctx).WithName("notification")
+
+	workers := q.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case notification := <-q.notifications:
+					if err := q.Notifier.Notify(ctx, notification); err != nil {
+						l.Error(err, "cannot deliver the notification", "event", notification.Event)
+					}
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	return nil
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable. The notifications are only queued by the reconcilers
+// of the leader, the workers run on every replica so the queue never blocks.
+func (q *Queue) NeedLeaderElection() bool {
+	return false
+}
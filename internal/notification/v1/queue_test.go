@@ -0,0 +1,75 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"context"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+)
+
+// Collects the notifications, blocking until the release channel is closed.
+type blockingNotifier struct {
+	mu            sync.Mutex
+	release       chan struct{}
+	notifications []Notification
+}
+
+func (n *blockingNotifier) Notify(ctx context.Context, notification Notification) error {
+	<-n.release
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.notifications = append(n.notifications, notification)
+	return nil
+}
+
+func (n *blockingNotifier) delivered() []Notification {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]Notification(nil), n.notifications...)
+}
+
+var _ = Describe("Queue", func() {
+	It("should deliver the notifications without blocking the caller", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		notifier := &blockingNotifier{release: make(chan struct{})}
+		queue := NewQueue(notifier, 1, 1)
+
+		tenant := &multitenancyv1.Tenant{ObjectMeta: metav1.ObjectMeta{Name: "lab"}}
+		Expect(queue.Notify(ctx, Notification{Event: EventTenantCreated, Tenant: tenant})).To(Succeed())
+		tenant.Name = "changed"
+
+		// Nothing is delivered before the workers start, the queue is full.
+		Expect(queue.Notify(ctx, Notification{Event: EventTenantSuspended})).To(MatchError(ErrQueueFull))
+
+		go func() {
+			defer GinkgoRecover()
+			Expect(queue.Start(ctx)).To(Succeed())
+		}()
+
+		close(notifier.release)
+		Eventually(func() int { return len(notifier.delivered()) }).Should(Equal(1))
+		Expect(notifier.delivered()[0].Tenant.GetName()).To(Equal("lab"))
+	})
+})
@@ -0,0 +1,206 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// These are the keys of the Secret holding the SMTP settings. The username and the password are optional, the
+// authentication is skipped if the username is empty.
+const (
+	SMTPHostKey     = "host"
+	SMTPPortKey     = "port"
+	SMTPUsernameKey = "username"
+	SMTPPasswordKey = "password"
+	SMTPFromKey     = "from"
+)
+
+// DefaultSMTPTimeout bounds the whole session with the SMTP server, from the connection to the end of the email.
+const DefaultSMTPTimeout = 30 * time.Second
+
+// SMTPConfig holds the settings of the SMTP server the emails are sent through.
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+
+	// The address the emails are sent from.
+	From string
+}
+
+// Reads the SMTP settings from the Secret. The port defaults to 587.
+func ParseSMTPConfig(secret *corev1.Secret) (SMTPConfig, error) {
+	config := SMTPConfig{
+		Host:     string(secret.Data[SMTPHostKey]),
+		Port:     string(secret.Data[SMTPPortKey]),
+		Username: string(secret.Data[SMTPUsernameKey]),
+		Password: string(secret.Data[SMTPPasswordKey]),
+		From:     string(secret.Data[SMTPFromKey]),
+	}
+
+	if config.Port == "" {
+		config.Port = "587"
+	}
+
+	if config.Host == "" {
+		return SMTPConfig{}, fmt.Errorf("the secret %s/%s doesn't have the %q key", secret.GetNamespace(), secret.GetName(), SMTPHostKey)
+	}
+
+	if !IsEmailAddress(config.From) {
+		return SMTPConfig{}, fmt.Errorf("the secret %s/%s doesn't have a valid %q address", secret.GetNamespace(), secret.GetName(), SMTPFromKey)
+	}
+
+	return config, nil
+}
+
+// SMTPSender sends the messages by email. The settings are read from the Secret for every message, so they can be
+// changed without restarting the controller.
+type SMTPSender struct {
+	// Used to read the Secret. An uncached reader avoids watching all of the Secrets of the cluster.
+	Reader client.Reader
+	Secret types.NamespacedName
+
+	// Bounds the session with the server, the deadline of the context is used if it is earlier.
+	Timeout time.Duration
+}
+
+var _ Sender = &SMTPSender{}
+
+func NewSMTPSender(reader client.Reader, secret types.NamespacedName) Sender {
+	return &SMTPSender{Reader: reader, Secret: secret, Timeout: DefaultSMTPTimeout}
+}
+
+func (s *SMTPSender) Send(ctx context.Context, message Message) error {
+	secret := &corev1.Secret{}
+	if err := s.Reader.Get(ctx, s.Secret, secret); err != nil {
+		return err
+	}
+
+	config, err := ParseSMTPConfig(secret)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if config.Username != "" {
+		auth = smtp.PlainAuth("", config.Username, config.Password, config.Host)
+	}
+
+	return s.sendMail(ctx, config, auth, message.To, FormatEmail(config.From, message, time.Now()))
+}
+
+// Same as smtp.SendMail, except the connection is bounded by the timeout and closed when the context is done.
+func (s *SMTPSender) sendMail(ctx context.Context, config SMTPConfig, auth smtp.Auth, to []string, data []byte) error {
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = DefaultSMTPTimeout
+	}
+
+	deadline := time.Now().Add(timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(config.Host, config.Port))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(deadline); err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, config.Host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: config.Host}); err != nil {
+			return err
+		}
+	}
+
+	if auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return fmt.Errorf("the server %s doesn't support the authentication", config.Host)
+		}
+		if err := c.Auth(auth); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(config.From); err != nil {
+		return err
+	}
+	for _, recipient := range to {
+		if err := c.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+// Formats the message as a plain text email. The subject is encoded in case it contains non-ASCII characters.
+func FormatEmail(from string, message Message, date time.Time) []byte {
+	builder := &strings.Builder{}
+
+	fmt.Fprintf(builder, "From: %s\r\n", from)
+	fmt.Fprintf(builder, "To: %s\r\n", strings.Join(message.To, ", "))
+	fmt.Fprintf(builder, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(builder, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	builder.WriteString("MIME-Version: 1.0\r\n")
+	builder.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	builder.WriteString("\r\n")
+
+	for _, line := range strings.Split(strings.TrimRight(message.Body, "\n"), "\n") {
+		builder.WriteString(line)
+		builder.WriteString("\r\n")
+	}
+
+	return []byte(builder.String())
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// A minimal SMTP server standing in for the real one. It accepts a single session without authentication and
+// keeps the envelope and the data of the received emails.
+type smtpStandIn struct {
+	listener net.Listener
+	received chan receivedEmail
+}
+
+type receivedEmail struct {
+	from string
+	to   []string
+	data string
+}
+
+func newSMTPStandIn() *smtpStandIn {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+
+	s := &smtpStandIn{listener: listener, received: make(chan receivedEmail, 1)}
+	go s.serve()
+	return s
+}
+
+func (s *smtpStandIn) address() (string, string) {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return host, port
+}
+
+func (s *smtpStandIn) serve() {
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) {
+		_, _ = conn.Write([]byte(line + "\r\n"))
+	}
+
+	email := receivedEmail{}
+	reply("220 localhost ESMTP")
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			email.from = strings.Trim(strings.TrimPrefix(command, "MAIL FROM:"), "<>")
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			email.to = append(email.to, strings.Trim(strings.TrimPrefix(command, "RCPT TO:"), "<>"))
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			data := &strings.Builder{}
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(dataLine)
+			}
+			email.data = data.String()
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 Bye")
+			s.received <- email
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+var _ = Describe("SMTPSender", func() {
+	ctx := context.Background()
+
+	It("should send the email through the server configured in the secret", func() {
+		server := newSMTPStandIn()
+		defer server.listener.Close()
+		host, port := server.address()
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "smtp", Namespace: "edgenet-system"},
+			Data: map[string][]byte{
+				SMTPHostKey: []byte(host),
+				SMTPPortKey: []byte(port),
+				SMTPFromKey: []byte("no-reply@edge-net.io"),
+			},
+		}
+		reader := fake.NewClientBuilder().WithObjects(secret).Build()
+
+		sender := NewSMTPSender(reader, types.NamespacedName{Name: "smtp", Namespace: "edgenet-system"})
+		Expect(sender.Send(ctx, Message{
+			To:      []string{"admin@example.com"},
+			Subject: "Your EdgeNet tenant lab is ready",
+			Body:    "Hello,\n\nThe tenant lab is created.\n",
+		})).To(Succeed())
+
+		var email receivedEmail
+		Eventually(server.received).Should(Receive(&email))
+		Expect(email.from).To(Equal("no-reply@edge-net.io"))
+		Expect(email.to).To(Equal([]string{"admin@example.com"}))
+		Expect(email.data).To(ContainSubstring("Subject: Your EdgeNet tenant lab is ready\r\n"))
+		Expect(email.data).To(ContainSubstring("\r\n\r\nHello,\r\n\r\nThe tenant lab is created.\r\n"))
+	})
+
+	It("should give up on a server that doesn't answer", func() {
+		// The server accepts the connection but never greets the client.
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		defer listener.Close()
+		host, port, _ := net.SplitHostPort(listener.Addr().String())
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "smtp", Namespace: "edgenet-system"},
+			Data: map[string][]byte{
+				SMTPHostKey: []byte(host),
+				SMTPPortKey: []byte(port),
+				SMTPFromKey: []byte("no-reply@edge-net.io"),
+			},
+		}
+		reader := fake.NewClientBuilder().WithObjects(secret).Build()
+
+		sender := &SMTPSender{Reader: reader, Secret: types.NamespacedName{Name: "smtp", Namespace: "edgenet-system"}, Timeout: 100 * time.Millisecond}
+		start := time.Now()
+		Expect(sender.Send(ctx, Message{To: []string{"admin@example.com"}})).NotTo(Succeed())
+		Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+	})
+
+	It("should reject an incomplete configuration", func() {
+		_, err := ParseSMTPConfig(&corev1.Secret{Data: map[string][]byte{SMTPFromKey: []byte("no-reply@edge-net.io")}})
+		Expect(err).To(HaveOccurred())
+
+		_, err = ParseSMTPConfig(&corev1.Secret{Data: map[string][]byte{SMTPHostKey: []byte("smtp.example.com")}})
+		Expect(err).To(HaveOccurred())
+
+		config, err := ParseSMTPConfig(&corev1.Secret{Data: map[string][]byte{
+			SMTPHostKey: []byte("smtp.example.com"),
+			SMTPFromKey: []byte("no-reply@edge-net.io"),
+		}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config.Port).To(Equal("587"))
+	})
+})
@@ -0,0 +1,124 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+// The templates of the messages, the first line is the subject and the rest is the body. The templates are
+// executed with the notification, the tenant and the data are available as .Tenant and .Data.
+var templates = map[Event]string{
+	EventTenantCreated: `Your EdgeNet tenant {{ .Tenant.Name }} is ready
+Hello,
+
+The tenant {{ .Tenant.Name }} ({{ .Tenant.Spec.FullName }}) is created on EdgeNet. Its core namespace
+{{ .Tenant.Name }} is ready to use and the resources of the initial request are allocated to it.
+`,
+	EventTenantSuspended: `Your EdgeNet tenant {{ .Tenant.Name }} is suspended
+Hello,
+
+The tenant {{ .Tenant.Name }} ({{ .Tenant.Spec.FullName }}) is suspended{{ with .Data.reason }} since it is {{ . }}{{ end }}.
+No new pods can be created in its namespaces and the role bindings of its members are removed. The data of the
+tenant is kept, please contact the EdgeNet administrators to resume it.
+`,
+	EventTenantQuotaChanged: `The resource quota of your EdgeNet tenant {{ .Tenant.Name }} is changed
+Hello,
+
+The resource quota of the tenant {{ .Tenant.Name }} ({{ .Tenant.Spec.FullName }}) is changed.
+
+Previous quota: {{ .Data.previous }}
+New quota: {{ .Data.quota }}
+`,
+	EventTenantExpiring: `Your EdgeNet tenant {{ .Tenant.Name }} will be suspended
+Hello,
+
+The tenant {{ .Tenant.Name }} ({{ .Tenant.Spec.FullName }}) will be suspended at {{ .Data.deadline }} since it is
+{{ .Data.reason }}. Its data is deleted after the grace period. Please contact the EdgeNet administrators to
+extend the tenant if you still need it.
+`,
+	EventRoleRequestApproved: `Your role request in the EdgeNet tenant {{ .Tenant.Name }} is approved
+Hello,
+
+The role request {{ .Data.name }} for {{ .Data.subject }} is approved, the {{ .Data.role }} role is granted in the
+namespaces of the tenant {{ .Tenant.Name }} ({{ .Tenant.Spec.FullName }}).
+`,
+}
+
+// The one line summaries of the events, posted to the webhooks. Unlike the emails, these are written for the
+// cluster operators and cover the events of the nodes as well.
+var summaries = map[Event]string{
+	EventTenantCreated:              `The tenant {{ .Tenant.Name }} is created`,
+	EventTenantSuspended:            `The tenant {{ .Tenant.Name }} is suspended{{ with .Data.reason }} since it is {{ . }}{{ end }}`,
+	EventTenantQuotaChanged:         `The resource quota of the tenant {{ .Tenant.Name }} is changed from {{ .Data.previous }} to {{ .Data.quota }}`,
+	EventTenantExpiring:             `The tenant {{ .Tenant.Name }} will be suspended at {{ .Data.deadline }} since it is {{ .Data.reason }}`,
+	EventRoleRequestApproved:        `The role request {{ .Data.name }} of {{ .Data.subject }} in the tenant {{ .Tenant.Name }} is approved`,
+	EventTenantReconciliationFailed: `The reconciliation of the tenant {{ .Tenant.Name }} failed: {{ .Data.error }}`,
+	EventTenantQuotaExhausted:       `The tenant {{ .Tenant.Name }} exhausted its quota of {{ .Data.resources }}`,
+	EventNodeLabelled:               `The labels of the node {{ .Data.node }} are changed: {{ .Data.changes }}`,
+	EventNodeRelocated:              `The node {{ .Data.node }} is relocated from {{ .Data.previous }} to {{ .Data.location }}`,
+}
+
+var parsedTemplates = map[Event]*template.Template{}
+
+var parsedSummaries = map[Event]*template.Template{}
+
+func init() {
+	for event, text := range templates {
+		parsedTemplates[event] = template.Must(template.New(string(event)).Option("missingkey=zero").Parse(text))
+	}
+	for event, text := range summaries {
+		parsedSummaries[event] = template.Must(template.New(string(event)).Option("missingkey=zero").Parse(text))
+	}
+}
+
+// Renders the one line summary of the notification.
+func Summarize(notification Notification) (string, error) {
+	t, ok := parsedSummaries[notification.Event]
+	if !ok {
+		return "", fmt.Errorf("no summary for the notification event %s", notification.Event)
+	}
+
+	buffer := &bytes.Buffer{}
+	if err := t.Execute(buffer, notification); err != nil {
+		return "", err
+	}
+
+	return buffer.String(), nil
+}
+
+// Renders the subject and the body of the notification from the template of its event.
+func Render(notification Notification) (Message, error) {
+	t, ok := parsedTemplates[notification.Event]
+	if !ok {
+		return Message{}, fmt.Errorf("no template for the notification event %s", notification.Event)
+	}
+
+	if notification.Tenant == nil {
+		return Message{}, fmt.Errorf("the notification %s doesn't have a tenant", notification.Event)
+	}
+
+	buffer := &bytes.Buffer{}
+	if err := t.Execute(buffer, notification); err != nil {
+		return Message{}, err
+	}
+
+	subject, body, _ := bytes.Cut(buffer.Bytes(), []byte("\n"))
+	return Message{Subject: string(subject), Body: string(body)}, nil
+}
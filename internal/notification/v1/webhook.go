@@ -0,0 +1,255 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	notificationv1 "github.com/edgenet-project/edgenet/api/notification/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// These are the headers of the requests posted to the endpoints.
+const (
+	EventHeader     = "X-EdgeNet-Event"
+	TimestampHeader = "X-EdgeNet-Timestamp"
+	SignatureHeader = "X-EdgeNet-Signature"
+)
+
+// DeadLetterReason is the reason of the events recorded on a policy when a delivery fails after all of the
+// retries.
+const DeadLetterReason = "NotificationDeadLetter"
+
+// Payload is the body posted to the generic endpoints.
+type Payload struct {
+	Event   Event             `json:"event"`
+	Time    time.Time         `json:"time"`
+	Tenant  string            `json:"tenant,omitempty"`
+	Message string            `json:"message"`
+	Data    map[string]string `json:"data,omitempty"`
+}
+
+//+kubebuilder:rbac:groups=notification.edge-net.io,resources=notificationpolicies,verbs=get;list;watch
+
+// WebhookNotifier posts the notifications to the HTTP endpoints of the NotificationPolicies selecting their events.
+type WebhookNotifier struct {
+	// Lists the notification policies.
+	Reader client.Reader
+
+	// Reads the signing secrets. An uncached reader avoids watching all of the Secrets of the cluster.
+	SecretReader client.Reader
+
+	HTTPClient *http.Client
+
+	// The wait before the first retry, doubled after each retry.
+	Backoff time.Duration
+
+	// Records the dead letters on the policies. If nil, the dead letters are returned as errors instead.
+	Recorder record.EventRecorder
+}
+
+var _ Notifier = &WebhookNotifier{}
+
+func NewWebhookNotifier(reader, secretReader client.Reader, recorder record.EventRecorder) Notifier {
+	return &WebhookNotifier{
+		Reader:       reader,
+		SecretReader: secretReader,
+		HTTPClient:   &http.Client{Timeout: 10 * time.Second},
+		Backoff:      time.Second,
+		Recorder:     recorder,
+	}
+}
+
+// Posts the notification to the endpoints of every policy selecting its event. The failed deliveries are retried
+// and then recorded as dead letters, they don't fail the notification.
+func (n *WebhookNotifier) Notify(ctx context.Context, notification Notification) error {
+	policyList := &notificationv1.NotificationPolicyList{}
+	if err := n.Reader.List(ctx, policyList); err != nil {
+		return err
+	}
+
+	var payload *Payload
+	var deadLetters []error
+
+	for i := range policyList.Items {
+		policy := &policyList.Items[i]
+		if !policy.Selects(string(notification.Event)) {
+			continue
+		}
+
+		// The summary is only rendered if a policy is interested in the event.
+		if payload == nil {
+			message, err := Summarize(notification)
+			if err != nil {
+				return err
+			}
+			payload = &Payload{Event: notification.Event, Time: time.Now().UTC(), Message: message, Data: notification.Data}
+			if notification.Tenant != nil {
+				payload.Tenant = notification.Tenant.GetName()
+			}
+		}
+
+		for _, endpoint := range policy.Spec.Endpoints {
+			err := n.deliver(ctx, endpoint, int(policy.Spec.Retries), payload)
+			if err == nil {
+				continue
+			}
+
+			message := fmt.Sprintf("The %s event cannot be delivered to the endpoint %s: %v", notification.Event, endpoint.Name, err)
+			if n.Recorder != nil {
+				n.Recorder.Event(policy, corev1.EventTypeWarning, DeadLetterReason, message)
+			} else {
+				deadLetters = append(deadLetters, errors.New(message))
+			}
+		}
+	}
+
+	return errors.Join(deadLetters...)
+}
+
+// Posts the payload to the endpoint, retrying the network errors and the server errors.
+func (n *WebhookNotifier) deliver(ctx context.Context, endpoint notificationv1.WebhookEndpoint, retries int, payload *Payload) error {
+	body, err := EncodePayload(endpoint.Format, payload)
+	if err != nil {
+		return err
+	}
+
+	var key []byte
+	if ref := endpoint.SigningSecretRef; ref != nil {
+		if key, err = n.getSigningKey(ctx, ref); err != nil {
+			return err
+		}
+	}
+
+	backoff := n.Backoff
+	for attempt := 0; ; attempt++ {
+		err = n.post(ctx, endpoint.URL, payload.Event, body, key)
+
+		var statusErr *StatusError
+		if err == nil || attempt >= retries || (errors.As(err, &statusErr) && !statusErr.Retryable()) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// Sends a single request to the endpoint.
+func (n *WebhookNotifier) post(ctx context.Context, url string, event Event, body, key []byte) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(EventHeader, string(event))
+	request.Header.Set(TimestampHeader, timestamp)
+	if key != nil {
+		request.Header.Set(SignatureHeader, Sign(key, timestamp, body))
+	}
+
+	response, err := n.HTTPClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	_, _ = io.Copy(io.Discard, response.Body)
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return &StatusError{StatusCode: response.StatusCode}
+	}
+
+	return nil
+}
+
+// Reads the signing key from the referenced secret.
+func (n *WebhookNotifier) getSigningKey(ctx context.Context, ref *notificationv1.SecretKeyReference) ([]byte, error) {
+	secret := &corev1.Secret{}
+	if err := n.SecretReader.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, secret); err != nil {
+		return nil, err
+	}
+
+	keyName := ref.Key
+	if keyName == "" {
+		keyName = "secret"
+	}
+
+	key, ok := secret.Data[keyName]
+	if !ok || len(key) == 0 {
+		return nil, fmt.Errorf("the secret %s/%s doesn't have the %q key", ref.Namespace, ref.Name, keyName)
+	}
+
+	return key, nil
+}
+
+// StatusError is returned when the endpoint answers with a non-2xx status.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("the endpoint returned %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// The server errors and the rate limiting are temporary, the other client errors are not retried.
+func (e *StatusError) Retryable() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests
+}
+
+// Encodes the payload in the format of the endpoint. The chat formats only contain the message.
+func EncodePayload(format notificationv1.PayloadFormat, payload *Payload) ([]byte, error) {
+	switch format {
+	case notificationv1.PayloadFormatSlack:
+		return json.Marshal(map[string]string{"text": payload.Message})
+	case notificationv1.PayloadFormatMatrix:
+		return json.Marshal(map[string]string{"msgtype": "m.text", "body": payload.Message})
+	case notificationv1.PayloadFormatGeneric, "":
+		return json.Marshal(payload)
+	default:
+		return nil, fmt.Errorf("unknown payload format %s", format)
+	}
+}
+
+// Computes the signature of the body, the receivers should compute the same value from the timestamp header and
+// the body to verify the origin of the request.
+func Sign(key []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	notificationv1 "github.com/edgenet-project/edgenet/api/notification/v1"
+)
+
+var _ = Describe("WebhookNotifier", func() {
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+	Expect(notificationv1.AddToScheme(scheme)).To(Succeed())
+
+	tenant := &multitenancyv1.Tenant{ObjectMeta: metav1.ObjectMeta{Name: "lab"}}
+
+	newPolicy := func(name string, events []string, endpoints ...notificationv1.WebhookEndpoint) *notificationv1.NotificationPolicy {
+		return &notificationv1.NotificationPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       notificationv1.NotificationPolicySpec{Events: events, Endpoints: endpoints, Retries: 2},
+		}
+	}
+
+	newNotifier := func(recorder record.EventRecorder, objects ...client.Object) *WebhookNotifier {
+		c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+		return &WebhookNotifier{Reader: c, SecretReader: c, HTTPClient: http.DefaultClient, Recorder: recorder}
+	}
+
+	It("should post the signed payload to the endpoints selecting the event", func() {
+		var requests []*http.Request
+		var bodies [][]byte
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			requests = append(requests, r)
+			bodies = append(bodies, body)
+		}))
+		defer server.Close()
+
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "signing", Namespace: "edgenet-system"},
+			Data:       map[string][]byte{"secret": []byte("s3cr3t")},
+		}
+		notifier := newNotifier(nil, secret,
+			newPolicy("ops", []string{string(EventTenantCreated)}, notificationv1.WebhookEndpoint{
+				Name:             "receiver",
+				URL:              server.URL,
+				SigningSecretRef: &notificationv1.SecretKeyReference{Namespace: "edgenet-system", Name: "signing"},
+			}),
+			newPolicy("nodes", []string{string(EventNodeRelocated)}, notificationv1.WebhookEndpoint{Name: "nodes", URL: server.URL}),
+		)
+
+		Expect(notifier.Notify(ctx, Notification{Event: EventTenantCreated, Tenant: tenant})).To(Succeed())
+		Expect(requests).To(HaveLen(1))
+
+		request := requests[0]
+		Expect(request.Header.Get(EventHeader)).To(Equal(string(EventTenantCreated)))
+		Expect(request.Header.Get(SignatureHeader)).To(Equal(Sign([]byte("s3cr3t"), request.Header.Get(TimestampHeader), bodies[0])))
+
+		payload := Payload{}
+		Expect(json.Unmarshal(bodies[0], &payload)).To(Succeed())
+		Expect(payload.Event).To(Equal(EventTenantCreated))
+		Expect(payload.Tenant).To(Equal("lab"))
+		Expect(payload.Message).To(Equal("The tenant lab is created"))
+	})
+
+	It("should post the chat messages", func() {
+		var body map[string]string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(json.NewDecoder(r.Body).Decode(&body)).To(Succeed())
+		}))
+		defer server.Close()
+
+		notifier := newNotifier(nil, newPolicy("chat", nil, notificationv1.WebhookEndpoint{
+			Name:   "slack",
+			URL:    server.URL,
+			Format: notificationv1.PayloadFormatSlack,
+		}))
+
+		notification := Notification{Event: EventNodeRelocated, Data: map[string]string{"node": "node-1", "previous": "Paris, FR", "location": "Berlin, DE"}}
+		Expect(notifier.Notify(ctx, notification)).To(Succeed())
+		Expect(body).To(Equal(map[string]string{"text": "The node node-1 is relocated from Paris, FR to Berlin, DE"}))
+	})
+
+	It("should retry the server errors", func() {
+		var attempts atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if attempts.Add(1) < 3 {
+				w.WriteHeader(http.StatusBadGateway)
+			}
+		}))
+		defer server.Close()
+
+		recorder := record.NewFakeRecorder(10)
+		notifier := newNotifier(recorder, newPolicy("ops", nil, notificationv1.WebhookEndpoint{Name: "receiver", URL: server.URL}))
+
+		Expect(notifier.Notify(ctx, Notification{Event: EventTenantCreated, Tenant: tenant})).To(Succeed())
+		Expect(attempts.Load()).To(Equal(int32(3)))
+		Expect(recorder.Events).To(BeEmpty())
+	})
+
+	It("should record the dead letters", func() {
+		var attempts atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		recorder := record.NewFakeRecorder(10)
+		notifier := newNotifier(recorder, newPolicy("ops", nil, notificationv1.WebhookEndpoint{Name: "receiver", URL: server.URL}))
+
+		Expect(notifier.Notify(ctx, Notification{Event: EventTenantCreated, Tenant: tenant})).To(Succeed())
+		Expect(attempts.Load()).To(Equal(int32(3)))
+		Expect(recorder.Events).To(Receive(ContainSubstring(DeadLetterReason)))
+
+		By("not retrying the client errors")
+		rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts.Add(1)
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer rejecting.Close()
+
+		attempts.Store(0)
+		notifier = newNotifier(nil, newPolicy("ops", nil, notificationv1.WebhookEndpoint{Name: "receiver", URL: rejecting.URL}))
+		Expect(notifier.Notify(ctx, Notification{Event: EventTenantCreated, Tenant: tenant})).NotTo(Succeed())
+		Expect(attempts.Load()).To(Equal(int32(1)))
+	})
+})
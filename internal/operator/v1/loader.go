@@ -0,0 +1,106 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	operatorv1 "github.com/edgenet-project/edgenet/api/operator/v1"
+)
+
+// The interval the EdgeNetConfig is loaded again by the Loader.
+const DefaultLoadInterval = time.Minute
+
+// Loader resolves the EdgeNetConfig into the Store on all of the replicas of the controller manager. The
+// EdgeNetConfig reconciler applies the changes right away but it only runs on the leader, so without the loader
+// the other replicas would start with the defaults when they become the leader.
+type Loader struct {
+	// Used to read the EdgeNetConfig and its Secrets, the cache of the manager is not synced before the start.
+	Reader   client.Reader
+	Defaults Settings
+	Store    *Store
+
+	// Defaults to DefaultLoadInterval.
+	Interval time.Duration
+}
+
+// Load resolves the EdgeNetConfig and applies its settings. The defaults are applied if it doesn't exist, an
+// invalid configuration keeps the previous settings.
+func (l *Loader) Load(ctx context.Context) error {
+	config := &operatorv1.EdgeNetConfig{}
+	if err := l.Reader.Get(ctx, types.NamespacedName{Name: operatorv1.EdgeNetConfigName}, config); err != nil {
+		if client.IgnoreNotFound(err) == nil || meta.IsNoMatchError(err) {
+			l.Store.Set(l.Defaults)
+			return nil
+		}
+		return err
+	}
+
+	settings, err := Resolve(ctx, l.Reader, l.Defaults, config.Spec)
+	if err != nil {
+		return err
+	}
+	l.Store.Set(settings)
+	return nil
+}
+
+// Start loads the EdgeNetConfig periodically until the context is done. The failures are logged once until the
+// error changes.
+func (l *Loader) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("edgenetconfig-loader")
+
+	interval := l.Interval
+	if interval <= 0 {
+		interval = DefaultLoadInterval
+	}
+
+	lastErr := ""
+	load := func() {
+		err := l.Load(ctx)
+		if err == nil {
+			lastErr = ""
+		} else if err.Error() != lastErr {
+			lastErr = err.Error()
+			logger.Info("EdgeNetConfig cannot be loaded, the previous settings are kept", "error", lastErr)
+		}
+	}
+
+	load()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			load()
+		}
+	}
+}
+
+// The loader runs on all of the replicas, the leader is kept up to date by the EdgeNetConfig reconciler as well.
+func (l *Loader) NeedLeaderElection() bool {
+	return false
+}
@@ -0,0 +1,241 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	operatorv1 "github.com/edgenet-project/edgenet/api/operator/v1"
+	labeller "github.com/edgenet-project/edgenet/internal/labeller/v1"
+	multitenancy "github.com/edgenet-project/edgenet/internal/multitenancy/v1"
+	notification "github.com/edgenet-project/edgenet/internal/notification/v1"
+)
+
+// Reconcilers are the names of the reconcilers of the controller manager, as given to the disabled reconcilers.
+var Reconcilers = []string{
+	"Tenant",
+	"NodeLabeller",
+	"NodeOwner",
+	"NodeReliability",
+	"SubNamespace",
+	"RoleRequest",
+	"Propagation",
+	"SliceClaim",
+	"Slice",
+	"SelectiveDeployment",
+	"AllocationPolicy",
+	"NodeContribution",
+	"EdgeNetConfig",
+}
+
+// Settings are the settings of the controllers that are applied at runtime. The flags of the controller manager
+// give the defaults, the EdgeNetConfig overrides them.
+type Settings struct {
+	// Nil if the nodes are not labelled with their geolocation.
+	MaxMind labeller.MaxMind
+
+	NamespaceDefaults    multitenancyv1.NamespaceDefaults
+	ClusterNetworkPolicy bool
+	Reclamation          multitenancy.ReclamationPolicy
+
+	// Empty if the notifications are not sent by email.
+	SMTPSecret types.NamespacedName
+}
+
+// Completes the multitenancy configuration with the settings.
+func (s Settings) MultiTenancyConfig(config multitenancy.Config) multitenancy.Config {
+	config.NamespaceDefaults = s.NamespaceDefaults
+	config.ClusterNetworkPolicy = s.ClusterNetworkPolicy
+	return config
+}
+
+// Store holds the settings active in the controllers. The reconcilers read it on every reconciliation, so the
+// changes are applied without a restart. It is set by the EdgeNetConfig reconciler on the leader and by the
+// Loader on all of the replicas.
+type Store struct {
+	mu       sync.RWMutex
+	settings Settings
+}
+
+func NewStore(settings Settings) *Store {
+	return &Store{settings: settings}
+}
+
+func (s *Store) Get() Settings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.settings
+}
+
+// The MaxMind resolved again with the same credentials is replaced by the previous one, so the credentials
+// rejected by MaxMind are still reported.
+func (s *Store) Set(settings Settings) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if labeller.SameMaxMind(settings.MaxMind, s.settings.MaxMind) {
+		settings.MaxMind = s.settings.MaxMind
+	}
+	s.settings = settings
+}
+
+// Returns the settings of the spec, the sections not given are taken from the defaults. The referenced Secrets
+// are read with the reader, an error is returned if they are missing or invalid.
+func Resolve(ctx context.Context, reader client.Reader, defaults Settings, spec operatorv1.EdgeNetConfigSpec) (Settings, error) {
+	settings := defaults
+
+	for _, name := range spec.DisabledReconcilers {
+		if !slices.ContainsFunc(Reconcilers, func(r string) bool { return strings.EqualFold(r, name) }) {
+			return Settings{}, fmt.Errorf("unknown reconciler %q", name)
+		}
+	}
+
+	if spec.MaxMind != nil {
+		secret, err := getSecret(ctx, reader, spec.MaxMind.SecretRef)
+		if err != nil {
+			return Settings{}, err
+		}
+		if settings.MaxMind, err = labeller.NewMaxMindFromSecretObject(spec.MaxMind.URL, secret); err != nil {
+			return Settings{}, err
+		}
+	}
+
+	if spec.DefaultNetworkProfile != nil {
+		settings.ClusterNetworkPolicy = spec.DefaultNetworkProfile.ClusterNetworkPolicy
+	}
+
+	if policy := spec.QuotaPolicy; policy != nil {
+		if policy.NamespaceDefaults != nil {
+			settings.NamespaceDefaults = *policy.NamespaceDefaults.DeepCopy()
+		}
+		if policy.ExpiryWarnings != nil {
+			settings.Reclamation.WarningLeadTimes = nil
+			for _, warning := range policy.ExpiryWarnings {
+				if warning.Duration <= 0 {
+					return Settings{}, fmt.Errorf("the expiry warning %s should be positive", warning.Duration)
+				}
+				settings.Reclamation.WarningLeadTimes = append(settings.Reclamation.WarningLeadTimes, warning.Duration)
+			}
+		}
+		if policy.GracePeriod != nil {
+			if policy.GracePeriod.Duration < 0 {
+				return Settings{}, fmt.Errorf("the grace period %s should not be negative", policy.GracePeriod.Duration)
+			}
+			settings.Reclamation.GracePeriod = policy.GracePeriod.Duration
+		}
+	}
+
+	if spec.Notifications != nil {
+		settings.SMTPSecret = types.NamespacedName{}
+		if ref := spec.Notifications.SMTPSecretRef; ref != nil {
+			secret, err := getSecret(ctx, reader, *ref)
+			if err != nil {
+				return Settings{}, err
+			}
+			if _, err := notification.ParseSMTPConfig(secret); err != nil {
+				return Settings{}, err
+			}
+			settings.SMTPSecret = types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}
+		}
+	}
+
+	return settings, nil
+}
+
+func getSecret(ctx context.Context, reader client.Reader, ref operatorv1.SecretReference) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	if err := reader.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, secret); err != nil {
+		return nil, fmt.Errorf("cannot read the secret %s/%s: %w", ref.Namespace, ref.Name, err)
+	}
+	return secret, nil
+}
+
+// Checks if the Secret is referenced by the spec, the settings are resolved again when it changes.
+func ReferencesSecret(spec operatorv1.EdgeNetConfigSpec, secret types.NamespacedName) bool {
+	refs := []*operatorv1.SecretReference{}
+	if spec.MaxMind != nil {
+		refs = append(refs, &spec.MaxMind.SecretRef)
+	}
+	if spec.Notifications != nil {
+		refs = append(refs, spec.Notifications.SMTPSecretRef)
+	}
+
+	for _, ref := range refs {
+		if ref != nil && ref.Namespace == secret.Namespace && ref.Name == secret.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the reconcilers that are not disabled by any of the lists, in the order of the Reconcilers.
+func ActiveReconcilers(disabled ...[]string) []string {
+	active := []string{}
+	for _, reconciler := range Reconcilers {
+		isDisabled := false
+		for _, list := range disabled {
+			if slices.ContainsFunc(list, func(name string) bool { return strings.EqualFold(name, reconciler) }) {
+				isDisabled = true
+				break
+			}
+		}
+		if !isDisabled {
+			active = append(active, reconciler)
+		}
+	}
+	return active
+}
+
+// Reads the disabled reconcilers of the EdgeNetConfig before the manager is started, the reconcilers are set up
+// only once. Nothing is returned if the configuration or its definition doesn't exist.
+func ReadDisabledReconcilers(ctx context.Context, reader client.Reader) ([]string, error) {
+	config := &operatorv1.EdgeNetConfig{}
+	if err := reader.Get(ctx, types.NamespacedName{Name: operatorv1.EdgeNetConfigName}, config); err != nil {
+		if client.IgnoreNotFound(err) == nil || meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return config.Spec.DisabledReconcilers, nil
+}
+
+// SMTPSender sends the emails through the SMTP server of the active settings. Nothing is sent if the email
+// notifications are disabled.
+type SMTPSender struct {
+	// Used to read the Secret of the SMTP settings.
+	Reader   client.Reader
+	Settings *Store
+}
+
+var _ notification.Sender = &SMTPSender{}
+
+func (s *SMTPSender) Send(ctx context.Context, message notification.Message) error {
+	secret := s.Settings.Get().SMTPSecret
+	if secret.Name == "" {
+		return nil
+	}
+	return notification.NewSMTPSender(s.Reader, secret).Send(ctx, message)
+}
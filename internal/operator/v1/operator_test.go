@@ -0,0 +1,199 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package operator
+
+import (
+	"context"
+	"encoding/base64"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	operatorv1 "github.com/edgenet-project/edgenet/api/operator/v1"
+	labeller "github.com/edgenet-project/edgenet/internal/labeller/v1"
+	multitenancy "github.com/edgenet-project/edgenet/internal/multitenancy/v1"
+	notification "github.com/edgenet-project/edgenet/internal/notification/v1"
+)
+
+var _ = Describe("Settings", func() {
+	ctx := context.Background()
+	encode := func(value string) []byte {
+		return []byte(base64.StdEncoding.EncodeToString([]byte(value)))
+	}
+
+	var reader client.Reader
+	defaults := Settings{
+		NamespaceDefaults: multitenancyv1.NamespaceDefaults{PodSecurityLevel: multitenancyv1.PodSecurityLevelBaseline},
+		Reclamation: multitenancy.ReclamationPolicy{
+			WarningLeadTimes: []time.Duration{24 * time.Hour},
+			GracePeriod:      time.Hour,
+		},
+		SMTPSecret: types.NamespacedName{Namespace: "edgenet-system", Name: "smtp-secret"},
+	}
+
+	BeforeEach(func() {
+		reader = fake.NewClientBuilder().WithScheme(clientgoscheme.Scheme).WithObjects(
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "edgenet-system", Name: "maxmind-secret"},
+				Data: map[string][]byte{
+					labeller.MaxMindAccountIdKey: encode("account"),
+					labeller.MaxMindTokenKey:     encode("token"),
+				},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "edgenet-system", Name: "invalid-smtp"},
+				Data:       map[string][]byte{notification.SMTPFromKey: []byte("edgenet@example.com")},
+			},
+		).Build()
+	})
+
+	It("keeps the defaults for the sections not given", func() {
+		settings, err := Resolve(ctx, reader, defaults, operatorv1.EdgeNetConfigSpec{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(settings).To(Equal(defaults))
+	})
+
+	It("overrides the defaults with the given sections", func() {
+		settings, err := Resolve(ctx, reader, defaults, operatorv1.EdgeNetConfigSpec{
+			MaxMind: &operatorv1.MaxMindConfig{
+				URL:       "https://geoip.maxmind.com/geoip/v2.1/city/",
+				SecretRef: operatorv1.SecretReference{Namespace: "edgenet-system", Name: "maxmind-secret"},
+			},
+			DefaultNetworkProfile: &operatorv1.NetworkProfile{ClusterNetworkPolicy: true},
+			QuotaPolicy: &operatorv1.QuotaPolicy{
+				NamespaceDefaults: &multitenancyv1.NamespaceDefaults{PodSecurityLevel: multitenancyv1.PodSecurityLevelRestricted},
+				ExpiryWarnings:    []metav1.Duration{{Duration: 48 * time.Hour}, {Duration: time.Hour}},
+			},
+			Notifications: &operatorv1.NotificationSettings{},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(settings.MaxMind).NotTo(BeNil())
+		Expect(settings.ClusterNetworkPolicy).To(BeTrue())
+		Expect(settings.NamespaceDefaults.PodSecurityLevel).To(Equal(multitenancyv1.PodSecurityLevelRestricted))
+		Expect(settings.Reclamation.WarningLeadTimes).To(Equal([]time.Duration{48 * time.Hour, time.Hour}))
+		Expect(settings.Reclamation.GracePeriod).To(Equal(time.Hour))
+		Expect(settings.SMTPSecret).To(Equal(types.NamespacedName{}))
+	})
+
+	It("rejects the invalid configurations", func() {
+		_, err := Resolve(ctx, reader, defaults, operatorv1.EdgeNetConfigSpec{DisabledReconcilers: []string{"Unknown"}})
+		Expect(err).To(HaveOccurred())
+
+		_, err = Resolve(ctx, reader, defaults, operatorv1.EdgeNetConfigSpec{
+			MaxMind: &operatorv1.MaxMindConfig{
+				URL:       "https://geoip.maxmind.com/geoip/v2.1/city/",
+				SecretRef: operatorv1.SecretReference{Namespace: "edgenet-system", Name: "missing"},
+			},
+		})
+		Expect(err).To(HaveOccurred())
+
+		_, err = Resolve(ctx, reader, defaults, operatorv1.EdgeNetConfigSpec{
+			Notifications: &operatorv1.NotificationSettings{
+				SMTPSecretRef: &operatorv1.SecretReference{Namespace: "edgenet-system", Name: "invalid-smtp"},
+			},
+		})
+		Expect(err).To(HaveOccurred())
+
+		_, err = Resolve(ctx, reader, defaults, operatorv1.EdgeNetConfigSpec{
+			QuotaPolicy: &operatorv1.QuotaPolicy{GracePeriod: &metav1.Duration{Duration: -time.Hour}},
+		})
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("finds the referenced secrets", func() {
+		spec := operatorv1.EdgeNetConfigSpec{
+			Notifications: &operatorv1.NotificationSettings{
+				SMTPSecretRef: &operatorv1.SecretReference{Namespace: "edgenet-system", Name: "smtp-secret"},
+			},
+		}
+		Expect(ReferencesSecret(spec, types.NamespacedName{Namespace: "edgenet-system", Name: "smtp-secret"})).To(BeTrue())
+		Expect(ReferencesSecret(spec, types.NamespacedName{Namespace: "edgenet-system", Name: "maxmind-secret"})).To(BeFalse())
+	})
+
+	It("lists the reconcilers not disabled", func() {
+		active := ActiveReconcilers([]string{"tenant"}, []string{"NodeLabeller", "SelectiveDeployment"})
+		Expect(active).NotTo(ContainElements("Tenant", "NodeLabeller", "SelectiveDeployment"))
+		Expect(active).To(ContainElements("SubNamespace", "EdgeNetConfig"))
+		Expect(active).To(HaveLen(len(Reconcilers) - 3))
+	})
+
+	It("sends no email without the SMTP settings", func() {
+		sender := &SMTPSender{Reader: reader, Settings: NewStore(Settings{})}
+		Expect(sender.Send(ctx, notification.Message{To: []string{"admin@example.com"}})).To(Succeed())
+	})
+})
+
+var _ = Describe("Loader", func() {
+	ctx := context.Background()
+	defaults := Settings{NamespaceDefaults: multitenancyv1.NamespaceDefaults{PodSecurityLevel: multitenancyv1.PodSecurityLevelBaseline}}
+
+	newLoader := func(objs ...client.Object) *Loader {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(operatorv1.AddToScheme(scheme)).To(Succeed())
+		return &Loader{
+			Reader:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(),
+			Defaults: defaults,
+			Store:    NewStore(Settings{ClusterNetworkPolicy: true}),
+		}
+	}
+
+	It("applies the defaults without the EdgeNetConfig", func() {
+		loader := newLoader()
+		Expect(loader.Load(ctx)).To(Succeed())
+		Expect(loader.Store.Get()).To(Equal(defaults))
+	})
+
+	It("applies the settings of the EdgeNetConfig and keeps them if it becomes invalid", func() {
+		config := &operatorv1.EdgeNetConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: operatorv1.EdgeNetConfigName},
+			Spec: operatorv1.EdgeNetConfigSpec{
+				QuotaPolicy: &operatorv1.QuotaPolicy{
+					NamespaceDefaults: &multitenancyv1.NamespaceDefaults{PodSecurityLevel: multitenancyv1.PodSecurityLevelRestricted},
+				},
+			},
+		}
+		loader := newLoader(config)
+		Expect(loader.Load(ctx)).To(Succeed())
+		Expect(loader.Store.Get().NamespaceDefaults.PodSecurityLevel).To(Equal(multitenancyv1.PodSecurityLevelRestricted))
+
+		config.Spec.DisabledReconcilers = []string{"Unknown"}
+		Expect(loader.Reader.(client.Client).Update(ctx, config)).To(Succeed())
+		Expect(loader.Load(ctx)).NotTo(Succeed())
+		Expect(loader.Store.Get().NamespaceDefaults.PodSecurityLevel).To(Equal(multitenancyv1.PodSecurityLevelRestricted))
+	})
+
+	It("keeps the MaxMind with the same credentials", func() {
+		store := NewStore(Settings{})
+		maxMind := labeller.NewMaxMindFromArgs("https://geoip.maxmind.com/geoip/v2.1/city/", "account", "token")
+		store.Set(Settings{MaxMind: maxMind})
+		store.Set(Settings{MaxMind: labeller.NewMaxMindFromArgs("https://geoip.maxmind.com/geoip/v2.1/city/", "account", "token")})
+		Expect(store.Get().MaxMind).To(BeIdenticalTo(maxMind))
+
+		store.Set(Settings{MaxMind: labeller.NewMaxMindFromArgs("https://geoip.maxmind.com/geoip/v2.1/city/", "account", "other")})
+		Expect(labeller.SameMaxMind(store.Get().MaxMind, maxMind)).To(BeFalse())
+	})
+})
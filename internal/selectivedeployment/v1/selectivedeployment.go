@@ -0,0 +1,372 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package selectivedeployment
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	appsv1 "github.com/edgenet-project/edgenet/api/apps/v1"
+	"github.com/edgenet-project/edgenet/internal/multitenancy/v1"
+	kappsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// Label added to the pods of the workload if the template doesn't have a selector.
+	SelectiveDeploymentLabel = "edge-net.io/selective-deployment"
+
+	// Annotation on the workload holding the hash of the rendered spec. The workload is updated if the hash
+	// changes or if its spec drifts from the rendered one.
+	TemplateHashAnnotation = "edge-net.io/template-hash"
+
+	// The only field of the nodes supported by the node selector requirements of the node affinity.
+	NodeNameField = "metadata.name"
+)
+
+// This interface contains the necessary functions to perform the operations related to the selective
+// deployments.
+type SelectiveDeploymentManager interface {
+	// Selects the nodes matching the selectors of the selective deployment.
+	SelectNodes(context.Context, *appsv1.SelectiveDeployment) (SelectionResult, error)
+
+	// Creates or updates the workload so its pods are only scheduled to the given nodes. If there are no nodes
+	// the workload is removed. The workloads of the other kinds created before by the selective deployment are
+	// removed as well.
+	ApplyWorkload(context.Context, *appsv1.SelectiveDeployment, []string) error
+}
+
+type selectiveDeploymentManager struct {
+	SelectiveDeploymentManager
+	client client.Client
+}
+
+func NewSelectiveDeploymentManager(ctx context.Context, client client.Client) (SelectiveDeploymentManager, error) {
+	return &selectiveDeploymentManager{
+		client: client,
+	}, nil
+}
+
+// Lists all of the nodes and selects the ones matching the selectors.
+func (m *selectiveDeploymentManager) SelectNodes(ctx context.Context, sd *appsv1.SelectiveDeployment) (SelectionResult, error) {
+	nodeList := &corev1.NodeList{}
+
+	if err := m.client.List(ctx, nodeList); err != nil {
+		return SelectionResult{}, err
+	}
+
+	return SelectNodes(nodeList.Items, sd.Spec.Selectors)
+}
+
+// Renders the workload from the template and applies it. The workload has the same name as the selective
+// deployment and it is owned by it, so it is removed by the garbage collector with the selective deployment.
+func (m *selectiveDeploymentManager) ApplyWorkload(ctx context.Context, sd *appsv1.SelectiveDeployment, nodes []string) error {
+	// Remove the workloads created before the kind is changed.
+	for _, kind := range []appsv1.WorkloadKind{appsv1.WorkloadKindDeployment, appsv1.WorkloadKindDaemonSet, appsv1.WorkloadKindStatefulSet} {
+		if kind == sd.Spec.Kind && len(nodes) != 0 {
+			continue
+		}
+		if err := m.deleteWorkload(ctx, sd, kind); err != nil {
+			return err
+		}
+	}
+
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	desired, err := RenderWorkload(sd, nodes)
+	if err != nil {
+		return err
+	}
+
+	if err := controllerutil.SetControllerReference(sd, desired, m.client.Scheme()); err != nil {
+		return err
+	}
+
+	current := desired.DeepCopyObject().(client.Object)
+	if err := m.client.Get(ctx, client.ObjectKeyFromObject(desired), current); err != nil {
+		if errors.IsNotFound(err) {
+			return m.client.Create(ctx, desired)
+		}
+		return err
+	}
+
+	if !metav1.IsControlledBy(current, sd) {
+		return fmt.Errorf("%s %s already exists and it is not managed by the selective deployment", sd.Spec.Kind, desired.GetName())
+	}
+
+	if current.GetAnnotations()[TemplateHashAnnotation] == desired.GetAnnotations()[TemplateHashAnnotation] && !hasDrifted(sd, desired, current) {
+		return nil
+	}
+
+	keepSuspension(desired, current)
+	desired.SetResourceVersion(current.GetResourceVersion())
+	return m.client.Update(ctx, desired)
+}
+
+// Deletes the workload of the given kind if it is created by the selective deployment.
+func (m *selectiveDeploymentManager) deleteWorkload(ctx context.Context, sd *appsv1.SelectiveDeployment, kind appsv1.WorkloadKind) error {
+	workload, err := newWorkload(kind)
+	if err != nil {
+		return err
+	}
+
+	if err := m.client.Get(ctx, types.NamespacedName{Name: sd.GetName(), Namespace: sd.GetNamespace()}, workload); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	if !metav1.IsControlledBy(workload, sd) {
+		return nil
+	}
+
+	return client.IgnoreNotFound(m.client.Delete(ctx, workload))
+}
+
+// Checks if the workload is changed by someone else since it is rendered. The fields that are not set in the
+// desired workload are ignored, otherwise the defaults set by the API server would cause an update on every
+// reconciliation. The replicas are only compared if the template sets them, so the workload can be scaled by
+// an autoscaler or the suspension of the tenant.
+func hasDrifted(sd *appsv1.SelectiveDeployment, desired, current client.Object) bool {
+	if !equality.Semantic.DeepDerivative(desired.GetLabels(), current.GetLabels()) {
+		return true
+	}
+
+	explicitReplicas := templateSetsReplicas(sd)
+
+	switch desired := desired.(type) {
+	case *kappsv1.Deployment:
+		current, ok := current.(*kappsv1.Deployment)
+		spec := desired.Spec.DeepCopy()
+		if !explicitReplicas {
+			spec.Replicas = nil
+		}
+		return !ok || !equality.Semantic.DeepDerivative(*spec, current.Spec)
+	case *kappsv1.DaemonSet:
+		current, ok := current.(*kappsv1.DaemonSet)
+		return !ok || !equality.Semantic.DeepDerivative(desired.Spec, current.Spec)
+	case *kappsv1.StatefulSet:
+		current, ok := current.(*kappsv1.StatefulSet)
+		spec := desired.Spec.DeepCopy()
+		if !explicitReplicas {
+			spec.Replicas = nil
+		}
+		return !ok || !equality.Semantic.DeepDerivative(*spec, current.Spec)
+	default:
+		return true
+	}
+}
+
+// Checks if the template of the selective deployment gives the replicas, otherwise they are set to the number of
+// the nodes.
+func templateSetsReplicas(sd *appsv1.SelectiveDeployment) bool {
+	template := struct {
+		Replicas *int32 `json:"replicas"`
+	}{}
+	if err := json.Unmarshal(sd.Spec.Template.Raw, &template); err != nil {
+		return false
+	}
+	return template.Replicas != nil
+}
+
+// The workload of a suspended tenant is scaled down, it keeps the replicas and the annotation of the suspension
+// when it is rendered again so it can be restored.
+func keepSuspension(desired, current client.Object) {
+	suspended, ok := current.GetAnnotations()[multitenancy.SuspendedReplicasAnnotation]
+	if !ok {
+		return
+	}
+	desired.GetAnnotations()[multitenancy.SuspendedReplicasAnnotation] = suspended
+
+	switch desired := desired.(type) {
+	case *kappsv1.Deployment:
+		if current, ok := current.(*kappsv1.Deployment); ok {
+			desired.Spec.Replicas = current.Spec.Replicas
+		}
+	case *kappsv1.StatefulSet:
+		if current, ok := current.(*kappsv1.StatefulSet); ok {
+			desired.Spec.Replicas = current.Spec.Replicas
+		}
+	}
+}
+
+// Creates an empty workload of the given kind.
+func newWorkload(kind appsv1.WorkloadKind) (client.Object, error) {
+	switch kind {
+	case appsv1.WorkloadKindDeployment:
+		return &kappsv1.Deployment{}, nil
+	case appsv1.WorkloadKindDaemonSet:
+		return &kappsv1.DaemonSet{}, nil
+	case appsv1.WorkloadKindStatefulSet:
+		return &kappsv1.StatefulSet{}, nil
+	default:
+		return nil, fmt.Errorf("unknown workload kind %q", kind)
+	}
+}
+
+// RenderWorkload creates the workload of the selective deployment from its template. The pods are restricted to
+// the given nodes with a required node affinity, the existing node affinity of the template is kept.
+func RenderWorkload(sd *appsv1.SelectiveDeployment, nodes []string) (client.Object, error) {
+	objectMeta := metav1.ObjectMeta{
+		Name:        sd.GetName(),
+		Namespace:   sd.GetNamespace(),
+		Labels:      map[string]string{SelectiveDeploymentLabel: sd.GetName()},
+		Annotations: map[string]string{},
+	}
+
+	// The replicas are set to the number of the nodes if they are not given.
+	replicas := int32(len(nodes))
+
+	var workload client.Object
+	var podTemplate *corev1.PodTemplateSpec
+	var selector **metav1.LabelSelector
+
+	switch sd.Spec.Kind {
+	case appsv1.WorkloadKindDeployment:
+		deployment := &kappsv1.Deployment{ObjectMeta: objectMeta}
+		if err := unmarshalTemplate(sd, &deployment.Spec); err != nil {
+			return nil, err
+		}
+		if deployment.Spec.Replicas == nil {
+			deployment.Spec.Replicas = &replicas
+		}
+		workload, podTemplate, selector = deployment, &deployment.Spec.Template, &deployment.Spec.Selector
+	case appsv1.WorkloadKindDaemonSet:
+		daemonSet := &kappsv1.DaemonSet{ObjectMeta: objectMeta}
+		if err := unmarshalTemplate(sd, &daemonSet.Spec); err != nil {
+			return nil, err
+		}
+		workload, podTemplate, selector = daemonSet, &daemonSet.Spec.Template, &daemonSet.Spec.Selector
+	case appsv1.WorkloadKindStatefulSet:
+		statefulSet := &kappsv1.StatefulSet{ObjectMeta: objectMeta}
+		if err := unmarshalTemplate(sd, &statefulSet.Spec); err != nil {
+			return nil, err
+		}
+		if statefulSet.Spec.Replicas == nil {
+			statefulSet.Spec.Replicas = &replicas
+		}
+		workload, podTemplate, selector = statefulSet, &statefulSet.Spec.Template, &statefulSet.Spec.Selector
+	default:
+		return nil, fmt.Errorf("unknown workload kind %q", sd.Spec.Kind)
+	}
+
+	// Select the pods by the name of the selective deployment if the template doesn't have a selector.
+	if *selector == nil {
+		*selector = &metav1.LabelSelector{MatchLabels: map[string]string{SelectiveDeploymentLabel: sd.GetName()}}
+		if podTemplate.Labels == nil {
+			podTemplate.Labels = map[string]string{}
+		}
+		podTemplate.Labels[SelectiveDeploymentLabel] = sd.GetName()
+	}
+
+	setNodeAffinity(&podTemplate.Spec, nodes)
+
+	// The replicas of a deployment or a stateful set are spread over the selected nodes, otherwise the scheduler
+	// could put all of them on a single node. A daemon set already runs one pod on each node.
+	if sd.Spec.Kind != appsv1.WorkloadKindDaemonSet {
+		setHostnameSpread(&podTemplate.Spec, *selector)
+	}
+
+	hash, err := hashObject(workload)
+	if err != nil {
+		return nil, err
+	}
+	workload.GetAnnotations()[TemplateHashAnnotation] = hash
+
+	return workload, nil
+}
+
+// Decodes the template of the selective deployment into the spec of the workload.
+func unmarshalTemplate(sd *appsv1.SelectiveDeployment, spec interface{}) error {
+	if len(sd.Spec.Template.Raw) == 0 {
+		return fmt.Errorf("the template of the selective deployment is empty")
+	}
+	if err := json.Unmarshal(sd.Spec.Template.Raw, spec); err != nil {
+		return fmt.Errorf("invalid %s template: %w", sd.Spec.Kind, err)
+	}
+	return nil
+}
+
+// Restricts the pods to the given nodes. The nodes are matched by their names rather than the hostname label,
+// which can differ from the name of the node. The node selector terms are ORed by the scheduler, so the
+// requirement is added to each of the terms of the template.
+func setNodeAffinity(podSpec *corev1.PodSpec, nodes []string) {
+	requirement := corev1.NodeSelectorRequirement{
+		Key:      NodeNameField,
+		Operator: corev1.NodeSelectorOpIn,
+		Values:   nodes,
+	}
+
+	if podSpec.Affinity == nil {
+		podSpec.Affinity = &corev1.Affinity{}
+	}
+	if podSpec.Affinity.NodeAffinity == nil {
+		podSpec.Affinity.NodeAffinity = &corev1.NodeAffinity{}
+	}
+	if podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &corev1.NodeSelector{}
+	}
+
+	nodeSelector := podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if len(nodeSelector.NodeSelectorTerms) == 0 {
+		nodeSelector.NodeSelectorTerms = []corev1.NodeSelectorTerm{{}}
+	}
+
+	for i := range nodeSelector.NodeSelectorTerms {
+		term := &nodeSelector.NodeSelectorTerms[i]
+		term.MatchFields = append(term.MatchFields, requirement)
+	}
+}
+
+// Spreads the pods selected by the selector over the nodes evenly. The nodes that are not ready are tainted and
+// left out of the spread by the taints policy, so they don't block the pods from the other nodes. The constraint
+// of the template on the hostname is kept if there is one.
+func setHostnameSpread(podSpec *corev1.PodSpec, selector *metav1.LabelSelector) {
+	for _, constraint := range podSpec.TopologySpreadConstraints {
+		if constraint.TopologyKey == corev1.LabelHostname {
+			return
+		}
+	}
+
+	honor := corev1.NodeInclusionPolicyHonor
+	podSpec.TopologySpreadConstraints = append(podSpec.TopologySpreadConstraints, corev1.TopologySpreadConstraint{
+		MaxSkew:           1,
+		TopologyKey:       corev1.LabelHostname,
+		WhenUnsatisfiable: corev1.DoNotSchedule,
+		LabelSelector:     selector.DeepCopy(),
+		NodeTaintsPolicy:  &honor,
+	})
+}
+
+// Hashes the JSON representation of the object.
+func hashObject(obj interface{}) (string, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:16], nil
+}
@@ -0,0 +1,376 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package selectivedeployment
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	appsv1 "github.com/edgenet-project/edgenet/api/apps/v1"
+	"github.com/edgenet-project/edgenet/internal/labeller/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Mean radius of the earth used by the distance calculations.
+const earthRadiusKilometers = 6371.0
+
+// SelectionResult contains the nodes selected for a selective deployment.
+type SelectionResult struct {
+	// Names of the selected nodes, sorted.
+	Nodes []string
+
+	// Explains the selectors that cannot select the requested quantity of nodes.
+	Unsatisfied []string
+}
+
+// Selects the nodes for the given selectors. The cordoned and the deleted nodes are not considered. The nodes that
+// are not ready are kept, the scheduler avoids them by their taints; the edge nodes often flap between ready and
+// not ready, dropping them would roll out the workload each time. The result contains the nodes selected by any of
+// the selectors. Nodes are picked in the order of their names so the selection is stable as long as the nodes
+// don't change.
+func SelectNodes(nodes []corev1.Node, selectors []appsv1.Selector) (SelectionResult, error) {
+	candidates := []*corev1.Node{}
+	for i := range nodes {
+		if isNodeSelectable(&nodes[i]) {
+			candidates = append(candidates, &nodes[i])
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].GetName() < candidates[j].GetName()
+	})
+
+	selected := map[string]bool{}
+	result := SelectionResult{}
+
+	for i, selector := range selectors {
+		matcher, err := newSelectorMatcher(selector)
+		if err != nil {
+			return SelectionResult{}, fmt.Errorf("selector %d: %w", i, err)
+		}
+
+		matching := []*corev1.Node{}
+		for _, node := range candidates {
+			if matcher.matches(node.GetLabels()) {
+				matching = append(matching, node)
+			}
+		}
+
+		names, unsatisfied := applyQuantity(matching, selector)
+		for _, name := range names {
+			selected[name] = true
+		}
+		for _, message := range unsatisfied {
+			result.Unsatisfied = append(result.Unsatisfied, fmt.Sprintf("selector %d: %s", i, message))
+		}
+	}
+
+	for name := range selected {
+		result.Nodes = append(result.Nodes, name)
+	}
+	sort.Strings(result.Nodes)
+
+	return result, nil
+}
+
+// Checks if the node can be selected, it shouldn't be cordoned or being deleted.
+func isNodeSelectable(node *corev1.Node) bool {
+	return !node.Spec.Unschedulable && node.GetDeletionTimestamp().IsZero()
+}
+
+// Limits the matching nodes to the quantity of the selector. If the selector groups the nodes, the quantity is
+// applied to each group. Returns the selected nodes and the groups that have less nodes than requested.
+func applyQuantity(matching []*corev1.Node, selector appsv1.Selector) ([]string, []string) {
+	names := []string{}
+	unsatisfied := []string{}
+
+	if selector.Quantity == 0 {
+		for _, node := range matching {
+			names = append(names, node.GetName())
+		}
+		if len(names) == 0 {
+			unsatisfied = append(unsatisfied, "no nodes match")
+		}
+		return names, unsatisfied
+	}
+
+	if selector.GroupBy == "" {
+		for _, node := range matching {
+			if len(names) == selector.Quantity {
+				break
+			}
+			names = append(names, node.GetName())
+		}
+		if len(names) < selector.Quantity {
+			unsatisfied = append(unsatisfied, fmt.Sprintf("%d of %d nodes selected", len(names), selector.Quantity))
+		}
+		return names, unsatisfied
+	}
+
+	key := groupLabel(selector.GroupBy)
+	groups := map[string][]string{}
+	for _, node := range matching {
+		value, ok := node.GetLabels()[key]
+		if !ok {
+			continue
+		}
+		value = strings.ToLower(value)
+		if len(groups[value]) < selector.Quantity {
+			groups[value] = append(groups[value], node.GetName())
+		}
+	}
+
+	// If the values of the group are listed in the selector, each of them is expected to have nodes.
+	expected := groupValues(selector)
+	if len(expected) == 0 {
+		for value := range groups {
+			expected = append(expected, value)
+		}
+	}
+	sort.Strings(expected)
+
+	for _, value := range expected {
+		group := groups[strings.ToLower(labeller.SanitizeLabelValue(value))]
+		names = append(names, group...)
+		if len(group) < selector.Quantity {
+			unsatisfied = append(unsatisfied, fmt.Sprintf("%d of %d nodes selected in %s", len(group), selector.Quantity, value))
+		}
+	}
+
+	if len(expected) == 0 {
+		unsatisfied = append(unsatisfied, "no nodes match")
+	}
+
+	return names, unsatisfied
+}
+
+// Returns the node label of the group.
+func groupLabel(group appsv1.SelectorGroup) string {
+	switch group {
+	case appsv1.SelectorGroupCity:
+		return labeller.CityLabel
+	case appsv1.SelectorGroupState:
+		return labeller.StateLabel
+	case appsv1.SelectorGroupCountry:
+		return labeller.CountryLabel
+	default:
+		return labeller.ContinentLabel
+	}
+}
+
+// Returns the values listed in the selector for its group.
+func groupValues(selector appsv1.Selector) []string {
+	switch selector.GroupBy {
+	case appsv1.SelectorGroupCity:
+		return append([]string{}, selector.Cities...)
+	case appsv1.SelectorGroupState:
+		return append([]string{}, selector.States...)
+	case appsv1.SelectorGroupCountry:
+		return append([]string{}, selector.Countries...)
+	default:
+		return append([]string{}, selector.Continents...)
+	}
+}
+
+// selectorMatcher is a selector with its radius and polygon parsed.
+type selectorMatcher struct {
+	selector appsv1.Selector
+
+	hasCenter bool
+	latitude  float64
+	longitude float64
+
+	// Each polygon is a list of rings, the first ring is the exterior and the others are the holes.
+	polygons [][][][2]float64
+}
+
+// Parses the radius and the polygon of the selector.
+func newSelectorMatcher(selector appsv1.Selector) (*selectorMatcher, error) {
+	m := &selectorMatcher{selector: selector}
+
+	if selector.Radius != nil {
+		latitude, err := strconv.ParseFloat(selector.Radius.Latitude, 64)
+		if err != nil || latitude < -90 || latitude > 90 {
+			return nil, fmt.Errorf("invalid latitude %q", selector.Radius.Latitude)
+		}
+		longitude, err := strconv.ParseFloat(selector.Radius.Longitude, 64)
+		if err != nil || longitude < -180 || longitude > 180 {
+			return nil, fmt.Errorf("invalid longitude %q", selector.Radius.Longitude)
+		}
+		m.hasCenter = true
+		m.latitude = latitude
+		m.longitude = longitude
+	}
+
+	if selector.Polygon != "" {
+		polygons, err := ParseGeoJSONPolygons(selector.Polygon)
+		if err != nil {
+			return nil, err
+		}
+		m.polygons = polygons
+	}
+
+	return m, nil
+}
+
+// Checks if the labels of a node match all of the criteria of the selector.
+func (m *selectorMatcher) matches(labels map[string]string) bool {
+	if !matchesValue(labels, labeller.CountryLabel, m.selector.Countries) ||
+		!matchesValue(labels, labeller.StateLabel, m.selector.States) ||
+		!matchesValue(labels, labeller.CityLabel, m.selector.Cities) ||
+		!matchesValue(labels, labeller.ContinentLabel, m.selector.Continents) {
+		return false
+	}
+
+	if !m.hasCenter && len(m.polygons) == 0 {
+		return true
+	}
+
+	latitude, longitude, ok := labeller.ParseCoordinates(labels)
+	if !ok {
+		return false
+	}
+
+	if m.hasCenter && Distance(m.latitude, m.longitude, latitude, longitude) > float64(m.selector.Radius.Kilometers) {
+		return false
+	}
+
+	if len(m.polygons) != 0 && !containsPoint(m.polygons, latitude, longitude) {
+		return false
+	}
+
+	return true
+}
+
+// Checks if the label is one of the values, if there are no values any node matches.
+func matchesValue(labels map[string]string, key string, values []string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	label, ok := labels[key]
+	if !ok {
+		return false
+	}
+	for _, value := range values {
+		if strings.EqualFold(label, labeller.SanitizeLabelValue(value)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Distance returns the great-circle distance between two points in kilometers using the haversine formula.
+func Distance(latitude1, longitude1, latitude2, longitude2 float64) float64 {
+	toRadians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+
+	dLatitude := toRadians(latitude2 - latitude1)
+	dLongitude := toRadians(longitude2 - longitude1)
+
+	a := math.Sin(dLatitude/2)*math.Sin(dLatitude/2) +
+		math.Cos(toRadians(latitude1))*math.Cos(toRadians(latitude2))*math.Sin(dLongitude/2)*math.Sin(dLongitude/2)
+
+	return 2 * earthRadiusKilometers * math.Asin(math.Sqrt(a))
+}
+
+// geoJSON contains the fields of the GeoJSON objects used by the selectors.
+type geoJSON struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+	Geometry    *geoJSON        `json:"geometry"`
+}
+
+// ParseGeoJSONPolygons parses a GeoJSON Polygon, MultiPolygon or a Feature containing one of them. The positions
+// are in the GeoJSON order, longitude first.
+func ParseGeoJSONPolygons(data string) ([][][][2]float64, error) {
+	object := geoJSON{}
+	if err := json.Unmarshal([]byte(data), &object); err != nil {
+		return nil, fmt.Errorf("invalid GeoJSON: %w", err)
+	}
+
+	if object.Type == "Feature" {
+		if object.Geometry == nil {
+			return nil, fmt.Errorf("the GeoJSON feature doesn't have a geometry")
+		}
+		object = *object.Geometry
+	}
+
+	polygons := [][][][2]float64{}
+
+	switch object.Type {
+	case "Polygon":
+		polygon := [][][2]float64{}
+		if err := json.Unmarshal(object.Coordinates, &polygon); err != nil {
+			return nil, fmt.Errorf("invalid GeoJSON polygon: %w", err)
+		}
+		polygons = append(polygons, polygon)
+	case "MultiPolygon":
+		if err := json.Unmarshal(object.Coordinates, &polygons); err != nil {
+			return nil, fmt.Errorf("invalid GeoJSON multipolygon: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("GeoJSON type %q is not supported, it should be a Polygon or a MultiPolygon", object.Type)
+	}
+
+	for _, polygon := range polygons {
+		if len(polygon) == 0 {
+			return nil, fmt.Errorf("the GeoJSON polygon doesn't have any rings")
+		}
+		for _, ring := range polygon {
+			if len(ring) < 4 {
+				return nil, fmt.Errorf("a GeoJSON ring should have at least 4 positions")
+			}
+		}
+	}
+
+	return polygons, nil
+}
+
+// Checks if the point is inside any of the polygons and not in one of their holes.
+func containsPoint(polygons [][][][2]float64, latitude, longitude float64) bool {
+	for _, polygon := range polygons {
+		if !ringContainsPoint(polygon[0], latitude, longitude) {
+			continue
+		}
+		inHole := false
+		for _, hole := range polygon[1:] {
+			if ringContainsPoint(hole, latitude, longitude) {
+				inHole = true
+				break
+			}
+		}
+		if !inHole {
+			return true
+		}
+	}
+	return false
+}
+
+// Ray casting algorithm, the coordinates are treated as planar which is accurate enough for the node selection.
+func ringContainsPoint(ring [][2]float64, latitude, longitude float64) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+		if (yi > latitude) != (yj > latitude) && longitude < (xj-xi)*(latitude-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
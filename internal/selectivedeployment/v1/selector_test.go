@@ -0,0 +1,227 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package selectivedeployment
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	kappsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	appsv1 "github.com/edgenet-project/edgenet/api/apps/v1"
+	"github.com/edgenet-project/edgenet/internal/labeller/v1"
+	"github.com/edgenet-project/edgenet/internal/multitenancy/v1"
+)
+
+// Creates a ready node with the geolocation labels.
+func newNode(name, city, country, continent string, latitude, longitude float64) corev1.Node {
+	return corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				labeller.CityLabel:      city,
+				labeller.CountryLabel:   country,
+				labeller.ContinentLabel: continent,
+				labeller.LatitudeLabel:  labeller.FormatLatitude(latitude),
+				labeller.LongitudeLabel: labeller.FormatLongitude(longitude),
+			},
+		},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	}
+}
+
+var _ = Describe("SelectNodes", func() {
+	nodes := []corev1.Node{
+		newNode("paris-1", "Paris", "FR", "Europe", 48.8566, 2.3522),
+		newNode("paris-2", "Paris", "FR", "Europe", 48.86, 2.34),
+		newNode("lyon-1", "Lyon", "FR", "Europe", 45.764, 4.8357),
+		newNode("tokyo-1", "Tokyo", "JP", "Asia", 35.6762, 139.6503),
+		newNode("sydney-1", "Sydney", "AU", "Oceania", -33.8688, 151.2093),
+	}
+
+	It("should select the nodes by country", func() {
+		result, err := SelectNodes(nodes, []appsv1.Selector{{Countries: []string{"fr", "JP"}}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Nodes).To(Equal([]string{"lyon-1", "paris-1", "paris-2", "tokyo-1"}))
+		Expect(result.Unsatisfied).To(BeEmpty())
+	})
+
+	It("should keep the nodes that are not ready and skip the cordoned ones", func() {
+		notReady := newNode("paris-3", "Paris", "FR", "Europe", 48.85, 2.35)
+		notReady.Status.Conditions[0].Status = corev1.ConditionFalse
+		cordoned := newNode("paris-4", "Paris", "FR", "Europe", 48.85, 2.35)
+		cordoned.Spec.Unschedulable = true
+
+		result, err := SelectNodes(append([]corev1.Node{notReady, cordoned}, nodes...), []appsv1.Selector{{Cities: []string{"Paris"}}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Nodes).To(Equal([]string{"paris-1", "paris-2", "paris-3"}))
+	})
+
+	It("should select the nodes within the radius", func() {
+		selector := appsv1.Selector{Radius: &appsv1.RadiusSelector{Latitude: "48.8566", Longitude: "2.3522", Kilometers: 500}}
+		result, err := SelectNodes(nodes, []appsv1.Selector{selector})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Nodes).To(Equal([]string{"lyon-1", "paris-1", "paris-2"}))
+	})
+
+	It("should select the nodes inside the polygon", func() {
+		// A rough box around Japan, the positions are longitude first.
+		polygon := `{"type":"Feature","geometry":{"type":"Polygon","coordinates":[[[128,30],[146,30],[146,46],[128,46],[128,30]]]}}`
+		result, err := SelectNodes(nodes, []appsv1.Selector{{Polygon: polygon}})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Nodes).To(Equal([]string{"tokyo-1"}))
+	})
+
+	It("should select a quantity of nodes per group", func() {
+		selector := appsv1.Selector{Countries: []string{"FR", "AU", "US"}, Quantity: 1, GroupBy: appsv1.SelectorGroupCountry}
+		result, err := SelectNodes(nodes, []appsv1.Selector{selector})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Nodes).To(Equal([]string{"lyon-1", "sydney-1"}))
+		Expect(result.Unsatisfied).To(Equal([]string{"selector 0: 0 of 1 nodes selected in US"}))
+	})
+
+	It("should group the nodes by the sanitized values of the selector", func() {
+		newYork := newNode("new-york-1", "New York", "US", "North_America", 40.7128, -74.006)
+		selector := appsv1.Selector{Continents: []string{"North America"}, Quantity: 1, GroupBy: appsv1.SelectorGroupContinent}
+		result, err := SelectNodes(append([]corev1.Node{newYork}, nodes...), []appsv1.Selector{selector})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(result.Nodes).To(Equal([]string{"new-york-1"}))
+		Expect(result.Unsatisfied).To(BeEmpty())
+	})
+
+	It("should reject invalid polygons", func() {
+		_, err := SelectNodes(nodes, []appsv1.Selector{{Polygon: `{"type":"Point","coordinates":[0,0]}`}})
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("RenderWorkload", func() {
+	It("should restrict the pods to the selected nodes", func() {
+		sd := &appsv1.SelectiveDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "lab"},
+			Spec: appsv1.SelectiveDeploymentSpec{
+				Kind:     appsv1.WorkloadKindDaemonSet,
+				Template: runtime.RawExtension{Raw: []byte(`{"template":{"spec":{"containers":[{"name":"nginx","image":"nginx"}]}}}`)},
+			},
+		}
+
+		workload, err := RenderWorkload(sd, []string{"paris-1", "tokyo-1"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(workload.GetAnnotations()).To(HaveKey(TemplateHashAnnotation))
+
+		daemonSet, ok := workload.(*kappsv1.DaemonSet)
+		Expect(ok).To(BeTrue())
+		Expect(daemonSet.Spec.Selector.MatchLabels).To(HaveKeyWithValue(SelectiveDeploymentLabel, "nginx"))
+
+		terms := daemonSet.Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+		Expect(terms).To(HaveLen(1))
+		Expect(terms[0].MatchFields).To(ContainElement(corev1.NodeSelectorRequirement{
+			Key:      NodeNameField,
+			Operator: corev1.NodeSelectorOpIn,
+			Values:   []string{"paris-1", "tokyo-1"},
+		}))
+		Expect(daemonSet.Spec.Template.Spec.TopologySpreadConstraints).To(BeEmpty())
+	})
+
+	It("should spread the replicas of a deployment over the nodes", func() {
+		sd := &appsv1.SelectiveDeployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "lab"},
+			Spec: appsv1.SelectiveDeploymentSpec{
+				Kind:     appsv1.WorkloadKindDeployment,
+				Template: runtime.RawExtension{Raw: []byte(`{"template":{"spec":{"containers":[{"name":"nginx","image":"nginx"}]}}}`)},
+			},
+		}
+
+		workload, err := RenderWorkload(sd, []string{"paris-1", "paris-2"})
+		Expect(err).NotTo(HaveOccurred())
+
+		deployment := workload.(*kappsv1.Deployment)
+		Expect(*deployment.Spec.Replicas).To(Equal(int32(2)))
+		constraints := deployment.Spec.Template.Spec.TopologySpreadConstraints
+		Expect(constraints).To(HaveLen(1))
+		Expect(constraints[0].TopologyKey).To(Equal(corev1.LabelHostname))
+		Expect(constraints[0].WhenUnsatisfiable).To(Equal(corev1.DoNotSchedule))
+		Expect(constraints[0].LabelSelector).To(Equal(deployment.Spec.Selector))
+	})
+})
+
+var _ = Describe("hasDrifted", func() {
+	sd := &appsv1.SelectiveDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "lab"},
+		Spec: appsv1.SelectiveDeploymentSpec{
+			Kind:     appsv1.WorkloadKindDeployment,
+			Template: runtime.RawExtension{Raw: []byte(`{"template":{"spec":{"containers":[{"name":"nginx","image":"nginx"}]}}}`)},
+		},
+	}
+
+	It("should ignore the defaults set by the API server", func() {
+		desired, err := RenderWorkload(sd, []string{"paris-1"})
+		Expect(err).NotTo(HaveOccurred())
+
+		current := desired.DeepCopyObject().(*kappsv1.Deployment)
+		current.Spec.Template.Spec.RestartPolicy = corev1.RestartPolicyAlways
+		current.Spec.Template.Spec.Containers[0].TerminationMessagePath = corev1.TerminationMessagePathDefault
+		Expect(hasDrifted(sd, desired, current)).To(BeFalse())
+	})
+
+	It("should ignore the replicas not given by the template", func() {
+		desired, err := RenderWorkload(sd, []string{"paris-1"})
+		Expect(err).NotTo(HaveOccurred())
+
+		current := desired.DeepCopyObject().(*kappsv1.Deployment)
+		scaled := int32(5)
+		current.Spec.Replicas = &scaled
+		Expect(hasDrifted(sd, desired, current)).To(BeFalse())
+
+		explicit := sd.DeepCopy()
+		explicit.Spec.Template.Raw = []byte(`{"replicas":1,"template":{"spec":{"containers":[{"name":"nginx","image":"nginx"}]}}}`)
+		desired, err = RenderWorkload(explicit, []string{"paris-1"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(hasDrifted(explicit, desired, current)).To(BeTrue())
+	})
+
+	It("should keep the replicas of a suspended workload", func() {
+		desired, err := RenderWorkload(sd, []string{"paris-1", "paris-2"})
+		Expect(err).NotTo(HaveOccurred())
+
+		current := desired.DeepCopyObject().(*kappsv1.Deployment)
+		suspended := int32(0)
+		current.Spec.Replicas = &suspended
+		current.Annotations[multitenancy.SuspendedReplicasAnnotation] = "2"
+
+		keepSuspension(desired, current)
+		Expect(*desired.(*kappsv1.Deployment).Spec.Replicas).To(Equal(int32(0)))
+		Expect(desired.GetAnnotations()).To(HaveKeyWithValue(multitenancy.SuspendedReplicasAnnotation, "2"))
+	})
+
+	It("should detect the changes of the spec", func() {
+		desired, err := RenderWorkload(sd, []string{"paris-1"})
+		Expect(err).NotTo(HaveOccurred())
+
+		current := desired.DeepCopyObject().(*kappsv1.Deployment)
+		current.Spec.Template.Spec.Affinity = nil
+		Expect(hasDrifted(sd, desired, current)).To(BeTrue())
+
+		current = desired.DeepCopyObject().(*kappsv1.Deployment)
+		current.Spec.Template.Spec.Containers[0].Image = "httpd"
+		Expect(hasDrifted(sd, desired, current)).To(BeTrue())
+	})
+})
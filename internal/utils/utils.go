@@ -23,6 +23,7 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -32,15 +33,19 @@ import (
 	errorsLegacy "errors"
 
 	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
-	"github.com/go-logr/logr"
 )
 
 // Define a custom type that implements the flag.Value interface
 type FlagList []string
 
-// Implement the Set method for the flag.Value interface
+// Implement the Set method for the flag.Value interface. The values can be given as a comma separated list or
+// by repeating the flag.
 func (s *FlagList) Set(value string) error {
-	*s = append(*s, value)
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.Trim(strings.TrimSpace(v), `"`); v != "" {
+			*s = append(*s, v)
+		}
+	}
 	return nil
 }
 
@@ -60,6 +65,29 @@ func (s *FlagList) Contains(flag string) bool {
 	return false
 }
 
+// Parses a comma separated list of resources such as "cpu=100m,memory=128Mi". Returns nil for an empty list.
+func ParseResourceList(value string) (corev1.ResourceList, error) {
+	var resources corev1.ResourceList
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		name, quantity, found := strings.Cut(item, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid resource %q, expected name=quantity", item)
+		}
+		parsed, err := resource.ParseQuantity(strings.TrimSpace(quantity))
+		if err != nil {
+			return nil, fmt.Errorf("invalid quantity of the resource %q: %w", name, err)
+		}
+		if resources == nil {
+			resources = corev1.ResourceList{}
+		}
+		resources[corev1.ResourceName(strings.TrimSpace(name))] = parsed
+	}
+	return resources, nil
+}
+
 // Resolve the core-namespace from tenant name (simply take the object name)
 func ResolveCoreNamespaceName(tenantName string) string {
 	return tenantName
@@ -91,6 +119,28 @@ func RemoveFinalizer(finalizers []string, finalizer string) []string {
 	return finalizers
 }
 
+// Checks if all of the expected labels exist in the labels with the same values.
+func ContainsLabels(labels, expected map[string]string) bool {
+	for key, value := range expected {
+		if v, ok := labels[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns a new map containing the labels overwritten by the given overrides.
+func MergeLabels(labels, overrides map[string]string) map[string]string {
+	merged := make(map[string]string, len(labels)+len(overrides))
+	for key, value := range labels {
+		merged[key] = value
+	}
+	for key, value := range overrides {
+		merged[key] = value
+	}
+	return merged
+}
+
 // Gets the object from by using the given client c and the address of the obj as well as namespacedName variable.
 // Populates the obj variable if the object exists.
 // Return values are (isDeleted, response, error)
@@ -171,29 +221,35 @@ func GetClusterUID(ctx context.Context, client client.Client) (types.UID, error)
 	return namespace.GetUID(), nil
 }
 
+// The event recorder requires these permissions.
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+
 // Gets the event recorder from the manager. This event recorder is used for sending events for objects.
 func GetEventRecorder(mgr ctrl.Manager) record.EventRecorder {
 	return mgr.GetEventRecorderFor("edgenet-controller")
 }
 
-// Sends an error to the object using the event recorder. The type is error.
-func RecordEventError(l *logr.Logger, r record.EventRecorder, obj client.Object, message string) {
-	if l != nil {
-		l.Error(errorsLegacy.New("error"), message)
-	}
-
-	if r != nil {
-		r.Eventf(obj, "Warning", "Error", message)
-	}
+// Checks if the error is temporary and the request is likely to succeed when it is retried, for example the
+// conflicts of the optimistic locking and the timeouts of the API server.
+func IsTransientError(err error) bool {
+	return errors.IsConflict(err) || errors.IsServerTimeout(err) || errors.IsTimeout(err) ||
+		errors.IsTooManyRequests(err) || errors.IsServiceUnavailable(err) || errorsLegacy.Is(err, context.DeadlineExceeded)
 }
 
-// Sends an update event to the object using the event recorder.
-func RecordEventInfo(l *logr.Logger, r record.EventRecorder, obj client.Object, message string) {
-	if l != nil {
-		l.Info(message)
+// Checks if the node is ready and accepts new pods.
+func IsNodeSchedulable(node *corev1.Node) bool {
+	if node.Spec.Unschedulable || !node.GetDeletionTimestamp().IsZero() {
+		return false
 	}
+	return IsNodeReady(node)
+}
 
-	if r != nil {
-		r.Eventf(obj, "Normal", "Update", message)
+// Checks the ready condition of the node.
+func IsNodeReady(node *corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
 	}
+	return false
 }
@@ -0,0 +1,193 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"fmt"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/labeller/v1"
+)
+
+// Returns the alternative requirements of the restriction, a node is allowed if it satisfies one of them.
+func locationRequirements(restriction *multitenancyv1.LocationRestriction) []corev1.NodeSelectorRequirement {
+	requirements := []corev1.NodeSelectorRequirement{}
+
+	if len(restriction.Countries) != 0 {
+		requirements = append(requirements, corev1.NodeSelectorRequirement{
+			Key:      labeller.CountryLabel,
+			Operator: corev1.NodeSelectorOpIn,
+			Values:   restriction.Countries,
+		})
+	}
+
+	if len(restriction.Continents) != 0 {
+		requirements = append(requirements, corev1.NodeSelectorRequirement{
+			Key:      labeller.ContinentLabel,
+			Operator: corev1.NodeSelectorOpIn,
+			Values:   restriction.Continents,
+		})
+	}
+
+	return requirements
+}
+
+// Restricts the required node affinity of the pod to the allowed locations. The terms of the node affinity are
+// ORed and the requirements of a term are ANDed, so each term is split into one term per alternative location
+// requirement. The terms that already have one of the requirements are left as they are.
+func addLocationAffinity(pod *corev1.Pod, restriction *multitenancyv1.LocationRestriction) {
+	requirements := locationRequirements(restriction)
+	if len(requirements) == 0 {
+		return
+	}
+
+	if pod.Spec.Affinity == nil {
+		pod.Spec.Affinity = &corev1.Affinity{}
+	}
+	if pod.Spec.Affinity.NodeAffinity == nil {
+		pod.Spec.Affinity.NodeAffinity = &corev1.NodeAffinity{}
+	}
+
+	nodeAffinity := pod.Spec.Affinity.NodeAffinity
+	if nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &corev1.NodeSelector{}
+	}
+
+	selector := nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if len(selector.NodeSelectorTerms) == 0 {
+		selector.NodeSelectorTerms = []corev1.NodeSelectorTerm{{}}
+	}
+
+	terms := []corev1.NodeSelectorTerm{}
+	for _, term := range selector.NodeSelectorTerms {
+		if hasLocationRequirement(term, requirements) {
+			terms = append(terms, term)
+			continue
+		}
+		for _, requirement := range requirements {
+			restricted := *term.DeepCopy()
+			restricted.MatchExpressions = append(restricted.MatchExpressions, requirement)
+			terms = append(terms, restricted)
+		}
+	}
+
+	selector.NodeSelectorTerms = terms
+}
+
+// Checks if the term already has one of the location requirements.
+func hasLocationRequirement(term corev1.NodeSelectorTerm, requirements []corev1.NodeSelectorRequirement) bool {
+	for _, expression := range term.MatchExpressions {
+		for _, requirement := range requirements {
+			if reflect.DeepEqual(expression, requirement) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Checks that the pod can only be scheduled on the nodes in the allowed locations. Either the node selector of the
+// pod selects an allowed location, or every term of its required node affinity has a requirement selecting only the
+// allowed locations. A location is allowed if its country or its continent is allowed, the same as the terms added
+// by addLocationAffinity and isNodeLocationAllowed. The pods the mutating webhook skipped are rejected this way.
+func validateLocationAffinity(pod *corev1.Pod, restriction *multitenancyv1.LocationRestriction) error {
+	if len(locationRequirements(restriction)) == 0 {
+		return nil
+	}
+
+	for key, value := range pod.Spec.NodeSelector {
+		if isLocationAllowed(restriction, key, value) {
+			return nil
+		}
+	}
+
+	terms := []corev1.NodeSelectorTerm{}
+	if pod.Spec.Affinity != nil && pod.Spec.Affinity.NodeAffinity != nil && pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+		terms = pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	}
+
+	if len(terms) == 0 {
+		return fmt.Errorf("neither the node selector nor the node affinity selects an allowed location")
+	}
+
+	for i, term := range terms {
+		if !hasAllowedLocationRequirement(term, restriction) {
+			return fmt.Errorf("node affinity term %d doesn't select an allowed location", i)
+		}
+	}
+
+	return nil
+}
+
+// Checks if one of the requirements of the term only selects the nodes in the allowed locations.
+func hasAllowedLocationRequirement(term corev1.NodeSelectorTerm, restriction *multitenancyv1.LocationRestriction) bool {
+	for _, expression := range term.MatchExpressions {
+		if expression.Operator != corev1.NodeSelectorOpIn || len(expression.Values) == 0 {
+			continue
+		}
+
+		allowed := true
+		for _, value := range expression.Values {
+			if !isLocationAllowed(restriction, expression.Key, value) {
+				allowed = false
+				break
+			}
+		}
+		if allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// Checks if the node is in one of the allowed locations.
+func isNodeLocationAllowed(node *corev1.Node, restriction *multitenancyv1.LocationRestriction) bool {
+	if len(locationRequirements(restriction)) == 0 {
+		return true
+	}
+
+	for key, value := range node.GetLabels() {
+		if isLocationAllowed(restriction, key, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// Checks if the value of the location label is allowed, the labels other than the country and the continent are
+// never allowed.
+func isLocationAllowed(restriction *multitenancyv1.LocationRestriction, key, value string) bool {
+	switch key {
+	case labeller.CountryLabel:
+		return contains(restriction.Countries, value)
+	case labeller.ContinentLabel:
+		return contains(restriction.Continents, value)
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
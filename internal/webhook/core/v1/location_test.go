@@ -0,0 +1,109 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/labeller/v1"
+)
+
+var _ = Describe("Location Restriction", func() {
+	restriction := &multitenancyv1.LocationRestriction{
+		Countries:  []string{"FR", "DE"},
+		Continents: []string{"Europe"},
+	}
+
+	It("should add one term per alternative location", func() {
+		pod := &corev1.Pod{}
+		addLocationAffinity(pod, restriction)
+
+		terms := pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+		Expect(terms).To(HaveLen(2))
+		Expect(terms[0].MatchExpressions).To(ConsistOf(HaveField("Key", labeller.CountryLabel)))
+		Expect(terms[1].MatchExpressions).To(ConsistOf(HaveField("Key", labeller.ContinentLabel)))
+	})
+
+	It("should restrict the existing terms only once", func() {
+		hostname := corev1.NodeSelectorRequirement{Key: "kubernetes.io/hostname", Operator: corev1.NodeSelectorOpIn, Values: []string{"node-1"}}
+		pod := &corev1.Pod{Spec: corev1.PodSpec{Affinity: &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{MatchExpressions: []corev1.NodeSelectorRequirement{hostname}}},
+			},
+		}}}}
+		addLocationAffinity(pod, restriction)
+		addLocationAffinity(pod, restriction)
+
+		terms := pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+		Expect(terms).To(HaveLen(2))
+		for _, term := range terms {
+			Expect(term.MatchExpressions).To(HaveLen(2))
+			Expect(term.MatchExpressions[0]).To(Equal(hostname))
+		}
+	})
+
+	It("should reject the pods not restricted to the allowed locations", func() {
+		Expect(validateLocationAffinity(&corev1.Pod{}, restriction)).NotTo(Succeed())
+
+		pod := &corev1.Pod{Spec: corev1.PodSpec{NodeSelector: map[string]string{labeller.CountryLabel: "US"}}}
+		Expect(validateLocationAffinity(pod, restriction)).NotTo(Succeed())
+
+		pod = &corev1.Pod{}
+		addLocationAffinity(pod, restriction)
+		Expect(validateLocationAffinity(pod, restriction)).To(Succeed())
+
+		selector := pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+		selector.NodeSelectorTerms = append(selector.NodeSelectorTerms, corev1.NodeSelectorTerm{
+			MatchExpressions: []corev1.NodeSelectorRequirement{{Key: labeller.ContinentLabel, Operator: corev1.NodeSelectorOpIn, Values: []string{"Europe", "Asia"}}},
+		})
+		Expect(validateLocationAffinity(pod, restriction)).NotTo(Succeed())
+	})
+
+	It("should allow the country or the continent", func() {
+		// The node selector selects an allowed continent, the country doesn't have to be allowed as well.
+		pod := &corev1.Pod{Spec: corev1.PodSpec{NodeSelector: map[string]string{labeller.ContinentLabel: "Europe"}}}
+		Expect(validateLocationAffinity(pod, restriction)).To(Succeed())
+
+		pod = &corev1.Pod{Spec: corev1.PodSpec{NodeSelector: map[string]string{labeller.CountryLabel: "IT"}}}
+		addLocationAffinity(pod, restriction)
+		Expect(validateLocationAffinity(pod, restriction)).To(Succeed())
+
+		italy := corev1.NodeSelectorRequirement{Key: labeller.CountryLabel, Operator: corev1.NodeSelectorOpIn, Values: []string{"IT"}}
+		pod = &corev1.Pod{Spec: corev1.PodSpec{Affinity: &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{MatchExpressions: []corev1.NodeSelectorRequirement{italy}}},
+			},
+		}}}}
+		Expect(validateLocationAffinity(pod, restriction)).NotTo(Succeed())
+		addLocationAffinity(pod, restriction)
+		Expect(validateLocationAffinity(pod, restriction)).To(Succeed())
+	})
+
+	It("should check the location of the nodes", func() {
+		node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+			labeller.CountryLabel:   "IT",
+			labeller.ContinentLabel: "Europe",
+		}}}
+		Expect(isNodeLocationAllowed(node, restriction)).To(BeTrue())
+		Expect(isNodeLocationAllowed(node, &multitenancyv1.LocationRestriction{Countries: []string{"FR"}})).To(BeFalse())
+		Expect(isNodeLocationAllowed(&corev1.Node{}, restriction)).To(BeFalse())
+	})
+})
@@ -0,0 +1,245 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/labeller/v1"
+)
+
+// podlog is for logging in this package.
+var podlog = logf.Log.WithName("pod-resource")
+
+// SetupPodWebhookWithManager registers the webhook for Pod in the manager.
+func SetupPodWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&corev1.Pod{}).
+		WithDefaulter(&PodCustomDefaulter{Client: mgr.GetClient()}).
+		WithValidator(&PodCustomValidator{Client: mgr.GetClient()}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/mutate--v1-pod,mutating=true,failurePolicy=ignore,sideEffects=None,groups="",resources=pods,verbs=create,versions=v1,name=mpod.edge-net.io,admissionReviewVersions=v1
+
+// PodCustomDefaulter sets the scheduling of the pods. The pods tolerate the taints of the slices claimed by their
+// namespace. The pods of a tenant tolerate the taints of the nodes owned by the tenant and prefer these nodes. If
+// the tenant restricts the locations of its pods, the pods are only scheduled on the nodes in these locations.
+type PodCustomDefaulter struct {
+	Client client.Client
+}
+
+var _ webhook.CustomDefaulter = &PodCustomDefaulter{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the Kind Pod. The webhook
+// fails open, if the tenant cannot be resolved the pod is admitted as it is and the PodCustomValidator rejects it
+// if its tenant restricts the locations.
+func (d *PodCustomDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return fmt.Errorf("expected a Pod object but got %T", obj)
+	}
+
+	if err := addSliceTolerations(ctx, d.Client, pod, podNamespace(ctx, pod)); err != nil {
+		podlog.Error(err, "cannot add the tolerations of the slices to the pod", "namespace", pod.GetNamespace())
+	}
+
+	tenant, err := getPodTenant(ctx, d.Client, pod)
+	if err != nil {
+		podlog.Error(err, "cannot get the tenant of the pod", "namespace", pod.GetNamespace())
+		return nil
+	}
+
+	if tenant == nil {
+		return nil
+	}
+
+	if labeller.OwnerTaint(tenant.GetName(), tenant.Spec.NodeScheduling) != nil {
+		addOwnerScheduling(pod, tenant.GetName())
+	}
+
+	if tenant.Spec.AllowedLocations != nil {
+		addLocationAffinity(pod, tenant.Spec.AllowedLocations)
+	}
+
+	return nil
+}
+
+//+kubebuilder:webhook:path=/validate--v1-pod,mutating=false,failurePolicy=fail,sideEffects=None,groups="",resources=pods,verbs=create,versions=v1,name=vpod.edge-net.io,admissionReviewVersions=v1
+
+// PodCustomValidator rejects the pods tolerating the slices of the other namespaces, the pods of a tenant tolerating
+// the nodes owned by the other tenants, and the pods that
+// can be scheduled on the nodes outside of the locations allowed to their tenant, including the pods the
+// PodCustomDefaulter could not restrict.
+type PodCustomValidator struct {
+	Client client.Client
+}
+
+var _ webhook.CustomValidator = &PodCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type Pod.
+func (v *PodCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return nil, fmt.Errorf("expected a Pod object but got %T", obj)
+	}
+
+	if err := validateSliceTolerations(ctx, v.Client, pod, podNamespace(ctx, pod)); err != nil {
+		return nil, err
+	}
+
+	tenant, err := getPodTenant(ctx, v.Client, pod)
+	if err != nil {
+		return nil, err
+	}
+
+	if tenant == nil {
+		return nil, nil
+	}
+
+	if err := validateOwnerTolerations(pod, tenant.GetName()); err != nil {
+		return nil, fmt.Errorf("pod violates the node ownership of tenant %s: %w", tenant.GetName(), err)
+	}
+
+	if tenant.Spec.AllowedLocations == nil {
+		return nil, nil
+	}
+
+	// The pods bound to a node directly are not scheduled, the node itself should be in an allowed location.
+	if pod.Spec.NodeName != "" {
+		node := &corev1.Node{}
+		if err := v.Client.Get(ctx, types.NamespacedName{Name: pod.Spec.NodeName}, node); err != nil {
+			return nil, err
+		}
+		if !isNodeLocationAllowed(node, tenant.Spec.AllowedLocations) {
+			return nil, fmt.Errorf("node %s is not in a location allowed to tenant %s", node.GetName(), tenant.GetName())
+		}
+		return nil, nil
+	}
+
+	if err := validateLocationAffinity(pod, tenant.Spec.AllowedLocations); err != nil {
+		return nil, fmt.Errorf("pod violates the location restriction of tenant %s: %w", tenant.GetName(), err)
+	}
+
+	return nil, nil
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type Pod. The
+// scheduling of the pods cannot be changed after they are created.
+func (v *PodCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type Pod.
+func (v *PodCustomValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// Returns the namespace of the pod. It might not be set in the object, it is taken from the request.
+func podNamespace(ctx context.Context, pod *corev1.Pod) string {
+	if req, err := admission.RequestFromContext(ctx); err == nil && req.Namespace != "" {
+		return req.Namespace
+	}
+	return pod.GetNamespace()
+}
+
+// Returns the tenant owning the namespace of the pod, nil if the namespace doesn't belong to a tenant.
+func getPodTenant(ctx context.Context, c client.Client, pod *corev1.Pod) (*multitenancyv1.Tenant, error) {
+	namespace := &corev1.Namespace{}
+	if err := c.Get(ctx, types.NamespacedName{Name: podNamespace(ctx, pod)}, namespace); err != nil {
+		return nil, err
+	}
+
+	tenantName, ok := namespace.GetLabels()["edge-net.io/tenant"]
+	if !ok {
+		return nil, nil
+	}
+
+	tenant := &multitenancyv1.Tenant{}
+	if err := c.Get(ctx, types.NamespacedName{Name: tenantName}, tenant); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return tenant, nil
+}
+
+// Adds the toleration of the owner taint and the preference for the nodes of the tenant to the pod. Nothing is
+// added twice, so the pods that already have them are left as they are.
+func addOwnerScheduling(pod *corev1.Pod, tenant string) {
+	toleration := labeller.OwnerToleration(tenant)
+
+	tolerated := false
+	for _, t := range pod.Spec.Tolerations {
+		if t.MatchToleration(&toleration) {
+			tolerated = true
+			break
+		}
+	}
+	if !tolerated {
+		pod.Spec.Tolerations = append(pod.Spec.Tolerations, toleration)
+	}
+
+	term := labeller.OwnerPreferredSchedulingTerm(tenant)
+
+	if pod.Spec.Affinity == nil {
+		pod.Spec.Affinity = &corev1.Affinity{}
+	}
+	if pod.Spec.Affinity.NodeAffinity == nil {
+		pod.Spec.Affinity.NodeAffinity = &corev1.NodeAffinity{}
+	}
+
+	nodeAffinity := pod.Spec.Affinity.NodeAffinity
+	for _, preferred := range nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution {
+		for _, expression := range preferred.Preference.MatchExpressions {
+			if expression.Key == term.Preference.MatchExpressions[0].Key {
+				return
+			}
+		}
+	}
+
+	nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(nodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution, term)
+}
+
+// Checks that the pod only tolerates the owner taint of its own tenant. The tolerations of the owner taint with
+// another value or with the Exists operator, and the wildcard tolerations tolerating every taint are rejected.
+func validateOwnerTolerations(pod *corev1.Pod, tenant string) error {
+	for _, toleration := range pod.Spec.Tolerations {
+		switch {
+		case toleration.Key == "" && toleration.Operator == corev1.TolerationOpExists:
+			return fmt.Errorf("the toleration of every taint is not allowed")
+		case toleration.Key != labeller.OwnerTaintKey:
+			continue
+		case toleration.Operator == corev1.TolerationOpExists || toleration.Value != tenant:
+			return fmt.Errorf("toleration %s=%s is not allowed", toleration.Key, toleration.Value)
+		}
+	}
+	return nil
+}
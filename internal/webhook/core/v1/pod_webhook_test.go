@@ -0,0 +1,73 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/edgenet-project/edgenet/internal/labeller/v1"
+)
+
+var _ = Describe("Pod Webhook", func() {
+	It("should add the toleration and the node preference of the tenant", func() {
+		pod := &corev1.Pod{}
+		addOwnerScheduling(pod, "lab")
+
+		Expect(pod.Spec.Tolerations).To(ConsistOf(labeller.OwnerToleration("lab")))
+		Expect(pod.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution).To(ConsistOf(labeller.OwnerPreferredSchedulingTerm("lab")))
+
+		taint := labeller.OwnerTaint("lab", "Exclusive")
+		Expect(pod.Spec.Tolerations[0].ToleratesTaint(taint)).To(BeTrue())
+	})
+
+	It("should not add them twice", func() {
+		pod := &corev1.Pod{}
+		addOwnerScheduling(pod, "lab")
+		addOwnerScheduling(pod, "lab")
+
+		Expect(pod.Spec.Tolerations).To(HaveLen(1))
+		Expect(pod.Spec.Affinity.NodeAffinity.PreferredDuringSchedulingIgnoredDuringExecution).To(HaveLen(1))
+	})
+
+	It("should not tolerate the nodes of the other tenants", func() {
+		pod := &corev1.Pod{}
+		addOwnerScheduling(pod, "lab")
+
+		Expect(pod.Spec.Tolerations[0].ToleratesTaint(labeller.OwnerTaint("other", "Exclusive"))).To(BeFalse())
+		Expect(labeller.OwnerTaint("lab", "Shared")).To(BeNil())
+	})
+
+	It("should reject the tolerations of the nodes of the other tenants", func() {
+		pod := &corev1.Pod{}
+		addOwnerScheduling(pod, "lab")
+		Expect(validateOwnerTolerations(pod, "lab")).To(Succeed())
+
+		for _, toleration := range []corev1.Toleration{
+			labeller.OwnerToleration("other"),
+			{Key: labeller.OwnerTaintKey, Operator: corev1.TolerationOpExists},
+			{Operator: corev1.TolerationOpExists},
+		} {
+			pod := &corev1.Pod{Spec: corev1.PodSpec{Tolerations: []corev1.Toleration{toleration}}}
+			Expect(validateOwnerTolerations(pod, "lab")).NotTo(Succeed())
+		}
+
+		pod = &corev1.Pod{Spec: corev1.PodSpec{Tolerations: []corev1.Toleration{{Key: "node.kubernetes.io/not-ready", Operator: corev1.TolerationOpExists}}}}
+		Expect(validateOwnerTolerations(pod, "lab")).To(Succeed())
+	})
+})
@@ -0,0 +1,90 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/multitenancy/v1"
+)
+
+// Adds the tolerations of the slices claimed by the namespace to the pod, so the pods can run on the reserved
+// nodes. The expired and the failed slices don't have nodes anymore, they are skipped. Nothing is added twice.
+func addSliceTolerations(ctx context.Context, c client.Client, pod *corev1.Pod, namespace string) error {
+	sliceList := &multitenancyv1.SliceList{}
+	if err := c.List(ctx, sliceList); err != nil {
+		return err
+	}
+
+	for i := range sliceList.Items {
+		slice := &sliceList.Items[i]
+		if slice.Spec.ClaimRef.Namespace != namespace ||
+			slice.Status.State == multitenancyv1.SliceStateExpired || slice.Status.State == multitenancyv1.SliceStateFailed {
+			continue
+		}
+
+		toleration := multitenancy.SliceToleration(slice)
+		tolerated := false
+		for _, t := range pod.Spec.Tolerations {
+			if t.MatchToleration(&toleration) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			pod.Spec.Tolerations = append(pod.Spec.Tolerations, toleration)
+		}
+	}
+
+	return nil
+}
+
+// Checks that the pod only tolerates the slices claimed by its namespace. The tolerations can be written in the pod
+// or added from the default tolerations of the namespace, in both cases the toleration of a slice claimed by another
+// namespace, or of every slice with the Exists operator, is rejected. The tolerations of the deleted slices are
+// harmless since the names of the slices are never reused.
+func validateSliceTolerations(ctx context.Context, c client.Client, pod *corev1.Pod, namespace string) error {
+	for _, toleration := range pod.Spec.Tolerations {
+		if toleration.Key != multitenancyv1.SliceLabel {
+			continue
+		}
+
+		if toleration.Operator == corev1.TolerationOpExists {
+			return fmt.Errorf("the toleration of every slice is not allowed")
+		}
+
+		slice := &multitenancyv1.Slice{}
+		if err := c.Get(ctx, types.NamespacedName{Name: toleration.Value}, slice); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+
+		if slice.Spec.ClaimRef.Namespace != namespace {
+			return fmt.Errorf("slice %s is not claimed by namespace %s", slice.GetName(), namespace)
+		}
+	}
+	return nil
+}
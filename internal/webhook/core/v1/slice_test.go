@@ -0,0 +1,76 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+)
+
+var _ = Describe("Slice Tolerations", func() {
+	ctx := context.Background()
+
+	scheme := runtime.NewScheme()
+	Expect(multitenancyv1.AddToScheme(scheme)).To(Succeed())
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(&multitenancyv1.Slice{
+		ObjectMeta: metav1.ObjectMeta{Name: "alpha"},
+		Spec:       multitenancyv1.SliceSpec{ClaimRef: multitenancyv1.SliceClaimReference{Namespace: "lab", Name: "claim"}},
+	}, &multitenancyv1.Slice{
+		ObjectMeta: metav1.ObjectMeta{Name: "beta"},
+		Spec:       multitenancyv1.SliceSpec{ClaimRef: multitenancyv1.SliceClaimReference{Namespace: "lab", Name: "old-claim"}},
+		Status:     multitenancyv1.SliceStatus{State: multitenancyv1.SliceStateExpired},
+	}, &multitenancyv1.Slice{
+		ObjectMeta: metav1.ObjectMeta{Name: "gamma"},
+		Spec:       multitenancyv1.SliceSpec{ClaimRef: multitenancyv1.SliceClaimReference{Namespace: "other", Name: "claim"}},
+	}).Build()
+
+	tolerating := func(toleration corev1.Toleration) *corev1.Pod {
+		return &corev1.Pod{Spec: corev1.PodSpec{Tolerations: []corev1.Toleration{toleration}}}
+	}
+
+	It("should only admit the tolerations of the slices of the namespace", func() {
+		toleration := corev1.Toleration{Key: multitenancyv1.SliceLabel, Operator: corev1.TolerationOpEqual, Value: "alpha", Effect: corev1.TaintEffectNoSchedule}
+		Expect(validateSliceTolerations(ctx, c, tolerating(toleration), "lab")).To(Succeed())
+		Expect(validateSliceTolerations(ctx, c, tolerating(toleration), "other")).NotTo(Succeed())
+
+		toleration.Value = "deleted"
+		Expect(validateSliceTolerations(ctx, c, tolerating(toleration), "other")).To(Succeed())
+
+		Expect(validateSliceTolerations(ctx, c, tolerating(corev1.Toleration{Key: multitenancyv1.SliceLabel, Operator: corev1.TolerationOpExists}), "lab")).NotTo(Succeed())
+	})
+
+	It("should add the tolerations of the slices claimed by the namespace", func() {
+		toleration := corev1.Toleration{Key: multitenancyv1.SliceLabel, Operator: corev1.TolerationOpEqual, Value: "alpha", Effect: corev1.TaintEffectNoSchedule}
+
+		pod := &corev1.Pod{}
+		Expect(addSliceTolerations(ctx, c, pod, "lab")).To(Succeed())
+		Expect(pod.Spec.Tolerations).To(Equal([]corev1.Toleration{toleration}))
+
+		By("not adding the toleration twice")
+		Expect(addSliceTolerations(ctx, c, pod, "lab")).To(Succeed())
+		Expect(pod.Spec.Tolerations).To(HaveLen(1))
+		Expect(validateSliceTolerations(ctx, c, pod, "lab")).To(Succeed())
+	})
+})
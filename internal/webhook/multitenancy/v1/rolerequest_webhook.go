@@ -0,0 +1,175 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/multitenancy/v1"
+)
+
+// The members of this group are the cluster admins, they can request and approve any role.
+const systemMastersGroup = "system:masters"
+
+// SetupRoleRequestWebhookWithManager registers the webhook for RoleRequest in the manager.
+func SetupRoleRequestWebhookWithManager(mgr ctrl.Manager, subjects multitenancy.SubjectMapper) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&multitenancyv1.RoleRequest{}).
+		WithValidator(&RoleRequestCustomValidator{Client: mgr.GetClient(), Subjects: subjects}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-multitenancy-edge-net-io-v1-rolerequest,mutating=false,failurePolicy=fail,sideEffects=None,groups=multitenancy.edge-net.io,resources=rolerequests;rolerequests/status,verbs=create;update,versions=v1,name=vrolerequest.edge-net.io,admissionReviewVersions=v1
+
+// RoleRequestCustomValidator ties the subject of a role request to the user creating it, and only lets the admins
+// and the owners of the tenant with a role at least as privileged as the requested one decide on it. The cluster admins are not
+// restricted.
+type RoleRequestCustomValidator struct {
+	Client   client.Client
+	Subjects multitenancy.SubjectMapper
+}
+
+var _ webhook.CustomValidator = &RoleRequestCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type RoleRequest.
+func (v *RoleRequestCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	roleRequest, ok := obj.(*multitenancyv1.RoleRequest)
+	if !ok {
+		return nil, fmt.Errorf("expected a RoleRequest object but got %T", obj)
+	}
+
+	user, err := requestUser(ctx)
+	if err != nil || isClusterAdmin(user) {
+		return nil, err
+	}
+
+	subject, err := v.subject(roleRequest)
+	if err != nil {
+		return nil, err
+	}
+	if !multitenancy.IsUserSubject(user, subject) {
+		return nil, fmt.Errorf("user %s cannot request a role for the %s %s", user.Username, subject.Kind, subject.Name)
+	}
+
+	return nil, nil
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type RoleRequest. The
+// spec is immutable, so only the changes of the decision in the status are checked.
+func (v *RoleRequestCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldRoleRequest, ok := oldObj.(*multitenancyv1.RoleRequest)
+	if !ok {
+		return nil, fmt.Errorf("expected a RoleRequest object but got %T", oldObj)
+	}
+	roleRequest, ok := newObj.(*multitenancyv1.RoleRequest)
+	if !ok {
+		return nil, fmt.Errorf("expected a RoleRequest object but got %T", newObj)
+	}
+
+	// The state can only be set to approved by the controller after the decision.
+	if roleRequest.Status.State == multitenancyv1.RoleRequestStateApproved && roleRequest.Status.Decision != multitenancyv1.RoleRequestStateApproved {
+		return nil, fmt.Errorf("role request %s cannot be approved without the decision", roleRequest.GetName())
+	}
+
+	if oldRoleRequest.Status.Decision == roleRequest.Status.Decision {
+		return nil, nil
+	}
+
+	user, err := requestUser(ctx)
+	if err != nil || isClusterAdmin(user) {
+		return nil, err
+	}
+
+	return nil, v.validateApprover(ctx, roleRequest, user)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type RoleRequest.
+func (v *RoleRequestCustomValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// Checks that the user deciding on the role request is not its subject, is an admin or an owner of the tenant, and
+// has a role at least as privileged as the requested one.
+func (v *RoleRequestCustomValidator) validateApprover(ctx context.Context, roleRequest *multitenancyv1.RoleRequest, user authenticationv1.UserInfo) error {
+	subject, err := v.subject(roleRequest)
+	if err != nil {
+		return err
+	}
+	if multitenancy.IsUserSubject(user, subject) {
+		return fmt.Errorf("user %s cannot decide on its own role request", user.Username)
+	}
+
+	// Role requests are created in the core namespace, which has the name of the tenant.
+	tenant := &multitenancyv1.Tenant{}
+	if err := v.Client.Get(ctx, types.NamespacedName{Name: roleRequest.GetNamespace()}, tenant); err != nil {
+		return err
+	}
+
+	role, ok, err := v.Subjects.TenantRoleOf(tenant, user)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("user %s is not a member of the tenant %s", user.Username, tenant.GetName())
+	}
+	if !role.Includes(multitenancyv1.TenantRoleAdmin) {
+		return fmt.Errorf("user %s with the %s role cannot decide on role requests", user.Username, role)
+	}
+	if !role.Includes(roleRequest.Spec.Role) {
+		return fmt.Errorf("user %s with the %s role cannot decide on a request for the %s role", user.Username, role, roleRequest.Spec.Role)
+	}
+
+	return nil
+}
+
+// Maps the subject of the role request, the same way the role bindings are created.
+func (v *RoleRequestCustomValidator) subject(roleRequest *multitenancyv1.RoleRequest) (rbacv1.Subject, error) {
+	kind := roleRequest.Spec.Kind
+	if kind == "" {
+		kind = rbacv1.UserKind
+	}
+	return v.Subjects.MapSubject(kind, roleRequest.Spec.Subject, "")
+}
+
+// Returns the user sending the admission request.
+func requestUser(ctx context.Context) (authenticationv1.UserInfo, error) {
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return authenticationv1.UserInfo{}, err
+	}
+	return req.UserInfo, nil
+}
+
+// Returns true if the user is a cluster admin.
+func isClusterAdmin(user authenticationv1.UserInfo) bool {
+	for _, group := range user.Groups {
+		if group == systemMastersGroup {
+			return true
+		}
+	}
+	return false
+}
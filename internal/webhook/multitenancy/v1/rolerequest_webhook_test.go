@@ -0,0 +1,124 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/multitenancy/v1"
+)
+
+var _ = Describe("RoleRequest Webhook", func() {
+	asUser := func(username string, groups ...string) context.Context {
+		return admission.NewContextWithRequest(context.Background(), admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{
+				UserInfo: authenticationv1.UserInfo{Username: username, Groups: groups},
+			},
+		})
+	}
+
+	roleRequest := func(subject string, role multitenancyv1.TenantRole) *multitenancyv1.RoleRequest {
+		return &multitenancyv1.RoleRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: "request", Namespace: "lab"},
+			Spec:       multitenancyv1.RoleRequestSpec{Subject: subject, Role: role},
+		}
+	}
+
+	newValidator := func() *RoleRequestCustomValidator {
+		scheme := runtime.NewScheme()
+		Expect(multitenancyv1.AddToScheme(scheme)).To(Succeed())
+
+		tenant := &multitenancyv1.Tenant{
+			ObjectMeta: metav1.ObjectMeta{Name: "lab"},
+			Spec: multitenancyv1.TenantSpec{
+				Admin: "alice",
+				Members: []multitenancyv1.TenantMember{
+					{Name: "carol", Kind: "User", Role: multitenancyv1.TenantRoleOwner},
+					{Name: "dave", Kind: "User", Role: multitenancyv1.TenantRoleMember},
+				},
+			},
+		}
+		return &RoleRequestCustomValidator{
+			Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(tenant).Build(),
+			Subjects: multitenancy.SubjectMapper{UsernamePrefix: "oidc:", GroupPrefix: "oidc:"},
+		}
+	}
+
+	decide := func(validator *RoleRequestCustomValidator, ctx context.Context, request *multitenancyv1.RoleRequest, decision string) error {
+		decided := request.DeepCopy()
+		decided.Status.Decision = decision
+		_, err := validator.ValidateUpdate(ctx, request, decided)
+		return err
+	}
+
+	It("should only admit the requests of the requester", func() {
+		validator := newValidator()
+
+		_, err := validator.ValidateCreate(asUser("oidc:bob"), roleRequest("bob", multitenancyv1.TenantRoleMember))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = validator.ValidateCreate(asUser("oidc:bob"), roleRequest("mallory", multitenancyv1.TenantRoleOwner))
+		Expect(err).To(MatchError(ContainSubstring("cannot request a role")))
+
+		group := roleRequest("researchers", multitenancyv1.TenantRoleMember)
+		group.Spec.Kind = "Group"
+		_, err = validator.ValidateCreate(asUser("oidc:bob", "oidc:researchers"), group)
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = validator.ValidateCreate(asUser("kubernetes-admin", systemMastersGroup), roleRequest("mallory", multitenancyv1.TenantRoleOwner))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should only admit the decisions of the privileged members", func() {
+		validator := newValidator()
+
+		Expect(decide(validator, asUser("oidc:alice"), roleRequest("bob", multitenancyv1.TenantRoleManager), multitenancyv1.RoleRequestStateApproved)).To(Succeed())
+		Expect(decide(validator, asUser("oidc:carol"), roleRequest("bob", multitenancyv1.TenantRoleOwner), multitenancyv1.RoleRequestStateDenied)).To(Succeed())
+
+		err := decide(validator, asUser("oidc:alice"), roleRequest("bob", multitenancyv1.TenantRoleOwner), multitenancyv1.RoleRequestStateApproved)
+		Expect(err).To(MatchError(ContainSubstring("with the admin role cannot decide")))
+
+		err = decide(validator, asUser("oidc:dave"), roleRequest("bob", multitenancyv1.TenantRoleMember), multitenancyv1.RoleRequestStateApproved)
+		Expect(err).To(MatchError(ContainSubstring("with the member role cannot decide on role requests")))
+
+		err = decide(validator, asUser("oidc:mallory"), roleRequest("bob", multitenancyv1.TenantRoleMember), multitenancyv1.RoleRequestStateApproved)
+		Expect(err).To(MatchError(ContainSubstring("is not a member of the tenant")))
+
+		err = decide(validator, asUser("oidc:carol"), roleRequest("carol", multitenancyv1.TenantRoleOwner), multitenancyv1.RoleRequestStateApproved)
+		Expect(err).To(MatchError(ContainSubstring("its own role request")))
+	})
+
+	It("should not admit an approved state without the decision", func() {
+		validator := newValidator()
+
+		request := roleRequest("bob", multitenancyv1.TenantRoleMember)
+		approved := request.DeepCopy()
+		approved.Status.State = multitenancyv1.RoleRequestStateApproved
+		_, err := validator.ValidateUpdate(asUser("oidc:bob"), request, approved)
+		Expect(err).To(MatchError(ContainSubstring("cannot be approved without the decision")))
+	})
+})
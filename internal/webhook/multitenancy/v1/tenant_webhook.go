@@ -0,0 +1,140 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/multitenancy/v1"
+)
+
+// SetupTenantWebhookWithManager registers the webhook for Tenant in the manager.
+func SetupTenantWebhookWithManager(mgr ctrl.Manager, subjects multitenancy.SubjectMapper) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&multitenancyv1.Tenant{}).
+		WithValidator(&TenantCustomValidator{Client: mgr.GetClient(), Subjects: subjects}).
+		Complete()
+}
+
+//+kubebuilder:webhook:path=/validate-multitenancy-edge-net-io-v1-tenant,mutating=false,failurePolicy=fail,sideEffects=None,groups=multitenancy.edge-net.io,resources=tenants,verbs=create;update,versions=v1,name=vtenant.edge-net.io,admissionReviewVersions=v1
+
+// TenantCustomValidator rejects the admins and members that cannot be mapped to RBAC subjects and enforces the
+// allocation policy of the cluster on the initial requests of the tenants. The initial requests are admitted as they
+// are if there is no allocation policy.
+type TenantCustomValidator struct {
+	Client   client.Client
+	Subjects multitenancy.SubjectMapper
+}
+
+var _ webhook.CustomValidator = &TenantCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type Tenant.
+func (v *TenantCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	tenant, ok := obj.(*multitenancyv1.Tenant)
+	if !ok {
+		return nil, fmt.Errorf("expected a Tenant object but got %T", obj)
+	}
+
+	if err := v.validateSubjects(tenant); err != nil {
+		return nil, err
+	}
+
+	return nil, v.validateAllocation(ctx, tenant, nil)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type Tenant. Only the
+// subjects and the changes of the initial request are checked, the other changes such as a suspension are always
+// admitted.
+func (v *TenantCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	oldTenant, ok := oldObj.(*multitenancyv1.Tenant)
+	if !ok {
+		return nil, fmt.Errorf("expected a Tenant object but got %T", oldObj)
+	}
+	tenant, ok := newObj.(*multitenancyv1.Tenant)
+	if !ok {
+		return nil, fmt.Errorf("expected a Tenant object but got %T", newObj)
+	}
+
+	if err := v.validateSubjects(tenant); err != nil {
+		return nil, err
+	}
+
+	if equality.Semantic.DeepEqual(oldTenant.Spec.InitialRequest, tenant.Spec.InitialRequest) {
+		return nil, nil
+	}
+
+	return nil, v.validateAllocation(ctx, tenant, oldTenant.Spec.InitialRequest)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type Tenant.
+func (v *TenantCustomValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// Checks that the admin and the members of the tenant can be bound to the tenant roles.
+func (v *TenantCustomValidator) validateSubjects(tenant *multitenancyv1.Tenant) error {
+	if _, err := v.Subjects.TenantRoleSubjects(tenant); err != nil {
+		return fmt.Errorf("tenant %s has invalid subjects: %w", tenant.GetName(), err)
+	}
+	return nil
+}
+
+// Checks the initial request of the tenant against the allocation policy, the capacity of the nodes and the
+// requests of the other tenants.
+func (v *TenantCustomValidator) validateAllocation(ctx context.Context, tenant *multitenancyv1.Tenant, previous corev1.ResourceList) error {
+	policy := &multitenancyv1.AllocationPolicy{}
+	if err := v.Client.Get(ctx, types.NamespacedName{Name: multitenancyv1.AllocationPolicyName}, policy); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+
+	nodeList := &corev1.NodeList{}
+	if err := v.Client.List(ctx, nodeList); err != nil {
+		return err
+	}
+	capacity, _ := multitenancy.ClusterCapacity(nodeList.Items)
+
+	tenantList := &multitenancyv1.TenantList{}
+	if err := v.Client.List(ctx, tenantList); err != nil {
+		return err
+	}
+	others := make([]multitenancyv1.Tenant, 0, len(tenantList.Items))
+	for _, other := range tenantList.Items {
+		if other.GetName() != tenant.GetName() {
+			others = append(others, other)
+		}
+	}
+
+	if err := multitenancy.CheckTenantAllocation(policy, tenant, previous, others, capacity); err != nil {
+		return fmt.Errorf("tenant %s violates the allocation policy: %w", tenant.GetName(), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2024 Contributors to EdgeNet Project.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	multitenancyv1 "github.com/edgenet-project/edgenet/api/multitenancy/v1"
+	"github.com/edgenet-project/edgenet/internal/multitenancy/v1"
+)
+
+var _ = Describe("Tenant Webhook", func() {
+	ctx := context.Background()
+
+	tenant := func(name, cpu string) *multitenancyv1.Tenant {
+		return &multitenancyv1.Tenant{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: multitenancyv1.TenantSpec{
+				InitialRequest: map[corev1.ResourceName]resource.Quantity{corev1.ResourceCPU: resource.MustParse(cpu)},
+			},
+		}
+	}
+
+	newValidator := func(objects ...client.Object) *TenantCustomValidator {
+		scheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).To(Succeed())
+		Expect(multitenancyv1.AddToScheme(scheme)).To(Succeed())
+
+		node := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node"},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")},
+				Conditions:  []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+			},
+		}
+		objects = append(objects, node, tenant("alpha", "3"))
+		return &TenantCustomValidator{
+			Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+			Subjects: multitenancy.SubjectMapper{UsernamePrefix: "oidc:"},
+		}
+	}
+
+	policy := &multitenancyv1.AllocationPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: multitenancyv1.AllocationPolicyName},
+		Spec: multitenancyv1.AllocationPolicySpec{
+			OvercommitRatios: map[corev1.ResourceName]resource.Quantity{corev1.ResourceCPU: resource.MustParse("1")},
+		},
+	}
+
+	It("should admit the tenants without an allocation policy", func() {
+		_, err := newValidator().ValidateCreate(ctx, tenant("beta", "8"))
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should reject the tenants exceeding the headroom", func() {
+		validator := newValidator(policy.DeepCopy())
+
+		_, err := validator.ValidateCreate(ctx, tenant("beta", "1"))
+		Expect(err).NotTo(HaveOccurred())
+
+		_, err = validator.ValidateCreate(ctx, tenant("beta", "2"))
+		Expect(err).To(MatchError(ContainSubstring("tenant beta violates the allocation policy")))
+	})
+
+	It("should only check the changes of the initial request", func() {
+		validator := newValidator(policy.DeepCopy())
+
+		// The existing tenant is not counted twice when it is updated.
+		oldTenant := tenant("alpha", "3")
+		newTenant := tenant("alpha", "4")
+		_, err := validator.ValidateUpdate(ctx, oldTenant, newTenant)
+		Expect(err).NotTo(HaveOccurred())
+
+		oldTenant = tenant("alpha", "8")
+		newTenant = tenant("alpha", "8")
+		newTenant.Spec.Suspended = true
+		_, err = validator.ValidateUpdate(ctx, oldTenant, newTenant)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should reject the subjects that cannot be bound", func() {
+		validator := newValidator()
+
+		valid := tenant("beta", "1")
+		valid.Spec.Admin = "alice"
+		valid.Spec.Members = []multitenancyv1.TenantMember{{Kind: "Group", Name: "researchers", Role: multitenancyv1.TenantRoleMember}}
+		_, err := validator.ValidateCreate(ctx, valid)
+		Expect(err).NotTo(HaveOccurred())
+
+		invalid := valid.DeepCopy()
+		invalid.Spec.Admin = "serviceaccount:default"
+		_, err = validator.ValidateCreate(ctx, invalid)
+		Expect(err).To(MatchError(ContainSubstring("tenant beta has invalid subjects")))
+
+		invalid = valid.DeepCopy()
+		invalid.Spec.Members = append(invalid.Spec.Members, multitenancyv1.TenantMember{Kind: "ServiceAccount", Name: "Invalid_Name", Namespace: "default", Role: multitenancyv1.TenantRoleManager})
+		_, err = validator.ValidateUpdate(ctx, valid, invalid)
+		Expect(err).To(MatchError(ContainSubstring("invalid member \"Invalid_Name\"")))
+	})
+})